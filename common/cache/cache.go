@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Backend names
+const (
+	Redis  = "redis"
+	Memory = "memory"
+	Tiered = "tiered"
+)
+
+// returned by Get when key is not found or is expired
+var ErrNotFound = errors.New("cache: key not found")
+
+// Cache stores serialized objects by key for a limited amount of time
+type Cache interface {
+	// gets Object from Cache, returns ErrNotFound if key is missing or expired
+	Get(key string) (string, error)
+
+	// sets Object in Cache using key, ttl <= 0 uses the default expiration of the backend
+	Set(key, value string, ttl time.Duration) error
+
+	// removes key from Cache
+	Delete(key string) error
+
+	// removes all keys matching a glob pattern with the semantics of MatchPattern, returns number of keys removed
+	DeletePattern(pattern string) (int64, error)
+
+	// returns usage counters of this Cache
+	Stats() Stats
+}
+
+// represents the usage counters of a Cache backend, Size is the number of entries of in-process backends
+// and is omitted for Redis where entries expire on their own
+type Stats struct {
+	Backend   string `json:"backend"`
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+	Sets      int64  `json:"sets"`
+	Deletes   int64  `json:"deletes"`
+	Evictions int64  `json:"evictions"`
+	Errors    int64  `json:"errors"`
+	Size      int64  `json:"size,omitempty"`
+}
+
+// escapes glob special characters of s so it's matched literally in a pattern
+func EscapePattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+	return r.Replace(s)
+}
+
+// matches key against a glob pattern as Redis SCAN and KEYS do: * matches any sequence of characters including /,
+// ? any single character, [abc], [^abc] and [a-z] any character of the set and \ escapes the next character
+func MatchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if MatchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			match, rest := matchSet(pattern[1:], key[0])
+			if !match {
+				return false
+			}
+			pattern = rest
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			pattern = pattern[1:]
+		}
+		key = key[1:]
+	}
+	return len(key) == 0
+}
+
+// matches c against the set of a [...] pattern starting at p, returns the pattern left after the set
+func matchSet(p string, c byte) (bool, string) {
+	not := len(p) > 0 && p[0] == '^'
+	if not {
+		p = p[1:]
+	}
+
+	match := false
+	for len(p) > 0 && p[0] != ']' {
+		switch {
+		case p[0] == '\\' && len(p) > 1:
+			match = match || p[1] == c
+			p = p[2:]
+		case len(p) > 2 && p[1] == '-' && p[2] != ']':
+			low, high := p[0], p[2]
+			if low > high {
+				low, high = high, low
+			}
+			match = match || (c >= low && c <= high)
+			p = p[3:]
+		default:
+			match = match || p[0] == c
+			p = p[1:]
+		}
+	}
+	if len(p) > 0 {
+		p = p[1:]
+	}
+	return match != not, p
+}
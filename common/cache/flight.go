@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Locker is implemented by shared cache backends able to hold short lived locks visible to every app instance
+type Locker interface {
+	// tries to lock key for at most ttl, returns the token needed to unlock it and false if already locked
+	Lock(key string, ttl time.Duration) (string, bool, error)
+
+	// releases the lock of key if still held with token
+	Unlock(key, token string) error
+}
+
+// Group coalesces concurrent calls sharing the same key so only the first one runs
+// while the others wait and receive its result.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// represents an in-flight or completed call of a Group
+type call struct {
+	wg  sync.WaitGroup
+	val interface{}
+}
+
+// Builds a new Group
+func NewGroup() *Group {
+	return &Group{calls: make(map[string]*call)}
+}
+
+// runs fn once for all concurrent calls with the same key and returns its result,
+// shared is true if the result was produced by another caller.
+func (g *Group) Do(key string, fn func() interface{}) (v interface{}, shared bool) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, true
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		c.wg.Done()
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+	}()
+
+	c.val = fn()
+	return c.val, false
+}
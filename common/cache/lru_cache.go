@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"container/list"
+	"log"
+	"sync"
+	"time"
+)
+
+// LRUCache is a bounded in-process Cache, when full the least recently used entry is evicted.
+// Entries expire after their ttl and are removed lazily on access or when evicted.
+type LRUCache struct {
+	MaxEntries             int
+	CacheExpirationSeconds int
+	mu                     sync.Mutex
+	ll                     *list.List
+	items                  map[string]*list.Element
+	stats                  Stats
+}
+
+type lruEntry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+// Builds a new in-memory LRU Cache holding at most maxEntries
+func NewLRUCache(maxEntries, cacheExpirationSeconds int) *LRUCache {
+	log.Printf("New LRU Cache: %d entries", maxEntries)
+
+	return &LRUCache{
+		MaxEntries:             maxEntries,
+		CacheExpirationSeconds: cacheExpirationSeconds,
+		ll:                     list.New(),
+		items:                  make(map[string]*list.Element),
+	}
+}
+
+// get Object from Cache
+func (c *LRUCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return "", ErrNotFound
+	}
+
+	entry := e.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.removeElement(e)
+		c.stats.Misses++
+		return "", ErrNotFound
+	}
+
+	c.ll.MoveToFront(e)
+	c.stats.Hits++
+	return entry.value, nil
+}
+
+// sets Object in Cache using key, evicting the least recently used entry if full
+func (c *LRUCache) Set(key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = time.Second * time.Duration(c.CacheExpirationSeconds)
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Sets++
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		entry := e.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key, value, expires})
+
+	if c.MaxEntries > 0 && c.ll.Len() > c.MaxEntries {
+		c.removeElement(c.ll.Back())
+		c.stats.Evictions++
+	}
+	return nil
+}
+
+// removes Object from Cache
+func (c *LRUCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.removeElement(e)
+		c.stats.Deletes++
+	}
+	return nil
+}
+
+// removes all entries with keys matching pattern, matched as Redis does so every backend removes the same keys
+func (c *LRUCache) DeletePattern(pattern string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	for key, e := range c.items {
+		if MatchPattern(pattern, key) {
+			c.removeElement(e)
+			n++
+		}
+	}
+	c.stats.Deletes += n
+	return n, nil
+}
+
+// returns usage counters and current number of entries
+func (c *LRUCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats
+	s.Backend = Memory
+	s.Size = int64(c.ll.Len())
+	return s
+}
+
+func (c *LRUCache) removeElement(e *list.Element) {
+	c.ll.Remove(e)
+	delete(c.items, e.Value.(*lruEntry).key)
+}
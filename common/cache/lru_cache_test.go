@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// tests least recently used entry is evicted once max entries is reached
+func TestLRUCacheEviction(t *testing.T) {
+	c := NewLRUCache(2, 60)
+
+	c.Set("a", "1", 0)
+	c.Set("b", "2", 0)
+	c.Get("a")
+	c.Set("c", "3", 0)
+
+	_, err := c.Get("b")
+	assert.Equal(t, ErrNotFound, err)
+
+	val, err := c.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	s := c.Stats()
+	assert.Equal(t, int64(1), s.Evictions)
+	assert.Equal(t, int64(2), s.Size)
+}
+
+// tests entries are not returned after ttl
+func TestLRUCacheExpiration(t *testing.T) {
+	c := NewLRUCache(10, 60)
+
+	c.Set("a", "1", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := c.Get("a")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, int64(0), c.Stats().Size)
+
+	c.Set("a", "2", 0)
+	c.Delete("a")
+	_, err = c.Get("a")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+// tests L2 hits are stored in L1
+func TestTieredCache(t *testing.T) {
+	l1, l2 := NewLRUCache(10, 60), NewLRUCache(10, 60)
+	c := NewTieredCache(l1, l2, 30)
+
+	l2.Set("a", "1", 0)
+	val, err := c.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	val, err = l1.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	c.Delete("a")
+	_, err = l2.Get("a")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, int64(1), c.Stats().Hits)
+}
+
+// tests entries are removed by pattern and special characters are matched literally once escaped
+func TestLRUCacheDeletePattern(t *testing.T) {
+	c := NewLRUCache(10, 60)
+	c.Set("page:a:x", "1", 0)
+	c.Set("page:b:x", "1", 0)
+	c.Set("search:x*", "1", 0)
+	c.Set("search:xy", "1", 0)
+
+	n, err := c.DeletePattern("page:*")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, _ = c.DeletePattern(EscapePattern("search:x*"))
+	assert.Equal(t, int64(1), n)
+
+	_, err = c.Get("search:xy")
+	assert.Nil(t, err)
+
+	// * crosses / as in Redis
+	c.Set("detail:id/usa", "1", 0)
+	n, _ = c.DeletePattern("detail:*")
+	assert.Equal(t, int64(1), n)
+}
+
+// tests patterns are matched with Redis glob semantics
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"*", "", true},
+		{"page:*", "page:walmart.com/usa", true},
+		{"page:*:usa", "page:a/b:usa", true},
+		{"page:*:usa", "page:a/b:can", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`search:x\*`, "search:x*", true},
+		{`search:x\*`, "search:xy", false},
+		{`a[\]]b`, "a]b", true},
+		{"a**b", "a/x/b", true},
+		{"ab", "abc", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.match, MatchPattern(test.pattern, test.key), test.pattern+" "+test.key)
+	}
+}
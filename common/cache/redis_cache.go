@@ -1,44 +1,66 @@
 package cache
 
 import (
+	"crypto/tls"
 	"fmt"
 	"github.com/go-redis/redis"
+	"github.com/guilhebl/go-offer/common/util"
 	"log"
-	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// number of keys fetched on each SCAN call
+const scanBatchSize = 100
+
+// RedisCache stores entries in a shared Redis DB, every key is prefixed by Namespace
+// so entries of other apps or older versions of this app are never read or overwritten.
+// Redis errors are returned to the caller and counted in Stats, they never crash the app.
 type RedisCache struct {
 	Client                 *redis.Client
+	Namespace              string
 	CacheExpirationSeconds int
+	stats                  Stats
 }
 
-var redisCache *RedisCache
-var once sync.Once
+// Redis connection and key namespacing options
+type RedisOptions struct {
+	Host                   string
+	Port                   string
+	Password               string
+	DB                     int
+	TLS                    bool
+	PoolSize               int
+	KeyPrefix              string
+	KeyVersion             int
+	CacheExpirationSeconds int
+}
 
-func BuildInstance(host, port string, cacheExpirationSeconds int) *RedisCache {
-	once.Do(func() {
-		redisCache = newRedisCache(host, port, cacheExpirationSeconds)
-	})
-	return redisCache
+// builds a versioned key namespace such as "offer:v1:", bumping the version invalidates all previous entries
+func BuildNamespace(prefix string, version int) string {
+	return fmt.Sprintf("%s:v%d:", prefix, version)
 }
 
 // Builds a new Redis Cache
-func newRedisCache(host, port string, cacheExpirationSeconds int) *RedisCache {
+func NewRedisCache(opts RedisOptions) *RedisCache {
 	log.Printf("New Cache")
 
-	address := fmt.Sprintf("%s:%s", host, port)
+	address := fmt.Sprintf("%s:%s", opts.Host, opts.Port)
 
-	client := redis.NewClient(&redis.Options{
+	options := &redis.Options{
 		Addr:     address,
-		Password: "", // no password set
-		DB:       0,  // use default DB
-	})
+		Password: opts.Password,
+		DB:       opts.DB,
+		PoolSize: opts.PoolSize,
+	}
+	if opts.TLS {
+		options.TLSConfig = &tls.Config{ServerName: opts.Host}
+	}
 
-	client.FlushAll()
 	redisCache := RedisCache{
-		Client:                 client,
-		CacheExpirationSeconds: cacheExpirationSeconds,
+		Client:                 redis.NewClient(options),
+		Namespace:              BuildNamespace(opts.KeyPrefix, opts.KeyVersion),
+		CacheExpirationSeconds: opts.CacheExpirationSeconds,
 	}
 
 	return &redisCache
@@ -46,25 +68,125 @@ func newRedisCache(host, port string, cacheExpirationSeconds int) *RedisCache {
 
 // get Object from Cache
 func (r *RedisCache) Get(key string) (string, error) {
-	var err error
-
-	val, err := r.Client.Get(key).Result()
+	val, err := r.Client.Get(r.Namespace + key).Result()
 	if err == redis.Nil {
-		return "", err
+		atomic.AddInt64(&r.stats.Misses, 1)
+		return "", ErrNotFound
 	}
 	if err != nil {
-		panic(err)
+		return "", r.fail("get", err)
 	}
 
+	atomic.AddInt64(&r.stats.Hits, 1)
 	log.Printf("CACHE HIT for key %s", key)
 	return val, nil
 }
 
 // sets Object in Cache using key
-func (r *RedisCache) Set(key, json string) error {
-	err := r.Client.Set(key, json, time.Second*time.Duration(r.CacheExpirationSeconds)).Err()
+func (r *RedisCache) Set(key, json string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = time.Second * time.Duration(r.CacheExpirationSeconds)
+	}
+
+	if err := r.Client.Set(r.Namespace+key, json, ttl).Err(); err != nil {
+		return r.fail("set", err)
+	}
+	atomic.AddInt64(&r.stats.Sets, 1)
+	return nil
+}
+
+// removes Object from Cache
+func (r *RedisCache) Delete(key string) error {
+	if err := r.Client.Del(r.Namespace + key).Err(); err != nil {
+		return r.fail("delete", err)
+	}
+	atomic.AddInt64(&r.stats.Deletes, 1)
+	return nil
+}
+
+// removes all keys of this namespace matching pattern, keys are scanned and deleted in batches
+// so Redis is never blocked as with KEYS
+func (r *RedisCache) DeletePattern(pattern string) (int64, error) {
+	var n int64
+	batch := make([]string, 0, scanBatchSize)
+
+	del := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		deleted, err := r.Client.Del(batch...).Result()
+		if err != nil {
+			return r.fail("delete", err)
+		}
+		n += deleted
+		batch = batch[:0]
+		return nil
+	}
+
+	iter := r.Client.Scan(0, r.Namespace+pattern, scanBatchSize).Iterator()
+	for iter.Next() {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanBatchSize {
+			if err := del(); err != nil {
+				return n, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return n, r.fail("scan", err)
+	}
+	if err := del(); err != nil {
+		return n, err
+	}
+
+	atomic.AddInt64(&r.stats.Deletes, n)
+	return n, nil
+}
+
+// returns usage counters of this instance, keys are not counted as it would scan the whole namespace
+func (r *RedisCache) Stats() Stats {
+	return Stats{
+		Backend: Redis,
+		Hits:    atomic.LoadInt64(&r.stats.Hits),
+		Misses:  atomic.LoadInt64(&r.stats.Misses),
+		Sets:    atomic.LoadInt64(&r.stats.Sets),
+		Deletes: atomic.LoadInt64(&r.stats.Deletes),
+		Errors:  atomic.LoadInt64(&r.stats.Errors),
+	}
+}
+
+// deletes a lock only if it still holds the token of its owner so an expired lock taken over by another instance is kept
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// tries to lock key for at most ttl using SET NX so only one instance holds it at a time
+func (r *RedisCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	token := util.GenerateStringUUID()
+	ok, err := r.Client.SetNX(r.lockKey(key), token, ttl).Result()
 	if err != nil {
-		panic(err)
+		return "", false, r.fail("lock", err)
+	}
+	return token, ok, nil
+}
+
+// releases the lock of key if still held with token
+func (r *RedisCache) Unlock(key, token string) error {
+	if err := unlockScript.Run(r.Client, []string{r.lockKey(key)}, token).Err(); err != nil {
+		return r.fail("unlock", err)
 	}
+	return nil
+}
+
+func (r *RedisCache) lockKey(key string) string {
+	return r.Namespace + "lock:" + key
+}
+
+// records a failed Redis operation and returns the error
+func (r *RedisCache) fail(op string, err error) error {
+	atomic.AddInt64(&r.stats.Errors, 1)
+	log.Printf("CACHE ERROR on %s: %s", op, err.Error())
 	return err
 }
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// TieredCache puts a local L1 Cache in front of a shared L2 Cache such as Redis.
+// Reads try L1 first and fill it on L2 hits, writes and deletes go to both tiers.
+// L1 entries live at most L1Expiration so instances converge to the shared L2 state.
+type TieredCache struct {
+	L1           Cache
+	L2           Cache
+	L1Expiration time.Duration
+	hits         int64
+	misses       int64
+}
+
+// Builds a new two tier Cache
+func NewTieredCache(l1, l2 Cache, l1ExpirationSeconds int) *TieredCache {
+	log.Printf("New Tiered Cache")
+
+	return &TieredCache{
+		L1:           l1,
+		L2:           l2,
+		L1Expiration: time.Second * time.Duration(l1ExpirationSeconds),
+	}
+}
+
+// get Object from L1 or else from L2 storing it in L1
+func (c *TieredCache) Get(key string) (string, error) {
+	if val, err := c.L1.Get(key); err == nil {
+		atomic.AddInt64(&c.hits, 1)
+		return val, nil
+	}
+
+	val, err := c.L2.Get(key)
+	if err != nil {
+		atomic.AddInt64(&c.misses, 1)
+		return "", err
+	}
+
+	c.L1.Set(key, val, c.L1Expiration)
+	atomic.AddInt64(&c.hits, 1)
+	return val, nil
+}
+
+// sets Object in both tiers, L1 keeps it for the shortest of ttl and L1Expiration
+// L1 is set even if L2 fails so this instance keeps serving the entry while L2 is down
+func (c *TieredCache) Set(key, value string, ttl time.Duration) error {
+	l1ttl := c.L1Expiration
+	if ttl > 0 && ttl < l1ttl {
+		l1ttl = ttl
+	}
+
+	c.L1.Set(key, value, l1ttl)
+	return c.L2.Set(key, value, ttl)
+}
+
+// removes Object from both tiers
+func (c *TieredCache) Delete(key string) error {
+	c.L1.Delete(key)
+	return c.L2.Delete(key)
+}
+
+// removes keys matching pattern from both tiers, returns number of keys removed from L2
+func (c *TieredCache) DeletePattern(pattern string) (int64, error) {
+	c.L1.DeletePattern(pattern)
+	return c.L2.DeletePattern(pattern)
+}
+
+// returns hits and misses of the tiered cache with L1 size
+func (c *TieredCache) Stats() Stats {
+	l1, l2 := c.L1.Stats(), c.L2.Stats()
+	return Stats{
+		Backend:   Tiered,
+		Hits:      atomic.LoadInt64(&c.hits),
+		Misses:    atomic.LoadInt64(&c.misses),
+		Sets:      l2.Sets,
+		Deletes:   l2.Deletes,
+		Evictions: l1.Evictions,
+		Errors:    l2.Errors,
+		Size:      l1.Size,
+	}
+}
+
+// locks key in L2 if it supports shared locks, otherwise the lock is always acquired
+func (c *TieredCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	if l, ok := c.L2.(Locker); ok {
+		return l.Lock(key, ttl)
+	}
+	return "", true, nil
+}
+
+// releases the lock of key in L2
+func (c *TieredCache) Unlock(key, token string) error {
+	if l, ok := c.L2.(Locker); ok {
+		return l.Unlock(key, token)
+	}
+	return nil
+}
@@ -6,98 +6,149 @@ import (
 	"github.com/guilhebl/go-props"
 	"github.com/guilhebl/xcrypto"
 	"log"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 )
 
-// Singleton which reads from configuration files for global app config params
+// Reads from configuration files the app config params, each module instance holds its own configuration
+// so multiple isolated instances can run side by side in the same process.
 type Configuration struct {
-	Props *props.Properties
+	Props     *props.Properties
+	overrides map[string]string
+	mu        sync.RWMutex
+
+	// directory of the config file, relative file paths of properties are resolved from it
+	dir string
 }
 
 const (
 	// Run modes
 	Prod = "prod"
 	Test = "test"
-)
 
-var instance *Configuration
-var once sync.Once
-
-func BuildInstance(mode string) *Configuration {
-	once.Do(func() {
-		instance = newConfiguration(mode)
-	})
-	return instance
-}
+	// config file paths by mode
+	ProdConfigFile = "common/config/app-config.properties"
+	TestConfigFile = "common/config/testdata/test-app-config.properties"
+)
 
-func GetInstance() *Configuration {
-	return instance
+// country provider keys used before marketplaceProviders.{country}, still read when the new key isn't set
+var deprecatedProviderKeys = map[string]string{
+	model.Canada: "marketplaceProvidersCanada",
 }
 
-func newConfiguration(mode string) *Configuration {
+// builds a new configuration reading from the config file of this mode
+func NewConfiguration(mode string) *Configuration {
 	log.Printf("Init Config: %s", mode)
 
-	var p props.Properties
-	var err error
-
+	path := TestConfigFile
 	if mode == Prod {
-		p, err = props.ReadPropertiesFile("common/config/app-config.properties")
-	} else {
-		p, err = props.ReadPropertiesFile("common/config/testdata/test-app-config.properties")
+		path = ProdConfigFile
 	}
 
+	c, err := NewConfigurationFromFile(path)
 	if err != nil {
 		log.Fatal(err)
 	}
+	return c
+}
+
+// builds a new configuration reading properties from file path
+func NewConfigurationFromFile(path string) (*Configuration, error) {
+	p, err := props.ReadPropertiesFile(path)
+	if err != nil {
+		return nil, err
+	}
 
 	config := Configuration{
-		Props: &p,
+		Props:     &p,
+		overrides: make(map[string]string),
+		dir:       filepath.Dir(path),
 	}
 
-	return &config
+	for country, key := range deprecatedProviderKeys {
+		if config.GetProperty(key) != "" {
+			log.Printf("%s is deprecated, use marketplaceProviders.%s instead", key, country)
+		}
+	}
+
+	return &config, nil
+}
+
+// overrides a property value for this configuration instance only
+func (c *Configuration) SetProperty(p, v string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overrides[p] = v
+}
+
+func (c *Configuration) override(p string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.overrides[p]
+	return v, ok
 }
 
-func GetProperty(p string) string {
-	return GetInstance().Props.GetProperty(p)
+func (c *Configuration) GetProperty(p string) string {
+	if v, ok := c.override(p); ok {
+		return v
+	}
+	return c.Props.GetProperty(p)
 }
 
-func GetIntProperty(p string) int {
-	return int(GetInstance().Props.GetIntProperty(p))
+func (c *Configuration) GetIntProperty(p string) int {
+	if v, ok := c.override(p); ok {
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return int(c.Props.GetIntProperty(p))
 }
 
-func GetBoolProperty(p string) bool {
-	return GetInstance().Props.GetBoolProperty(p)
+func (c *Configuration) GetBoolProperty(p string) bool {
+	if v, ok := c.override(p); ok {
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return c.Props.GetBoolProperty(p)
 }
 
-func getHost() string {
-	return GetProperty("protocol") + GetProperty("host") + ":" + GetProperty("port") + "/"
+// resolves a file path read from a property relative to the directory of the config file, absolute paths are kept
+func (c *Configuration) ResolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, path)
 }
 
-func getImageFolderUrl() string {
-	return getHost() + "assets/images/"
+func (c *Configuration) getHost() string {
+	return c.GetProperty("protocol") + c.GetProperty("host") + ":" + c.GetProperty("port") + "/"
+}
+
+func (c *Configuration) getImageFolderUrl() string {
+	return c.getHost() + "assets/images/"
 }
 
 // returns if this provider requires image proxy (HTTP/HTTPS)
-func IsProxyRequired(provider string) bool {
-	return strings.Index(GetProperty("marketplaceProvidersImageProxyRequired"), provider) != -1
+func (c *Configuration) IsProxyRequired(provider string) bool {
+	return strings.Index(c.GetProperty("marketplaceProvidersImageProxyRequired"), provider) != -1
 }
 
 // builds an img source from an external server should proxy if http is used to avoid security warns (HTTP/S MIXED MODE)
 // if empty string return img placeholder default
-func BuildImgUrlExternal(s string, proxyRequired bool) string {
+func (c *Configuration) BuildImgUrlExternal(s string, proxyRequired bool) string {
 	if s == "" {
-		return fmt.Sprintf(getImageFolderUrl() + "image-placeholder.png")
+		return fmt.Sprintf(c.getImageFolderUrl() + "image-placeholder.png")
 	}
 
 	if proxyRequired {
-		key := GetProperty("privateKeyAES")
+		key := c.GetProperty("privateKeyAES")
 		hash, err := xcrypto.Encrypt([]byte(key), []byte(s))
 		if err != nil {
 			return ""
 		}
 
-		url := fmt.Sprintf(GetProperty("proxyHost") + "?hash=" + string(hash))
+		url := fmt.Sprintf(c.GetProperty("proxyHost") + "?hash=" + string(hash))
 		return url
 	} else {
 		// case when provider has an https available service just switch to that if not already https
@@ -112,36 +163,41 @@ func BuildImgUrlExternal(s string, proxyRequired bool) string {
 }
 
 // builds img from local assets folder
-func BuildImgUrl(s string) string {
+func (c *Configuration) BuildImgUrl(s string) string {
 	img := s
 	if img == "" {
 		img = "image-placeholder.png"
 	}
 
-	return fmt.Sprintf(getImageFolderUrl() + img)
-}
-
-func CountMarketplaceProviderListSize() int {
-	arr := strings.Split(GetProperty("marketplaceProviders"), ",")
-	return len(arr)
+	return fmt.Sprintf(c.getImageFolderUrl() + img)
 }
 
-// returns max number of providers - default country USA
-func CountMarketplaceProviders(country string) int {
-	var size int
-	switch country {
-	//Canada
-	case model.Canada:
-		{
-			arr := strings.Split(GetProperty("marketplaceProvidersCanada"), ",")
-			size = len(arr)
+// returns the marketplace providers of a country: marketplaceProviders.{country} if set (or its deprecated key), otherwise
+// marketplaceProviders for the default country UnitedStates and marketplaceProvidersInternational for any other country
+func (c *Configuration) MarketplaceProviders(country string) []string {
+	country = model.NormalizeCountry(country)
+	providers := c.GetProperty("marketplaceProviders." + country)
+	if key, ok := deprecatedProviderKeys[country]; ok && providers == "" {
+		providers = c.GetProperty(key)
+	}
+	if providers == "" {
+		if country == model.UnitedStates {
+			providers = c.GetProperty("marketplaceProviders")
+		} else {
+			providers = c.GetProperty("marketplaceProvidersInternational")
 		}
-	default:
-		{
-			arr := strings.Split(GetProperty("marketplaceProviders"), ",")
-			size = len(arr)
+	}
+
+	list := make([]string, 0)
+	for _, p := range strings.Split(providers, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
 		}
 	}
+	return list
+}
 
-	return size
+// returns max number of providers of a country - default country USA
+func (c *Configuration) CountMarketplaceProviders(country string) int {
+	return len(c.MarketplaceProviders(country))
 }
@@ -1,81 +1,299 @@
 package db
 
 import (
-	"fmt"
+	"encoding/base64"
 	"github.com/gocql/gocql"
 	"github.com/guilhebl/go-offer/common/model"
 	"github.com/guilhebl/go-offer/common/util"
 	"log"
+	"strings"
 	"sync"
 	"time"
 )
 
-// represents a Cassandra driver client
+// represents a Cassandra driver client, it's the OfferStore used when cassandraEnabled=true.
+// A single session is shared by all calls for the lifetime of the client, it's connected on first use
+// so the app starts even if Cassandra is down and connecting is retried on next calls until it succeeds.
 type CassandraClient struct {
 	ClusterConfig *gocql.ClusterConfig
+	mu            sync.Mutex
+	session       *gocql.Session
 }
 
-var instance *CassandraClient
-var once sync.Once
+// Cassandra connection, consistency and pooling options
+type CassandraOptions struct {
+	Hosts                []string
+	Port                 int
+	Username             string
+	Password             string
+	Keyspace             string
+	Consistency          string
+	TimeoutMillis        int
+	ConnectTimeoutMillis int
+	NumConns             int
+	Retries              int
+	RetryMinMillis       int
+	RetryMaxMillis       int
+	LocalDC              string
+	TLS                  bool
+	CaPath               string
+	CertPath             string
+	KeyPath              string
+	TLSVerifyHost        bool
+}
+
+// builds a new cassandra db client instance, fails if options are not valid
+func NewCassandraClient(opts CassandraOptions) (*CassandraClient, error) {
+	cluster, err := NewClusterConfig(opts)
+	if err != nil {
+		return nil, err
+	}
 
-// builds a new cassandra db client instance using default port 9042
-func BuildInstance(host, username, password, keyspace string, port int) *CassandraClient {
-	once.Do(func() {
-		cluster := gocql.NewCluster(host)
-		cluster.Keyspace = keyspace
-		cluster.Port = port
-		cluster.ProtoVersion = 4
-		cluster.Authenticator = gocql.PasswordAuthenticator{
-			Username: username,
-			Password: password,
+	return &CassandraClient{
+		ClusterConfig: cluster,
+	}, nil
+}
+
+// builds the cluster config of options: contact hosts, consistency, timeouts, pool size,
+// exponential backoff retries, token aware routing to the hosts of LocalDC if set and TLS
+func NewClusterConfig(opts CassandraOptions) (*gocql.ClusterConfig, error) {
+	cluster := gocql.NewCluster(opts.Hosts...)
+	cluster.Keyspace = opts.Keyspace
+	cluster.Port = opts.Port
+	cluster.ProtoVersion = 4
+	cluster.Authenticator = gocql.PasswordAuthenticator{
+		Username: opts.Username,
+		Password: opts.Password,
+	}
+
+	if opts.Consistency != "" {
+		consistency, err := gocql.ParseConsistencyWrapper(opts.Consistency)
+		if err != nil {
+			return nil, err
 		}
+		cluster.Consistency = consistency
+	}
+	if opts.TimeoutMillis > 0 {
+		cluster.Timeout = time.Duration(opts.TimeoutMillis) * time.Millisecond
+	}
+	if opts.ConnectTimeoutMillis > 0 {
+		cluster.ConnectTimeout = time.Duration(opts.ConnectTimeoutMillis) * time.Millisecond
+	}
+	if opts.NumConns > 0 {
+		cluster.NumConns = opts.NumConns
+	}
+	if opts.Retries > 0 {
+		cluster.RetryPolicy = &gocql.ExponentialBackoffRetryPolicy{
+			NumRetries: opts.Retries,
+			Min:        time.Duration(opts.RetryMinMillis) * time.Millisecond,
+			Max:        time.Duration(opts.RetryMaxMillis) * time.Millisecond,
+		}
+	}
+
+	fallback := gocql.RoundRobinHostPolicy()
+	if opts.LocalDC != "" {
+		fallback = gocql.DCAwareRoundRobinPolicy(opts.LocalDC)
+	}
+	cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(fallback)
+
+	if opts.TLS {
+		cluster.SslOpts = &gocql.SslOptions{
+			CaPath:                 opts.CaPath,
+			CertPath:               opts.CertPath,
+			KeyPath:                opts.KeyPath,
+			EnableHostVerification: opts.TLSVerifyHost,
+		}
+	}
+	return cluster, nil
+}
 
-		instance = &CassandraClient{
-			ClusterConfig: cluster,
+// returns the shared session connecting it if not connected yet
+func (c *CassandraClient) getSession() (*gocql.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil || c.session.Closed() {
+		session, err := c.ClusterConfig.CreateSession()
+		if err != nil {
+			return nil, err
 		}
-	})
-	return instance
+		c.session = session
+	}
+	return c.session, nil
 }
 
-func GetInstance() *CassandraClient {
-	return instance
+// closes the shared session
+func (c *CassandraClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil {
+		c.session.Close()
+		c.session = nil
+	}
 }
 
-// gets all offers
-func GetOffers() ([]model.Offer, error) {
-	log.Printf("Cassandra.GetOffers")
+// gets a page of offers matching the query with the total count of matching offers if requested, counting reads
+// every matching row so pages following a page state aren't counted by default.
+// Filters are answered by the materialized view partitioned by the most selective of them: upc, category
+// and then party name, remaining filters are applied within that partition.
+func (c *CassandraClient) QueryOffers(q *OfferQuery) (*OfferPage, error) {
+	log.Printf("Cassandra.QueryOffers")
 
-	session, err := GetInstance().ClusterConfig.CreateSession()
-	defer session.Close()
+	session, err := c.getSession()
 	if err != nil {
 		log.Print(err)
 		return nil, err
 	}
 
+	state, err := base64.RawURLEncoding.DecodeString(q.PageState)
+	if err != nil {
+		return nil, ErrInvalidPageState
+	}
+
+	table, where, args := offerQueryWhere(q)
+	page := &OfferPage{Offers: make([]model.Offer, 0, q.PageSize), TotalCount: UnknownCount}
+
+	if q.Count {
+		if err := session.Query("SELECT COUNT(*) FROM "+table+where, args...).Scan(&page.TotalCount); err != nil {
+			log.Print(err)
+			return nil, err
+		}
+	}
+
+	selectStatement := "SELECT " + offerColumns + " FROM " + table + where
+
+	// without a page state skip pages until the requested one
+	if q.PageState == "" {
+		for p := 1; p < q.Page; p++ {
+			iter := session.Query(selectStatement, args...).PageSize(q.PageSize).PageState(state).Iter()
+			state = iter.PageState()
+			if err := iter.Close(); err != nil {
+				return nil, err
+			}
+			if len(state) == 0 {
+				return page, nil
+			}
+		}
+	}
+
+	// read only rows of this page as iter fetches next pages on demand
+	iter := session.Query(selectStatement, args...).PageSize(q.PageSize).PageState(state).Iter()
+	for i := iter.NumRows(); i > 0; i-- {
+		o, ok := scanOffer(iter)
+		if !ok {
+			break
+		}
+		page.Offers = append(page.Offers, *o)
+	}
+
+	if next := iter.PageState(); len(next) > 0 {
+		page.NextPageState = base64.RawURLEncoding.EncodeToString(next)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// returns the table or view answering the query with its where clause and args
+func offerQueryWhere(q *OfferQuery) (string, string, []interface{}) {
+	table := "offer"
+	switch {
+	case q.Upc != "":
+		table = "offer_by_upc"
+	case q.Category != "":
+		table = "offer_by_category"
+	case q.PartyName != "":
+		table = "offer_by_party_name"
+	}
+
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if q.Upc != "" {
+		conditions, args = append(conditions, "upc = ?"), append(args, q.Upc)
+	}
+	if q.Category != "" {
+		conditions, args = append(conditions, "product_category = ?"), append(args, q.Category)
+	}
+	if q.PartyName != "" {
+		conditions, args = append(conditions, "party_name = ?"), append(args, q.PartyName)
+	}
+
+	if len(conditions) == 0 {
+		return table, "", args
+	}
+
+	// filtering is bound to a single partition of the view
+	where := " WHERE " + strings.Join(conditions, " AND ")
+	if len(conditions) > 1 {
+		where += " ALLOW FILTERING"
+	}
+	return table, where, args
+}
+
+// version of new offers, offers inserted before 0002_add_offer_version have a null version read as this one
+const initialOfferVersion = 1
+
+// columns of offer table read into an Offer by scanOffer
+const offerColumns = `id, external_id, upc, name, party_name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created, version`
+
+// scans next row of iter into an offer, returns false if there are no more rows
+func scanOffer(iter *gocql.Iter) (*model.Offer, bool) {
 	var id, externalId, upc, name, partyName, semanticName, mainImageFileUrl, partyImageFileUrl, productCategory string
 	var price, rating float32
 	var numReviews int
 	var created time.Time
+	var version *int64
+
+	if !iter.Scan(&id, &externalId, &upc, &name, &partyName, &semanticName, &mainImageFileUrl, &partyImageFileUrl, &productCategory, &price, &rating, &numReviews, &created, &version) {
+		return nil, false
+	}
+
+	o := model.NewOffer(id, externalId, upc, name, partyName, semanticName, mainImageFileUrl, partyImageFileUrl, productCategory, price, rating, numReviews, created)
+	o.Version = initialOfferVersion
+	if version != nil {
+		o.Version = *version
+	}
+	return o, true
+}
+
+// gets an offer by id
+func (c *CassandraClient) GetOffer(id string) (*model.Offer, error) {
+	log.Printf("Cassandra.GetOffer")
 
-	// output list
-	list := make([]model.Offer, 0)
+	session, err := c.getSession()
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return getOffer(session, id)
+}
 
-	// list all
-	selectStatement := `SELECT id, external_id, upc, name, party_name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created FROM offer`
-	iter := session.Query(selectStatement).Iter()
-	for iter.Scan(&id, &externalId, &upc, &name, &partyName, &semanticName, &mainImageFileUrl, &partyImageFileUrl, &productCategory, &price, &rating, &numReviews, &created) {
-		o := model.NewOffer(id, externalId, upc, name, partyName, semanticName, mainImageFileUrl, partyImageFileUrl, productCategory, price, rating, numReviews, created)
-		list = append(list, *o)
+// gets an offer by id, ids which are not valid UUIDs are not found
+func getOffer(session *gocql.Session, id string) (*model.Offer, error) {
+	uuid, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	iter := session.Query(`SELECT `+offerColumns+` FROM offer WHERE id = ?`, uuid).Iter()
+	o, ok := scanOffer(iter)
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
 	}
-	return list, nil
+	return o, nil
 }
 
 // Insert Offer
-func InsertOffer(o *model.Offer) (*model.Offer, error) {
+func (c *CassandraClient) InsertOffer(o *model.Offer) (*model.Offer, error) {
 	log.Print("Cassandra.InsertOffer")
 
-	session, err := GetInstance().ClusterConfig.CreateSession()
-	defer session.Close()
+	session, err := c.getSession()
 	if err != nil {
 		log.Print(err)
 		return nil, err
@@ -83,6 +301,7 @@ func InsertOffer(o *model.Offer) (*model.Offer, error) {
 
 	// create new UUID
 	o.Id = util.GenerateStringUUID()
+	o.Version = initialOfferVersion
 
 	if err := insertOffer(session, o); err != nil {
 		return nil, err
@@ -93,96 +312,243 @@ func InsertOffer(o *model.Offer) (*model.Offer, error) {
 // insert Offer
 func insertOffer(session *gocql.Session, o *model.Offer) error {
 	insertStatement := `
-INSERT INTO offer (id, external_id, upc, name, party_name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+INSERT INTO offer (id, external_id, upc, name, party_name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created, version)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	// insert an offer
 	if err := session.Query(insertStatement,
-		o.Id, o.ExternalId, o.Upc, o.Name, o.PartyName, o.SemanticName, o.MainImageFileUrl, o.PartyImageFileUrl, o.ProductCategory, o.Price, o.Rating, o.NumReviews, o.Created).Exec(); err != nil {
+		o.Id, o.ExternalId, o.Upc, o.Name, o.PartyName, o.SemanticName, o.MainImageFileUrl, o.PartyImageFileUrl, o.ProductCategory, o.Price, o.Rating, o.NumReviews, o.Created, o.Version).Exec(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// Resets DB
-func Reset() error {
-	log.Print("Cassandra.Reset")
+// Update Offer using a lightweight transaction so it's applied only if version wasn't changed
+func (c *CassandraClient) UpdateOffer(o *model.Offer, version int64) (*model.Offer, error) {
+	log.Print("Cassandra.UpdateOffer")
 
-	session, err := GetInstance().ClusterConfig.CreateSession()
-	defer session.Close()
+	session, err := c.getSession()
 	if err != nil {
 		log.Print(err)
-		return err
+		return nil, err
+	}
+
+	if _, err := getOffer(session, o.Id); err != nil {
+		return nil, err
+	}
+
+	updateStatement := `
+UPDATE offer SET external_id = ?, upc = ?, name = ?, party_name = ?, semantic_name = ?, main_image_file_url = ?, party_image_file_url = ?, product_category = ?, price = ?, rating = ?, num_reviews = ?, created = ?, version = ?
+WHERE id = ?`
+
+	if err := execIfVersion(session, updateStatement, version,
+		o.ExternalId, o.Upc, o.Name, o.PartyName, o.SemanticName, o.MainImageFileUrl, o.PartyImageFileUrl, o.ProductCategory, o.Price, o.Rating, o.NumReviews, o.Created, version+1, o.Id); err != nil {
+		return nil, err
 	}
 
-	// drop table if exists
-	keyspace := GetInstance().ClusterConfig.Keyspace
-	dropTable := fmt.Sprintf("DROP TABLE IF EXISTS %s.offer", keyspace)
-	if err := session.Query(dropTable).Exec(); err != nil {
+	o.Version = version + 1
+	return o, nil
+}
+
+// Delete Offer using a lightweight transaction so it's applied only if version wasn't changed
+func (c *CassandraClient) DeleteOffer(id string, version int64) error {
+	log.Print("Cassandra.DeleteOffer")
+
+	session, err := c.getSession()
+	if err != nil {
 		log.Print(err)
 		return err
 	}
 
-	// create table
-	createTableStatement := fmt.Sprintf(`
-CREATE TABLE %s.offer (
-	id uuid PRIMARY KEY,
-	external_id text, 
-	upc text, 
-	name text, 
-	party_name text, 
-	semantic_name text, 
-	main_image_file_url text, 
-	party_image_file_url text, 
-	product_category text, 
-	price float, 
-	rating float, 
-	num_reviews int,
-	created date);
-`, keyspace)
+	if _, err := getOffer(session, id); err != nil {
+		return err
+	}
 
-	if err := session.Query(createTableStatement).Exec(); err != nil {
+	return execIfVersion(session, `DELETE FROM offer WHERE id = ?`, version, id)
+}
+
+// executes statement as a lightweight transaction applied only if the offer is still at version, offers with a null
+// version are read as initialOfferVersion so for that version the transaction is retried on a null version
+func execIfVersion(session *gocql.Session, statement string, version int64, args ...interface{}) error {
+	var current *int64
+	applied, err := session.Query(statement+" IF version = ?", append(args, version)...).ScanCAS(&current)
+	if err == nil && !applied && current == nil && version == initialOfferVersion {
+		applied, err = session.Query(statement+" IF version = null", args...).ScanCAS(&current)
+	}
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// columns of catalog_offer table read into an OfferDetail by GetCatalogOffer
+const catalogOfferColumns = `id, external_id, upc, name, party_name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created, condition, seller, shipping_cost, description, attributes`
+
+// inserts or replaces the catalog offer, as only offer columns are written description and attributes are kept
+func (c *CassandraClient) UpsertCatalogOffer(o *model.Offer) error {
+	session, err := c.getSession()
+	if err != nil {
 		log.Print(err)
 		return err
 	}
 
-	//// create index on UPC
-	//createIndexUpcStatement := fmt.Sprintf(`
-	//CREATE INDEX IF NOT EXISTS offer_upc
-	//ON %s.offer (upc)`, keyspace)
-	//
-	//if err := session.Query(createIndexUpcStatement).Exec(); err != nil {
-	//	log.Print(err)
-	//	return err
-	//}
-
-	// insert sample offers
-	if err := insertOffer(session, model.NewOffer(
-		util.GenerateStringUUID(), "1", "upc12345678", "offer 1", "amazon.com", "https://amazon.com/offer/001", "https://amazon.com/img/offer/001", "amazon-logo.jpg", "offers", 50.00, 2.5, 50, time.Now(),
-	)); err != nil {
+	upsertStatement := `
+INSERT INTO catalog_offer (party_name, external_id, id, upc, name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created, condition, seller, shipping_cost, updated)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	return session.Query(upsertStatement,
+		o.PartyName, o.ExternalId, o.Id, o.Upc, o.Name, o.SemanticName, o.MainImageFileUrl, o.PartyImageFileUrl, o.ProductCategory, o.Price, o.Rating, o.NumReviews, o.Created, o.Condition, o.Seller, o.ShippingCost, time.Now()).Exec()
+}
+
+// inserts or replaces the catalog offer with its description and attributes
+func (c *CassandraClient) UpsertCatalogDetail(d *model.OfferDetail) error {
+	session, err := c.getSession()
+	if err != nil {
 		log.Print(err)
 		return err
 	}
 
-	if err := insertOffer(session, model.NewOffer(
-		util.GenerateStringUUID(), "2", "upc22345678", "offer 2", "bestbuy.com", "https://bestbuy.com/offer/001", "https://bestbuy.com/img/offer/001", "bestbuy-logo.jpg", "offers", 60.00, 2.8, 30, time.Now(),
-	)); err != nil {
+	attributes := make(map[string]string)
+	for _, a := range d.Attributes {
+		attributes[a.Name] = a.Value
+	}
+
+	upsertStatement := `
+INSERT INTO catalog_offer (party_name, external_id, id, upc, name, semantic_name, main_image_file_url, party_image_file_url, product_category, price, rating, num_reviews, created, condition, seller, shipping_cost, description, attributes, updated)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	o := &d.Offer
+	return session.Query(upsertStatement,
+		o.PartyName, o.ExternalId, o.Id, o.Upc, o.Name, o.SemanticName, o.MainImageFileUrl, o.PartyImageFileUrl, o.ProductCategory, o.Price, o.Rating, o.NumReviews, o.Created, o.Condition, o.Seller, o.ShippingCost, d.Description, attributes, time.Now()).Exec()
+}
+
+// gets a catalog offer detail by provider and external id
+func (c *CassandraClient) GetCatalogOffer(partyName, externalId string) (*model.OfferDetail, error) {
+	session, err := c.getSession()
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var o model.Offer
+	var description string
+	var attributes map[string]string
+
+	iter := session.Query(`SELECT `+catalogOfferColumns+` FROM catalog_offer WHERE party_name = ? AND external_id = ?`, partyName, externalId).Iter()
+	ok := iter.Scan(&o.Id, &o.ExternalId, &o.Upc, &o.Name, &o.PartyName, &o.SemanticName, &o.MainImageFileUrl, &o.PartyImageFileUrl, &o.ProductCategory, &o.Price, &o.Rating, &o.NumReviews, &o.Created, &o.Condition, &o.Seller, &o.ShippingCost, &description, &attributes)
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return model.NewOfferDetail(o, description, attributes, nil), nil
+}
+
+// gets the provider, external id and country of an offer id
+func (c *CassandraClient) GetOfferRef(id string) (*model.OfferRef, error) {
+	session, err := c.getSession()
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	ref := model.OfferRef{Id: id}
+	iter := session.Query(`SELECT party_name, external_id, country FROM offer_ref WHERE id = ?`, id).Iter()
+	ok := iter.Scan(&ref.PartyName, &ref.ExternalId, &ref.Country)
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &ref, nil
+}
+
+// stores the references of offer ids one by one, refs belong to different partitions so a batch would only load
+// its coordinator. stops on the first failure
+func (c *CassandraClient) PutOfferRefs(refs []model.OfferRef) error {
+	session, err := c.getSession()
+	if err != nil {
 		log.Print(err)
 		return err
 	}
 
-	if err := insertOffer(session, model.NewOffer(
-		util.GenerateStringUUID(), "3", "upc32345678", "offer 3", "walmart.com", "https://walmart.com/offer/001", "https://walmart.com/img/offer/001", "walmart-logo.jpg", "offers", 65.00, 4.5, 60, time.Now(),
-	)); err != nil {
+	for _, ref := range refs {
+		err := session.Query(`INSERT INTO offer_ref (id, party_name, external_id, country) VALUES (?, ?, ?, ?)`,
+			ref.Id, ref.PartyName, ref.ExternalId, ref.Country).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removes all offers, views are truncated along with their base table
+func (c *CassandraClient) Truncate() error {
+	log.Print("Cassandra.Truncate")
+
+	session, err := c.getSession()
+	if err != nil {
 		log.Print(err)
 		return err
 	}
 
-	if err := insertOffer(session, model.NewOffer(
-		util.GenerateStringUUID(), "4", "upc42345678", "offer 4", "ebay.com", "https://ebay.com/offer/001", "https://ebay.com/img/offer/001", "ebay-logo.jpg", "offers", 105.00, 3.5, 60, time.Now(),
-	)); err != nil {
+	return session.Query(`TRUNCATE offer`).Exec()
+}
+
+// applies the pending schema migrations of dir, on dry run they are only logged
+func (c *CassandraClient) Migrate(dir string, dryRun bool) ([]Migration, error) {
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	return Migrate(c, migrations, dryRun)
+}
+
+// returns the versions of migrations recorded in schema_version table, creating it if missing
+func (c *CassandraClient) AppliedVersions() (map[int]bool, error) {
+	session, err := c.getSession()
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	createTableStatement := `
+CREATE TABLE IF NOT EXISTS schema_version (
+	version int PRIMARY KEY,
+	name text,
+	applied_at timestamp)`
+
+	if err := session.Query(createTableStatement).Exec(); err != nil {
+		return nil, err
+	}
+
+	var version int
+	versions := make(map[int]bool)
+	iter := session.Query(`SELECT version FROM schema_version`).Iter()
+	for iter.Scan(&version) {
+		versions[version] = true
+	}
+	return versions, iter.Close()
+}
+
+// executes the statements of migration in order and records its version in schema_version table
+func (c *CassandraClient) ApplyMigration(m Migration) error {
+	session, err := c.getSession()
+	if err != nil {
 		log.Print(err)
 		return err
 	}
-	return nil
+
+	for _, statement := range m.Statements {
+		if err := session.Query(statement).Exec(); err != nil {
+			return err
+		}
+	}
+
+	return session.Query(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`, m.Version, m.Name, time.Now()).Exec()
 }
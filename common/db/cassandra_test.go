@@ -0,0 +1,35 @@
+package db
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// tests cluster config is built from options
+func TestNewClusterConfig(t *testing.T) {
+	cluster, err := NewClusterConfig(CassandraOptions{
+		Hosts:         []string{"10.0.0.1", "10.0.0.2"},
+		Port:          9042,
+		Keyspace:      "test",
+		Consistency:   "LOCAL_QUORUM",
+		TimeoutMillis: 2000,
+		NumConns:      4,
+		Retries:       3,
+		LocalDC:       "dc1",
+		TLS:           true,
+		TLSVerifyHost: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cluster.Hosts)
+	assert.Equal(t, gocql.LocalQuorum, cluster.Consistency)
+	assert.Equal(t, 2*time.Second, cluster.Timeout)
+	assert.Equal(t, 4, cluster.NumConns)
+	assert.Equal(t, 3, cluster.RetryPolicy.(*gocql.ExponentialBackoffRetryPolicy).NumRetries)
+	assert.NotNil(t, cluster.PoolConfig.HostSelectionPolicy)
+	assert.True(t, cluster.SslOpts.EnableHostVerification)
+
+	_, err = NewClusterConfig(CassandraOptions{Hosts: []string{"localhost"}, Consistency: "MOST"})
+	assert.NotNil(t, err)
+}
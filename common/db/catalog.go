@@ -0,0 +1,30 @@
+package db
+
+import "github.com/guilhebl/go-offer/common/model"
+
+// CatalogStore persists the offers observed in marketplace search and detail results, building a local
+// product catalog. Catalog offers are keyed by provider (party name) and external id so every new
+// observation of an offer replaces the previous one instead of adding a duplicate.
+// The reference of each offer id minted for marketplace results is kept whether or not the catalog is enabled,
+// so offers can be fetched by id alone.
+type CatalogStore interface {
+	// inserts or replaces the catalog offer, keeps description and attributes of an existing offer detail
+	UpsertCatalogOffer(o *model.Offer) error
+
+	// inserts or replaces the catalog offer with its description and attributes
+	UpsertCatalogDetail(d *model.OfferDetail) error
+
+	// gets a catalog offer detail by provider and external id, returns ErrNotFound if missing
+	GetCatalogOffer(partyName, externalId string) (*model.OfferDetail, error)
+
+	// stores the provider, external id and country of offer ids, refs of an id never change so they're rewritten as is
+	PutOfferRefs(refs []model.OfferRef) error
+
+	// gets the provider, external id and country of an offer id, returns ErrNotFound if never observed
+	GetOfferRef(id string) (*model.OfferRef, error)
+}
+
+// returns the key of a catalog offer in memory
+func catalogKey(partyName, externalId string) string {
+	return partyName + "|" + externalId
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"encoding/json"
+	"github.com/guilhebl/go-offer/common/model"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+// replaces all offers of store with the offers of a JSON fixture file, returns the number of offers loaded
+// offers get new ids and if not set the current time as creation date
+func LoadFixtures(s OfferStore, path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var offers []model.Offer
+	if err := json.Unmarshal(data, &offers); err != nil {
+		return 0, err
+	}
+
+	if err := s.Truncate(); err != nil {
+		return 0, err
+	}
+
+	for i := range offers {
+		o := &offers[i]
+		if o.Created.IsZero() {
+			o.Created = time.Now()
+		}
+		if _, err := s.InsertOffer(o); err != nil {
+			return i, err
+		}
+	}
+
+	log.Printf("Loaded %d offers from %s", len(offers), path)
+	return len(offers), nil
+}
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/common/util"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// MemoryStore keeps offers in memory in insertion order, it's the OfferStore used when cassandraEnabled=false
+// so the datastore endpoints work offline, offers are lost when the app stops.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	offers  []model.Offer
+	catalog map[string]model.OfferDetail
+	refs    map[string]model.OfferRef
+}
+
+// most refs of offer ids kept in memory
+const maxMemoryRefs = 100000
+
+// builds a new empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	log.Printf("New Memory Store")
+
+	return &MemoryStore{
+		offers:  make([]model.Offer, 0),
+		catalog: make(map[string]model.OfferDetail),
+		refs:    make(map[string]model.OfferRef),
+	}
+}
+
+// gets a page of offers matching the query, page state is the offset of the next page
+func (s *MemoryStore) QueryOffers(q *OfferQuery) (*OfferPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]model.Offer, 0)
+	for i := range s.offers {
+		if q.Matches(&s.offers[i]) {
+			matches = append(matches, s.offers[i])
+		}
+	}
+
+	start := (q.Page - 1) * q.PageSize
+	if q.PageState != "" {
+		var err error
+		if start, err = strconv.Atoi(q.PageState); err != nil {
+			return nil, ErrInvalidPageState
+		}
+	}
+	if start < 0 || start > len(matches) {
+		start = len(matches)
+	}
+
+	end := start + q.PageSize
+	page := &OfferPage{TotalCount: UnknownCount}
+	if q.Count {
+		page.TotalCount = len(matches)
+	}
+	if end < len(matches) {
+		page.NextPageState = strconv.Itoa(end)
+	} else {
+		end = len(matches)
+	}
+
+	page.Offers = make([]model.Offer, end-start)
+	copy(page.Offers, matches[start:end])
+	return page, nil
+}
+
+// gets an offer by id
+func (s *MemoryStore) GetOffer(id string) (*model.Offer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.indexOf(id)
+	if i == -1 {
+		return nil, ErrNotFound
+	}
+	o := s.offers[i]
+	return &o, nil
+}
+
+// Insert Offer
+func (s *MemoryStore) InsertOffer(o *model.Offer) (*model.Offer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// create new UUID
+	o.Id = util.GenerateStringUUID()
+	o.Version = 1
+	s.offers = append(s.offers, *o)
+	return o, nil
+}
+
+// Update Offer
+func (s *MemoryStore) UpdateOffer(o *model.Offer, version int64) (*model.Offer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(o.Id)
+	if i == -1 {
+		return nil, ErrNotFound
+	}
+	if s.offers[i].Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	o.Version = version + 1
+	s.offers[i] = *o
+	return o, nil
+}
+
+// Delete Offer
+func (s *MemoryStore) DeleteOffer(id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(id)
+	if i == -1 {
+		return ErrNotFound
+	}
+	if s.offers[i].Version != version {
+		return ErrVersionConflict
+	}
+
+	s.offers = append(s.offers[:i], s.offers[i+1:]...)
+	return nil
+}
+
+// removes all offers
+func (s *MemoryStore) Truncate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offers = make([]model.Offer, 0)
+	return nil
+}
+
+// returns the index of offer with id or -1 if not found
+func (s *MemoryStore) indexOf(id string) int {
+	for i := range s.offers {
+		if s.offers[i].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// inserts or replaces the catalog offer keeping description and attributes of an existing offer detail
+func (s *MemoryStore) UpsertCatalogOffer(o *model.Offer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := catalogKey(o.PartyName, o.ExternalId)
+	d := s.catalog[key]
+	d.Offer = *o
+	s.catalog[key] = d
+	return nil
+}
+
+// inserts or replaces the catalog offer with its description and attributes
+func (s *MemoryStore) UpsertCatalogDetail(d *model.OfferDetail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.catalog[catalogKey(d.Offer.PartyName, d.Offer.ExternalId)] = model.OfferDetail{
+		Offer:       d.Offer,
+		Description: d.Description,
+		Attributes:  d.Attributes,
+	}
+	return nil
+}
+
+// gets a catalog offer detail by provider and external id
+func (s *MemoryStore) GetCatalogOffer(partyName, externalId string) (*model.OfferDetail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.catalog[catalogKey(partyName, externalId)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &d, nil
+}
+
+// gets the provider, external id and country of an offer id
+func (s *MemoryStore) GetOfferRef(id string) (*model.OfferRef, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref, ok := s.refs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &ref, nil
+}
+
+// stores the references of offer ids, when maxMemoryRefs are stored an arbitrary ref is dropped for each new one,
+// a dropped ref is stored again when its offer is seen again
+func (s *MemoryStore) PutOfferRefs(refs []model.OfferRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ref := range refs {
+		if _, ok := s.refs[ref.Id]; !ok && len(s.refs) >= maxMemoryRefs {
+			for id := range s.refs {
+				delete(s.refs, id)
+				break
+			}
+		}
+		s.refs[ref.Id] = ref
+	}
+	return nil
+}
+
+// nothing to release as offers are kept in memory
+func (s *MemoryStore) Close() {
+}
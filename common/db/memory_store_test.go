@@ -0,0 +1,98 @@
+package db
+
+import (
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// tests offers are inserted with a new id and loading fixtures replaces them with the sample offers
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+
+	o, err := s.InsertOffer(model.NewOffer("", "1", "upc1", "offer", model.Amazon, "", "", "", "", 10, 1, 1, time.Now()))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, o.Id)
+
+	page, _ := s.QueryOffers(NewOfferQuery("", "", "", 1, 10, ""))
+	assert.Equal(t, 1, len(page.Offers))
+	assert.Equal(t, o.Id, page.Offers[0].Id)
+
+	n, err := LoadFixtures(s, "fixtures/offers.json")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+
+	page, _ = s.QueryOffers(NewOfferQuery("", "", "", 1, 10, ""))
+	assert.Equal(t, 4, len(page.Offers))
+	assert.Equal(t, "offer 1", page.Offers[0].Name)
+}
+
+// tests offers are filtered and paged using page number or page state
+func TestMemoryStoreQuery(t *testing.T) {
+	s := NewMemoryStore()
+	LoadFixtures(s, "fixtures/offers.json")
+
+	page, err := s.QueryOffers(NewOfferQuery("", "", "", 1, 3, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(page.Offers))
+	assert.Equal(t, 4, page.TotalCount)
+
+	q := NewOfferQuery("", "", "", 2, 3, page.NextPageState)
+	page, _ = s.QueryOffers(q)
+	assert.Equal(t, 1, len(page.Offers))
+	assert.Equal(t, "offer 4", page.Offers[0].Name)
+	assert.Equal(t, "", page.NextPageState)
+	assert.Equal(t, UnknownCount, page.TotalCount)
+
+	q.Count = true
+	page, _ = s.QueryOffers(q)
+	assert.Equal(t, 4, page.TotalCount)
+
+	page, _ = s.QueryOffers(NewOfferQuery("", "", "", 2, 3, ""))
+	assert.Equal(t, "offer 4", page.Offers[0].Name)
+
+	page, _ = s.QueryOffers(NewOfferQuery(model.Walmart, "", "offers", 1, 3, ""))
+	assert.Equal(t, 1, page.TotalCount)
+	assert.Equal(t, "upc32345678", page.Offers[0].Upc)
+
+	_, err = s.QueryOffers(NewOfferQuery("", "", "", 1, 3, "abc"))
+	assert.Equal(t, ErrInvalidPageState, err)
+}
+
+// tests updates and deletes only succeed on the current version
+func TestMemoryStoreVersion(t *testing.T) {
+	s := NewMemoryStore()
+	o, _ := s.InsertOffer(model.NewOffer("", "1", "upc1", "offer", model.Amazon, "", "", "", "", 10, 1, 1, time.Now()))
+
+	o.Price = 20
+	_, err := s.UpdateOffer(o, 2)
+	assert.Equal(t, ErrVersionConflict, err)
+
+	o, err = s.UpdateOffer(o, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), o.Version)
+
+	assert.Equal(t, ErrVersionConflict, s.DeleteOffer(o.Id, 1))
+	assert.Nil(t, s.DeleteOffer(o.Id, 2))
+
+	_, err = s.GetOffer(o.Id)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+// tests refs of offer ids are bounded to maxMemoryRefs keeping the latest one stored
+func TestMemoryStoreRefsBounded(t *testing.T) {
+	s := NewMemoryStore()
+
+	refs := make([]model.OfferRef, 0, maxMemoryRefs+1)
+	for i := 0; i <= maxMemoryRefs; i++ {
+		refs = append(refs, *model.NewOfferRef(model.Walmart, strconv.Itoa(i), model.UnitedStates))
+	}
+	assert.Nil(t, s.PutOfferRefs(refs))
+	assert.Equal(t, maxMemoryRefs, len(s.refs))
+
+	ref, err := s.GetOfferRef(refs[maxMemoryRefs].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(maxMemoryRefs), ref.ExternalId)
+}
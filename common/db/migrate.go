@@ -0,0 +1,113 @@
+package db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a versioned change of the datastore schema read from a CQL file named {version}_{name}.cql
+// such as 0001_create_offer_table.cql, migrations are applied in version order and only once.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+// MigrationExecutor runs migrations against a datastore keeping track of the applied versions
+type MigrationExecutor interface {
+	// returns the versions of migrations already applied
+	AppliedVersions() (map[int]bool, error)
+
+	// executes the statements of migration and records its version as applied
+	ApplyMigration(m Migration) error
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.cql$`)
+
+// reads all migrations of dir sorted by version
+func LoadMigrations(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0)
+	versions := make(map[int]string)
+	for _, f := range files {
+		match := migrationFileName.FindStringSubmatch(f.Name())
+		if f.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, f.Name())
+		}
+		versions[version] = f.Name()
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: match[2], Statements: splitStatements(string(data))})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// splits a CQL script into statements removing "--" comment lines
+func splitStatements(script string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	statements := make([]string, 0)
+	for _, s := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+// applies in order the migrations not applied yet returning them, on dry run they are only logged.
+// Stops at the first failed migration, as CQL has no transactions its statements should be idempotent
+// so the migration can be applied again once fixed.
+func Migrate(e MigrationExecutor, migrations []Migration, dryRun bool) ([]Migration, error) {
+	applied, err := e.AppliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+
+	for i, m := range pending {
+		if dryRun {
+			log.Printf("Migration %04d_%s pending:\n%s;", m.Version, m.Name, strings.Join(m.Statements, ";\n"))
+			continue
+		}
+
+		log.Printf("Migration %04d_%s applying", m.Version, m.Name)
+		if err := e.ApplyMigration(m); err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s failed: %s", m.Version, m.Name, err.Error())
+		}
+	}
+	return pending, nil
+}
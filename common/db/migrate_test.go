@@ -0,0 +1,66 @@
+package db
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// fake executor recording applied migrations, fails on version failOn
+type fakeExecutor struct {
+	applied []int
+	failOn  int
+}
+
+func (e *fakeExecutor) AppliedVersions() (map[int]bool, error) {
+	versions := make(map[int]bool)
+	for _, v := range e.applied {
+		versions[v] = true
+	}
+	return versions, nil
+}
+
+func (e *fakeExecutor) ApplyMigration(m Migration) error {
+	if m.Version == e.failOn {
+		return errors.New("syntax error")
+	}
+	e.applied = append(e.applied, m.Version)
+	return nil
+}
+
+// tests migrations are read sorted by version and split into statements
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations("migrations")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(migrations))
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_offer_table", migrations[0].Name)
+	assert.Equal(t, 3, migrations[2].Version)
+	assert.Equal(t, 3, len(migrations[2].Statements))
+
+	assert.Equal(t, []string{"CREATE TABLE a (id int)", "ALTER TABLE a ADD b int"},
+		splitStatements("-- comment\nCREATE TABLE a (id int);\n\nALTER TABLE a ADD b int;\n"))
+}
+
+// tests only pending migrations are applied in order and nothing is applied on dry run
+func TestMigrate(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+	e := &fakeExecutor{applied: []int{1}}
+
+	pending, err := Migrate(e, migrations, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(pending))
+	assert.Equal(t, []int{1}, e.applied)
+
+	e.failOn = 3
+	applied, err := Migrate(e, migrations, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(applied))
+	assert.Equal(t, []int{1, 2}, e.applied)
+
+	e.failOn = 0
+	applied, err = Migrate(e, migrations, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(applied))
+	assert.Equal(t, []int{1, 2, 3}, e.applied)
+}
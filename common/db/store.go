@@ -0,0 +1,91 @@
+package db
+
+import (
+	"errors"
+	"github.com/guilhebl/go-offer/common/model"
+)
+
+// store errors, their messages match the model error codes handled by the REST API
+var (
+	// returned when no offer has the given id
+	ErrNotFound = errors.New(model.NotFound)
+
+	// returned when the offer was changed since the version given was read
+	ErrVersionConflict = errors.New(model.VersionConflict)
+
+	// returned when a query page state is not a token returned by the store
+	ErrInvalidPageState = errors.New(model.InvalidRequest)
+)
+
+// OfferStore persists the offers of the datastore endpoints.
+// Every offer has a Version starting at 1 which is incremented on each update, updates and deletes
+// only succeed if the version given is the current one so concurrent changes are never lost.
+// Stores also hold the catalog of offers observed in marketplaces.
+type OfferStore interface {
+	CatalogStore
+
+	// gets a page of offers matching the query filters
+	QueryOffers(q *OfferQuery) (*OfferPage, error)
+
+	// gets an offer by id, returns ErrNotFound if missing
+	GetOffer(id string) (*model.Offer, error)
+
+	// inserts offer assigning it a new id and version 1
+	InsertOffer(o *model.Offer) (*model.Offer, error)
+
+	// replaces offer with same id if its current version is version, returns the offer with the new version
+	UpdateOffer(o *model.Offer, version int64) (*model.Offer, error)
+
+	// deletes offer by id if its current version is version
+	DeleteOffer(id string, version int64) error
+
+	// removes all offers
+	Truncate() error
+
+	// releases the connections held by the store
+	Close()
+}
+
+// OfferQuery filters offers by party name, upc and category, empty filters match all offers.
+// Pages are fetched using the PageState token returned with the previous page, if it's empty
+// the query starts from the first page and skips pages until Page is reached.
+// Matching offers are only counted if Count is set, by default for queries without PageState.
+type OfferQuery struct {
+	PartyName string
+	Upc       string
+	Category  string
+	Page      int
+	PageSize  int
+	PageState string
+	Count     bool
+}
+
+// TotalCount of pages of queries without Count
+const UnknownCount = -1
+
+// represents a page of offers with the total number of offers matching the query, UnknownCount if not counted
+type OfferPage struct {
+	Offers        []model.Offer
+	TotalCount    int
+	NextPageState string
+}
+
+func NewOfferQuery(partyName, upc, category string, page, pageSize int, pageState string) *OfferQuery {
+	o := &OfferQuery{
+		PartyName: partyName,
+		Upc:       upc,
+		Category:  category,
+		Page:      page,
+		PageSize:  pageSize,
+		PageState: pageState,
+		Count:     pageState == "",
+	}
+	return o
+}
+
+// checks if offer matches the query filters
+func (q *OfferQuery) Matches(o *model.Offer) bool {
+	return (q.PartyName == "" || q.PartyName == o.PartyName) &&
+		(q.Upc == "" || q.Upc == o.Upc) &&
+		(q.Category == "" || q.Category == o.ProductCategory)
+}
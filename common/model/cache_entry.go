@@ -0,0 +1,27 @@
+package model
+
+// represents an entry stored in cache with its key and lookup status HIT, STALE or MISS
+type CacheEntry struct {
+	Key    string      `json:"key"`
+	Status string      `json:"status"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+func NewCacheEntry(key, status string, value interface{}) *CacheEntry {
+	o := &CacheEntry{
+		Key:    key,
+		Status: status,
+		Value:  value,
+	}
+	return o
+}
+
+// represents the result of a cache invalidation
+type CacheInvalidation struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// represents the result of a cache warm up request
+type CacheWarmUp struct {
+	Queries int `json:"queries"`
+}
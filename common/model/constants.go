@@ -7,10 +7,19 @@ const (
 	Canada       = "can"
 
 	// Marketplace Constants
-	Walmart = "walmart.com"
-	Ebay    = "ebay.com"
-	BestBuy = "bestbuy.com"
-	Amazon  = "amazon.com"
+	Walmart       = "walmart.com"
+	WalmartCanada = "walmart.ca"
+	Ebay          = "ebay.com"
+	BestBuy       = "bestbuy.com"
+	BestBuyCanada = "bestbuy.ca"
+	Amazon        = "amazon.com"
+	Target        = "target.com"
+	AliExpress    = "aliexpress.com"
+	Feed          = "feed"
+
+	// Rating range
+	MinRating = 0
+	MaxRating = 5
 
 	// General
 	Name         = "name"
@@ -23,6 +32,10 @@ const (
 	SortOrder    = "sortOrder"
 	RowsPerPage  = "rowsPerPage"
 	Upc          = "upc"
+	PartyName    = "partyName"
+	Category     = "productCategory"
+	PageState    = "pageState"
+	Count        = "count"
 	Ean          = "ean"
 	Isbn         = "isbn"
 	Keywords     = "keywords"
@@ -35,8 +48,20 @@ const (
 	Trending     = "trending"
 	Search       = "search"
 	NoResults    = "noResults"
+	CategoryId   = "categoryId"
+	MinPrice     = "minPrice"
+	MaxPrice     = "maxPrice"
+	BestSeller   = "bestseller"
+	Newest       = "new"
 
 	// Error Codes
-	InvalidRequest = "invalid request"
-	InternalError  = "internal error"
+	InvalidRequest       = "invalid request"
+	InternalError        = "internal error"
+	ProvidersUnavailable = "providers unavailable"
+	ProvidersThrottled   = "providers throttled"
+	CacheDisabled        = "cache disabled"
+	Unauthorized         = "unauthorized"
+	NotFound             = "not found"
+	VersionConflict      = "version conflict"
+	VersionRequired      = "version required"
 )
@@ -0,0 +1,52 @@
+package model
+
+import "strings"
+
+type isoCountry struct {
+	alpha3 string
+	alpha2 string
+	name   string
+}
+
+// country aliases accepted in requests: alpha-2 and alpha-3 codes and english names of ISO countries
+var countryAliases = map[string]string{
+	"uk":                       "gbr",
+	"america":                  UnitedStates,
+	"united states of america": UnitedStates,
+	"great britain":            "gbr",
+}
+
+// alpha-2 code of each country alpha-3 code
+var countryAlpha2 = make(map[string]string)
+
+func init() {
+	for _, c := range isoCountries {
+		countryAlpha2[c.alpha3] = c.alpha2
+		countryAliases[c.alpha3] = c.alpha3
+		countryAliases[c.alpha2] = c.alpha3
+		countryAliases[c.name] = c.alpha3
+	}
+}
+
+// normalizes a country param to its lower-cased ISO alpha-3 code, empty defaults to UnitedStates
+func NormalizeCountry(country string) string {
+	c := strings.ToLower(strings.TrimSpace(country))
+	if c == "" {
+		return UnitedStates
+	}
+	if code, ok := countryAliases[c]; ok {
+		return code
+	}
+	return c
+}
+
+// returns if country is an ISO country code or name, empty is the default country
+func IsValidCountry(country string) bool {
+	_, ok := countryAlpha2[NormalizeCountry(country)]
+	return ok
+}
+
+// returns the upper-cased ISO alpha-2 code of a country used by marketplace regional endpoints, empty if unknown
+func CountryAlpha2(country string) string {
+	return strings.ToUpper(countryAlpha2[NormalizeCountry(country)])
+}
@@ -0,0 +1,254 @@
+package model
+
+// ISO 3166-1 countries: alpha-3 code, alpha-2 code and english name
+var isoCountries = []isoCountry{
+	{"abw", "aw", "aruba"},
+	{"afg", "af", "afghanistan"},
+	{"ago", "ao", "angola"},
+	{"aia", "ai", "anguilla"},
+	{"ala", "ax", "åland islands"},
+	{"alb", "al", "albania"},
+	{"and", "ad", "andorra"},
+	{"are", "ae", "united arab emirates"},
+	{"arg", "ar", "argentina"},
+	{"arm", "am", "armenia"},
+	{"asm", "as", "american samoa"},
+	{"ata", "aq", "antarctica"},
+	{"atf", "tf", "french southern territories"},
+	{"atg", "ag", "antigua and barbuda"},
+	{"aus", "au", "australia"},
+	{"aut", "at", "austria"},
+	{"aze", "az", "azerbaijan"},
+	{"bdi", "bi", "burundi"},
+	{"bel", "be", "belgium"},
+	{"ben", "bj", "benin"},
+	{"bes", "bq", "bonaire, sint eustatius and saba"},
+	{"bfa", "bf", "burkina faso"},
+	{"bgd", "bd", "bangladesh"},
+	{"bgr", "bg", "bulgaria"},
+	{"bhr", "bh", "bahrain"},
+	{"bhs", "bs", "bahamas"},
+	{"bih", "ba", "bosnia and herzegovina"},
+	{"blm", "bl", "saint barthélemy"},
+	{"blr", "by", "belarus"},
+	{"blz", "bz", "belize"},
+	{"bmu", "bm", "bermuda"},
+	{"bol", "bo", "bolivia"},
+	{"bra", "br", "brazil"},
+	{"brb", "bb", "barbados"},
+	{"brn", "bn", "brunei darussalam"},
+	{"btn", "bt", "bhutan"},
+	{"bvt", "bv", "bouvet island"},
+	{"bwa", "bw", "botswana"},
+	{"caf", "cf", "central african republic"},
+	{"can", "ca", "canada"},
+	{"cck", "cc", "cocos (keeling) islands"},
+	{"che", "ch", "switzerland"},
+	{"chl", "cl", "chile"},
+	{"chn", "cn", "china"},
+	{"civ", "ci", "côte d'ivoire"},
+	{"cmr", "cm", "cameroon"},
+	{"cod", "cd", "congo, the democratic republic of the"},
+	{"cog", "cg", "congo"},
+	{"cok", "ck", "cook islands"},
+	{"col", "co", "colombia"},
+	{"com", "km", "comoros"},
+	{"cpv", "cv", "cabo verde"},
+	{"cri", "cr", "costa rica"},
+	{"cub", "cu", "cuba"},
+	{"cuw", "cw", "curaçao"},
+	{"cxr", "cx", "christmas island"},
+	{"cym", "ky", "cayman islands"},
+	{"cyp", "cy", "cyprus"},
+	{"cze", "cz", "czechia"},
+	{"deu", "de", "germany"},
+	{"dji", "dj", "djibouti"},
+	{"dma", "dm", "dominica"},
+	{"dnk", "dk", "denmark"},
+	{"dom", "do", "dominican republic"},
+	{"dza", "dz", "algeria"},
+	{"ecu", "ec", "ecuador"},
+	{"egy", "eg", "egypt"},
+	{"eri", "er", "eritrea"},
+	{"esh", "eh", "western sahara"},
+	{"esp", "es", "spain"},
+	{"est", "ee", "estonia"},
+	{"eth", "et", "ethiopia"},
+	{"fin", "fi", "finland"},
+	{"fji", "fj", "fiji"},
+	{"flk", "fk", "falkland islands (malvinas)"},
+	{"fra", "fr", "france"},
+	{"fro", "fo", "faroe islands"},
+	{"fsm", "fm", "micronesia, federated states of"},
+	{"gab", "ga", "gabon"},
+	{"gbr", "gb", "united kingdom"},
+	{"geo", "ge", "georgia"},
+	{"ggy", "gg", "guernsey"},
+	{"gha", "gh", "ghana"},
+	{"gib", "gi", "gibraltar"},
+	{"gin", "gn", "guinea"},
+	{"glp", "gp", "guadeloupe"},
+	{"gmb", "gm", "gambia"},
+	{"gnb", "gw", "guinea-bissau"},
+	{"gnq", "gq", "equatorial guinea"},
+	{"grc", "gr", "greece"},
+	{"grd", "gd", "grenada"},
+	{"grl", "gl", "greenland"},
+	{"gtm", "gt", "guatemala"},
+	{"guf", "gf", "french guiana"},
+	{"gum", "gu", "guam"},
+	{"guy", "gy", "guyana"},
+	{"hkg", "hk", "hong kong"},
+	{"hmd", "hm", "heard island and mcdonald islands"},
+	{"hnd", "hn", "honduras"},
+	{"hrv", "hr", "croatia"},
+	{"hti", "ht", "haiti"},
+	{"hun", "hu", "hungary"},
+	{"idn", "id", "indonesia"},
+	{"imn", "im", "isle of man"},
+	{"ind", "in", "india"},
+	{"iot", "io", "british indian ocean territory"},
+	{"irl", "ie", "ireland"},
+	{"irn", "ir", "iran"},
+	{"irq", "iq", "iraq"},
+	{"isl", "is", "iceland"},
+	{"isr", "il", "israel"},
+	{"ita", "it", "italy"},
+	{"jam", "jm", "jamaica"},
+	{"jey", "je", "jersey"},
+	{"jor", "jo", "jordan"},
+	{"jpn", "jp", "japan"},
+	{"kaz", "kz", "kazakhstan"},
+	{"ken", "ke", "kenya"},
+	{"kgz", "kg", "kyrgyzstan"},
+	{"khm", "kh", "cambodia"},
+	{"kir", "ki", "kiribati"},
+	{"kna", "kn", "saint kitts and nevis"},
+	{"kor", "kr", "south korea"},
+	{"kwt", "kw", "kuwait"},
+	{"lao", "la", "laos"},
+	{"lbn", "lb", "lebanon"},
+	{"lbr", "lr", "liberia"},
+	{"lby", "ly", "libya"},
+	{"lca", "lc", "saint lucia"},
+	{"lie", "li", "liechtenstein"},
+	{"lka", "lk", "sri lanka"},
+	{"lso", "ls", "lesotho"},
+	{"ltu", "lt", "lithuania"},
+	{"lux", "lu", "luxembourg"},
+	{"lva", "lv", "latvia"},
+	{"mac", "mo", "macao"},
+	{"maf", "mf", "saint martin (french part)"},
+	{"mar", "ma", "morocco"},
+	{"mco", "mc", "monaco"},
+	{"mda", "md", "moldova"},
+	{"mdg", "mg", "madagascar"},
+	{"mdv", "mv", "maldives"},
+	{"mex", "mx", "mexico"},
+	{"mhl", "mh", "marshall islands"},
+	{"mkd", "mk", "north macedonia"},
+	{"mli", "ml", "mali"},
+	{"mlt", "mt", "malta"},
+	{"mmr", "mm", "myanmar"},
+	{"mne", "me", "montenegro"},
+	{"mng", "mn", "mongolia"},
+	{"mnp", "mp", "northern mariana islands"},
+	{"moz", "mz", "mozambique"},
+	{"mrt", "mr", "mauritania"},
+	{"msr", "ms", "montserrat"},
+	{"mtq", "mq", "martinique"},
+	{"mus", "mu", "mauritius"},
+	{"mwi", "mw", "malawi"},
+	{"mys", "my", "malaysia"},
+	{"myt", "yt", "mayotte"},
+	{"nam", "na", "namibia"},
+	{"ncl", "nc", "new caledonia"},
+	{"ner", "ne", "niger"},
+	{"nfk", "nf", "norfolk island"},
+	{"nga", "ng", "nigeria"},
+	{"nic", "ni", "nicaragua"},
+	{"niu", "nu", "niue"},
+	{"nld", "nl", "netherlands"},
+	{"nor", "no", "norway"},
+	{"npl", "np", "nepal"},
+	{"nru", "nr", "nauru"},
+	{"nzl", "nz", "new zealand"},
+	{"omn", "om", "oman"},
+	{"pak", "pk", "pakistan"},
+	{"pan", "pa", "panama"},
+	{"pcn", "pn", "pitcairn"},
+	{"per", "pe", "peru"},
+	{"phl", "ph", "philippines"},
+	{"plw", "pw", "palau"},
+	{"png", "pg", "papua new guinea"},
+	{"pol", "pl", "poland"},
+	{"pri", "pr", "puerto rico"},
+	{"prk", "kp", "north korea"},
+	{"prt", "pt", "portugal"},
+	{"pry", "py", "paraguay"},
+	{"pse", "ps", "palestine, state of"},
+	{"pyf", "pf", "french polynesia"},
+	{"qat", "qa", "qatar"},
+	{"reu", "re", "réunion"},
+	{"rou", "ro", "romania"},
+	{"rus", "ru", "russian federation"},
+	{"rwa", "rw", "rwanda"},
+	{"sau", "sa", "saudi arabia"},
+	{"sdn", "sd", "sudan"},
+	{"sen", "sn", "senegal"},
+	{"sgp", "sg", "singapore"},
+	{"sgs", "gs", "south georgia and the south sandwich islands"},
+	{"shn", "sh", "saint helena, ascension and tristan da cunha"},
+	{"sjm", "sj", "svalbard and jan mayen"},
+	{"slb", "sb", "solomon islands"},
+	{"sle", "sl", "sierra leone"},
+	{"slv", "sv", "el salvador"},
+	{"smr", "sm", "san marino"},
+	{"som", "so", "somalia"},
+	{"spm", "pm", "saint pierre and miquelon"},
+	{"srb", "rs", "serbia"},
+	{"ssd", "ss", "south sudan"},
+	{"stp", "st", "sao tome and principe"},
+	{"sur", "sr", "suriname"},
+	{"svk", "sk", "slovakia"},
+	{"svn", "si", "slovenia"},
+	{"swe", "se", "sweden"},
+	{"swz", "sz", "eswatini"},
+	{"sxm", "sx", "sint maarten (dutch part)"},
+	{"syc", "sc", "seychelles"},
+	{"syr", "sy", "syria"},
+	{"tca", "tc", "turks and caicos islands"},
+	{"tcd", "td", "chad"},
+	{"tgo", "tg", "togo"},
+	{"tha", "th", "thailand"},
+	{"tjk", "tj", "tajikistan"},
+	{"tkl", "tk", "tokelau"},
+	{"tkm", "tm", "turkmenistan"},
+	{"tls", "tl", "timor-leste"},
+	{"ton", "to", "tonga"},
+	{"tto", "tt", "trinidad and tobago"},
+	{"tun", "tn", "tunisia"},
+	{"tur", "tr", "türkiye"},
+	{"tuv", "tv", "tuvalu"},
+	{"twn", "tw", "taiwan"},
+	{"tza", "tz", "tanzania"},
+	{"uga", "ug", "uganda"},
+	{"ukr", "ua", "ukraine"},
+	{"umi", "um", "united states minor outlying islands"},
+	{"ury", "uy", "uruguay"},
+	{"usa", "us", "united states"},
+	{"uzb", "uz", "uzbekistan"},
+	{"vat", "va", "holy see (vatican city state)"},
+	{"vct", "vc", "saint vincent and the grenadines"},
+	{"ven", "ve", "venezuela"},
+	{"vgb", "vg", "virgin islands, british"},
+	{"vir", "vi", "virgin islands, u.s."},
+	{"vnm", "vn", "vietnam"},
+	{"vut", "vu", "vanuatu"},
+	{"wlf", "wf", "wallis and futuna"},
+	{"wsm", "ws", "samoa"},
+	{"yem", "ye", "yemen"},
+	{"zaf", "za", "south africa"},
+	{"zmb", "zm", "zambia"},
+	{"zwe", "zw", "zimbabwe"},
+}
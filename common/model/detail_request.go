@@ -7,19 +7,20 @@ type DetailRequest struct {
 	Country string `json:"country"`
 }
 
+// builds a detail request normalizing its country, empty defaults to UnitedStates
 func NewDetailRequest(id, idType, source, country string) *DetailRequest {
 	o := &DetailRequest{
 		Id:      id,
 		IdType:  idType,
 		Source:  source,
-		Country: country,
+		Country: NormalizeCountry(country),
 	}
 	return o
 }
 
 // Checks if request is valid
 func (r *DetailRequest) IsValid() bool {
-	if r.Id == "" || r.IdType == "" || r.Source == "" {
+	if r.Id == "" || r.IdType == "" || r.Source == "" || !IsValidCountry(r.Country) {
 		return false
 	}
 
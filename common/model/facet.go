@@ -0,0 +1,45 @@
+package model
+
+// Facet is a search refinement with the number of offers of each of its values
+type Facet struct {
+	Name   string       `json:"name"`
+	Values []FacetValue `json:"values"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+func NewFacet(name string, values []FacetValue) Facet {
+	return Facet{
+		Name:   name,
+		Values: values,
+	}
+}
+
+// merges facets into list adding the counts of facets and values present in both, keeping their order
+func MergeFacets(list []Facet, facets []Facet) []Facet {
+	for _, f := range facets {
+		i := 0
+		for i < len(list) && list[i].Name != f.Name {
+			i++
+		}
+		if i == len(list) {
+			list = append(list, NewFacet(f.Name, make([]FacetValue, 0, len(f.Values))))
+		}
+
+		for _, v := range f.Values {
+			j := 0
+			for j < len(list[i].Values) && list[i].Values[j].Value != v.Value {
+				j++
+			}
+			if j == len(list[i].Values) {
+				list[i].Values = append(list[i].Values, v)
+			} else {
+				list[i].Values[j].Count += v.Count
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,6 @@
+package model
+
+// represents the result of loading datastore fixtures
+type FixturesLoad struct {
+	Offers int `json:"offers"`
+}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 type ListRequest struct {
@@ -11,6 +13,7 @@ type ListRequest struct {
 	SortOrder     string      `json:"sortOrder"`
 	Page          int         `json:"page"`
 	RowsPerPage   int         `json:"rowsPerPage"`
+	PageState     string      `json:"pageState,omitempty"`
 }
 
 func NewListRequest(searchColumns []NameValue, sortBy, sortOrder string, page, rowsPerPage int) *ListRequest {
@@ -68,5 +71,63 @@ func (r *ListRequest) IsValid() bool {
 		}
 	}
 
+	// check valid country and price range
+	for _, p := range r.SearchColumns {
+		switch p.Name {
+		case Country:
+			if !IsValidCountry(p.Value) {
+				return false
+			}
+		case MinPrice, MaxPrice:
+			if v, err := strconv.ParseFloat(p.Value, 32); err != nil || v < 0 {
+				return false
+			}
+		}
+	}
+
 	return true
 }
+
+// builds the canonical form of this request so equivalent requests share the same cache key:
+// keywords are lower-cased, trimmed and deduplicated, columns are sorted by name with the last value of
+// repeated names kept, country is normalized and filled in and sort order is dropped if there's no sort field.
+func (r *ListRequest) Canonical() *ListRequest {
+	columns := make(map[string]string)
+	for _, p := range r.SearchColumns {
+		columns[p.Name] = strings.TrimSpace(p.Value)
+	}
+	columns[Name] = NormalizeKeywords(columns[Name])
+	columns[Country] = NormalizeCountry(columns[Country])
+
+	searchColumns := make([]NameValue, 0, len(columns))
+	for name, value := range columns {
+		if value != "" {
+			searchColumns = append(searchColumns, NewNameValue(name, value))
+		}
+	}
+	sort.Slice(searchColumns, func(i, j int) bool {
+		return searchColumns[i].Name < searchColumns[j].Name
+	})
+
+	sortOrder := strings.ToLower(strings.TrimSpace(r.SortOrder))
+	if r.SortBy == "" {
+		sortOrder = ""
+	}
+
+	c := NewListRequest(searchColumns, r.SortBy, sortOrder, r.Page, r.RowsPerPage)
+	c.PageState = r.PageState
+	return c
+}
+
+// lower-cases and removes blank and repeated words of a keyword query keeping its word order
+func NormalizeKeywords(keyword string) string {
+	words := make([]string, 0)
+	found := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(keyword)) {
+		if !found[w] {
+			found[w] = true
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
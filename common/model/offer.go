@@ -1,10 +1,15 @@
 package model
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
 
 // represents an offer for a Product or service offered by a provider in a specific moment of Time
 // Id represents the internal Id of this offer to uniquely address this offer within the system
 // External Id represents the external Id used by the provider to address this entity in their system
+// Condition, Seller and ShippingCost are set by providers that expose them, a nil ShippingCost is unknown
+// Version is incremented by the datastore on every update and is used for optimistic concurrency
 type Offer struct {
 	Id                string    `json:"id"`
 	ExternalId        string    `json:"externalId"`
@@ -19,6 +24,11 @@ type Offer struct {
 	Rating            float32   `json:"rating"`
 	NumReviews        int       `json:"numReviews"`
 	Created           time.Time `json:"created"`
+	Condition         string    `json:"condition,omitempty"`
+	Seller            string    `json:"seller,omitempty"`
+	ShippingCost      *float32  `json:"shippingCost,omitempty"`
+	Brand             string    `json:"brand,omitempty"`
+	Version           int64     `json:"version,omitempty"`
 }
 
 func NewOffer(id, externalId, upc, name, partyName, semanticName, mainImageUrl, partyImageUrl, productCategory string, price, rating float32, numReviews int, created time.Time) *Offer {
@@ -40,6 +50,30 @@ func NewOffer(id, externalId, upc, name, partyName, semanticName, mainImageUrl,
 	return o
 }
 
+// Checks if offer is valid to be stored: name is set, price and numReviews are not negative, rating is
+// within MinRating and MaxRating, urls are absolute http(s) urls and party name is a known marketplace
+func (o *Offer) IsValid(isKnownParty func(name string) bool) bool {
+	if o.Name == "" || o.Price < 0 || o.NumReviews < 0 || o.Rating < MinRating || o.Rating > MaxRating {
+		return false
+	}
+
+	if !isValidUrl(o.SemanticName) || !isValidUrl(o.MainImageFileUrl) {
+		return false
+	}
+
+	return isKnownParty(o.PartyName)
+}
+
+// checks if s is empty or an absolute http(s) url
+func isValidUrl(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	u, err := url.ParseRequestURI(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 // represents an Offer with Rank information:
 // num keywords - the number of keywords found out of a group of N keywords
 // total - total matches of keywords found in this offer
@@ -0,0 +1,31 @@
+package model
+
+import "github.com/google/uuid"
+
+// namespace of offer ids generated by NewOfferId
+var offerIdNamespace = uuid.MustParse("8f7c2a1e-5d3b-4c6a-9e0f-1b2d3c4e5f60")
+
+// OfferRef references the offer of a provider addressed by an offer id
+type OfferRef struct {
+	Id         string `json:"id"`
+	PartyName  string `json:"partyName"`
+	ExternalId string `json:"externalId"`
+	Country    string `json:"country"`
+}
+
+// Builds the offer id of an offer of provider by its external id in country, the same offer always gets
+// the same id: a name based UUID (version 5) of provider, external id and normalized country
+func NewOfferId(partyName, externalId, country string) string {
+	name := partyName + "|" + externalId + "|" + NormalizeCountry(country)
+	return uuid.NewSHA1(offerIdNamespace, []byte(name)).String()
+}
+
+// Builds the reference of an offer of provider by its external id in country
+func NewOfferRef(partyName, externalId, country string) *OfferRef {
+	return &OfferRef{
+		Id:         NewOfferId(partyName, externalId, country),
+		PartyName:  partyName,
+		ExternalId: externalId,
+		Country:    NormalizeCountry(country),
+	}
+}
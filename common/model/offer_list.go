@@ -1,15 +1,20 @@
 package model
 
-// represents a List of offers response with a summary
+// represents a List of offers response with a summary, the facets of the offers found and the canonical form
+// of the request that produced it
 type OfferList struct {
 	List    []Offer `json:"list"`
 	Summary `json:"summary"`
+	Facets  []Facet      `json:"facets,omitempty"`
+	Request *ListRequest `json:"request,omitempty"`
 }
 
+// NextPageState is the token used to fetch the page after this one, empty on the last page
 type Summary struct {
-	Page       int `json:"page"`
-	PageCount  int `json:"pageCount"`
-	TotalCount int `json:"totalCount"`
+	Page          int    `json:"page"`
+	PageCount     int    `json:"pageCount"`
+	TotalCount    int    `json:"totalCount"`
+	NextPageState string `json:"nextPageState,omitempty"`
 }
 
 func NewOfferList(list []Offer, page int, pageCount int, total int) *OfferList {
@@ -1,15 +1,32 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/db"
 	"github.com/guilhebl/go-offer/offer"
 )
 
-// runs app in PROD mode
+// runs app in PROD mode, or else a datastore command and exits:
+// -migrate applies the pending Cassandra schema migrations, with -dry-run they are only printed
+// -fixtures replaces all offers in datastore with the sample offers fixture
 func main() {
-	run("prod")
+	migrate := flag.Bool("migrate", false, "apply pending datastore schema migrations and exit")
+	dryRun := flag.Bool("dry-run", false, "with -migrate print pending migrations without applying them")
+	fixtures := flag.Bool("fixtures", false, "load the sample offers fixture into datastore and exit")
+	flag.Parse()
+
+	switch {
+	case *migrate:
+		runMigrations(config.Prod, *dryRun)
+	case *fixtures:
+		loadFixtures(config.Prod)
+	default:
+		run(config.Prod)
+	}
 }
 
 // run starts the app
@@ -25,8 +42,33 @@ func run(mode string) {
 // starts a new server instance using mode config and port
 func startServer(port, mode string) {
 
-	// inits app module setting up worker pool and other global scoped objects
-	offer.BuildInstance(mode)
+	// builds app module setting up worker pool, marketplace providers and other app scoped objects
+	app := offer.BuildModule(mode)
+
+	log.Fatal(http.ListenAndServe(port, app.Router))
+}
+
+// applies the pending schema migrations to the Cassandra keyspace of mode config
+func runMigrations(mode string, dryRun bool) {
+	c := config.NewConfiguration(mode)
+	migrations, err := offer.NewCassandraClient(c).Migrate(c.GetProperty("cassandraMigrationsDir"), dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if dryRun {
+		log.Printf("Migrations pending: %d", len(migrations))
+		return
+	}
+	log.Printf("Migrations applied: %d", len(migrations))
+}
 
-	log.Fatal(http.ListenAndServe(port, offer.GetInstance().Router))
+// replaces all offers in the datastore of mode config with the sample offers fixture
+func loadFixtures(mode string) {
+	c := config.NewConfiguration(mode)
+	n, err := db.LoadFixtures(offer.NewOfferStore(c), c.GetProperty("datastoreFixturesFile"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Fixtures loaded: %d offers", n)
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
 	"github.com/guilhebl/go-offer/offer"
 	"github.com/stretchr/testify/assert"
@@ -12,38 +14,30 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"testing"
 )
 
-var app offer.Module
-
-func init() {
-	runtime.LockOSThread()
-}
+var app *offer.Module
 
 // TestMain builds an instance of the application in test mode to run e2e tests. Every external calls are intercepted through stubs
 // that returns test JSON data instead of really calling external services.
-// This way it's possible to build and run offline Functional Tests on top of the actual running stack and test multiple end-to-end scenarios.
+// This way it's possible to build and run offline Functional Tests on top of the actual app stack and test multiple end-to-end scenarios.
 func TestMain(m *testing.M) {
 	setup()
-	go func() {
-		exitVal := m.Run()
-		teardown()
-		os.Exit(exitVal)
-	}()
-
-	log.Println("setting up test server...")
-	run("test")
+	exitVal := m.Run()
+	teardown()
+	os.Exit(exitVal)
 }
 
 func setup() {
 	log.Println("SETUP")
+	app = offer.BuildModule(config.Test)
 }
 
 func teardown() {
 	log.Println("TEARDOWN")
+	app.Stop()
 }
 
 func check(e error) {
@@ -60,18 +54,39 @@ func readFile(path string) []byte {
 }
 
 const (
-	WalmartTrendingUrl       = "http://api.walmartlabs.com/v1/trends"
-	WalmartSearchUrl         = "http://api.walmartlabs.com/v1/search"
-	WalmartGetDetailUrl      = "http://api.walmartlabs.com/v1/items/53966162"
-	WalmartGetDetailByUpcUrl = "http://api.walmartlabs.com/v1/items"
-	BestBuyTrendingUrl       = "https://api.bestbuy.com/beta/products/trendingViewed"
-	BestBuySearchUrl         = "https://api.bestbuy.com/v1/products(search=skyrim)"
-	BestBuyGetDetailUrl      = "https://api.bestbuy.com/v1/products(productId=5529006)"
-	BestBuyGetDetailByUpcUrl = "https://api.bestbuy.com/v1/products(upc=065857174434)"
-	EbaySearchUrl            = "http://svcs.ebay.com/services/search/FindingService/v1"
-	EbayGetDetailUrl         = "http://svcs.ebay.com/services/search/FindingService/v1"
-	AmazonSearchUrl          = "https://webservices.amazon.com/onca/xml"
-	AmazonGetDetailUrl       = "https://webservices.amazon.com/onca/xml"
+	WalmartTrendingUrl         = "http://api.walmartlabs.com/v1/trends"
+	WalmartSearchUrl           = "http://api.walmartlabs.com/v1/search"
+	WalmartGetDetailUrl        = "http://api.walmartlabs.com/v1/items/53966162"
+	WalmartGetDetailByUpcUrl   = "http://api.walmartlabs.com/v1/items"
+	BestBuyTrendingUrl         = "https://api.bestbuy.com/beta/products/trendingViewed"
+	BestBuySearchUrl           = "https://api.bestbuy.com/v1/products(search=skyrim)"
+	BestBuyGetDetailUrl        = "https://api.bestbuy.com/v1/products(productId=5529006)"
+	BestBuyGetDetailByUpcUrl   = "https://api.bestbuy.com/v1/products(upc=065857174434)"
+	WalmartCASearchUrl         = "https://api.walmart.ca/v1/search"
+	WalmartCAGetDetailUrl      = "https://api.walmart.ca/v1/items/6000197438161"
+	WalmartCAGetDetailByUpcUrl = "https://api.walmart.ca/v1/items"
+	BestBuyCASearchUrl         = "https://www.bestbuy.ca/api/v2/json/search"
+	BestBuyCAGetDetailUrl      = "https://www.bestbuy.ca/api/v2/json/product/10441046"
+	BestBuyCAGetDetailByUpcUrl = BestBuyCASearchUrl
+	EbayTokenUrl               = "https://api.ebay.com/identity/v1/oauth2/token"
+	EbayBrowseUrl              = "https://api.ebay.com/buy/browse/v1"
+	EbaySearchUrl              = EbayBrowseUrl + "/item_summary/search"
+	EbayGetDetailUrl           = EbayBrowseUrl + "/item/get_item_by_legacy_id"
+	EbayGetDetailByUpcUrl      = EbaySearchUrl
+	EbayGetItemsUrl            = EbayBrowseUrl + "/item/"
+	AmazonSearchUrl            = "https://webservices.amazon.com/paapi5/searchitems"
+	AmazonGetDetailUrl         = "https://webservices.amazon.com/paapi5/getitems"
+	AmazonGetDetailByUpcUrl    = "https://webservices.amazon.com/paapi5/searchitems"
+	AmazonCASearchUrl          = "https://webservices.amazon.ca/paapi5/searchitems"
+	AmazonCAGetDetailByUpcUrl  = AmazonCASearchUrl
+	TargetSearchUrl            = "https://redsky.target.com/redsky_aggregations/v1/web/plp_search_v2"
+	TargetGetDetailUrl         = "https://redsky.target.com/redsky_aggregations/v1/web/pdp_client_v1"
+	TargetGetDetailByUpcUrl    = TargetSearchUrl
+	AliExpressUrl              = "https://api-sg.aliexpress.com/sync"
+	AliExpressSearchUrl        = AliExpressUrl
+	AliExpressGetDetailUrl     = AliExpressUrl
+	DealsExampleSearchUrl      = "https://api.deals.example.com/v2/products"
+	DealsExampleGetDetailUrl   = "https://api.deals.example.com/v2/products/DX-20931"
 )
 
 // returns the bytes of a corresponding mock API call for an external resource for the 'Trending' API CALL
@@ -83,8 +98,14 @@ func getJsonBytesTrendingMock(url string) []byte {
 		return readFile("offer/bestbuy/testdata/bestbuy_sample_trending_response.json")
 	case EbaySearchUrl:
 		return readFile("offer/ebay/testdata/ebay_sample_trending_response.json")
+	case EbayGetItemsUrl:
+		return readFile("offer/ebay/testdata/ebay_sample_get_items_response.json")
 	case AmazonSearchUrl:
-		return readFile("offer/amazon/testdata/amazon_sample_trending_response.xml")
+		return readFile("offer/amazon/testdata/amazon_sample_trending_response.json")
+	case TargetSearchUrl:
+		return readFile("offer/target/testdata/target_sample_trending_response.json")
+	case AliExpressSearchUrl:
+		return readFile("offer/aliexpress/testdata/aliexpress_sample_trending_response.json")
 
 	default:
 		return nil
@@ -100,9 +121,21 @@ func getJsonBytesSearchMock(url string) []byte {
 		return readFile("offer/bestbuy/testdata/bestbuy_sample_search_response.json")
 	case EbaySearchUrl:
 		return readFile("offer/ebay/testdata/ebay_sample_search_response.json")
-	case AmazonSearchUrl:
-		return readFile("offer/amazon/testdata/amazon_sample_search_response.xml")
-
+	case EbayGetItemsUrl:
+		return readFile("offer/ebay/testdata/ebay_sample_get_items_response.json")
+	case AmazonSearchUrl, AmazonCASearchUrl:
+		return readFile("offer/amazon/testdata/amazon_sample_search_response.json")
+	case TargetSearchUrl:
+		return readFile("offer/target/testdata/target_sample_search_response.json")
+	case AliExpressSearchUrl:
+		return readFile("offer/aliexpress/testdata/aliexpress_sample_search_response.json")
+	case DealsExampleSearchUrl:
+		return readFile("offer/rest/testdata/deals_example_search_response.json")
+
+	case WalmartCASearchUrl:
+		return readFile("offer/walmart/testdata/walmart_ca_sample_search_response.json")
+	case BestBuyCASearchUrl:
+		return readFile("offer/bestbuy/testdata/bestbuy_ca_sample_search_response.json")
 	default:
 		return nil
 	}
@@ -118,8 +151,16 @@ func getJsonBytesSearchNoResultsMock(url string) []byte {
 	case EbaySearchUrl:
 		return readFile("offer/ebay/testdata/ebay_search_no_results.json")
 	case AmazonSearchUrl:
-		return readFile("offer/amazon/testdata/amazon_search_no_results.xml")
-
+		return readFile("offer/amazon/testdata/amazon_search_no_results.json")
+	case TargetSearchUrl:
+		return readFile("offer/target/testdata/target_search_no_results.json")
+	case AliExpressSearchUrl:
+		return readFile("offer/aliexpress/testdata/aliexpress_search_no_results.json")
+	case DealsExampleSearchUrl:
+		return readFile("offer/rest/testdata/deals_example_search_no_results.json")
+
+	case BestBuyCASearchUrl:
+		return readFile("offer/bestbuy/testdata/bestbuy_ca_search_no_results.json")
 	default:
 		return nil
 	}
@@ -135,8 +176,18 @@ func getJsonBytesGetDetailByIdMock(url string) []byte {
 	case EbayGetDetailUrl:
 		return readFile("offer/ebay/testdata/ebay_sample_get_detail_by_id_response.json")
 	case AmazonGetDetailUrl:
-		return readFile("offer/amazon/testdata/amazon_sample_get_detail_by_id_response.xml")
-
+		return readFile("offer/amazon/testdata/amazon_sample_get_detail_by_id_response.json")
+	case TargetGetDetailUrl:
+		return readFile("offer/target/testdata/target_sample_get_detail_by_id_response.json")
+	case AliExpressGetDetailUrl:
+		return readFile("offer/aliexpress/testdata/aliexpress_sample_get_detail_by_id_response.json")
+	case DealsExampleGetDetailUrl:
+		return readFile("offer/rest/testdata/deals_example_get_detail_by_id_response.json")
+
+	case WalmartCAGetDetailUrl:
+		return readFile("offer/walmart/testdata/walmart_ca_sample_get_detail_by_id_response.json")
+	case BestBuyCAGetDetailUrl:
+		return readFile("offer/bestbuy/testdata/bestbuy_ca_sample_get_detail_by_id_response.json")
 	default:
 		return nil
 	}
@@ -149,11 +200,19 @@ func getJsonBytesGetDetailByUpcMock(url string) []byte {
 		return readFile("offer/walmart/testdata/walmart_sample_get_detail_by_upc_response.json")
 	case BestBuyGetDetailByUpcUrl:
 		return readFile("offer/bestbuy/testdata/bestbuy_sample_get_detail_by_upc_response.json")
-	case EbayGetDetailUrl:
+	case EbayGetDetailByUpcUrl:
 		return readFile("offer/ebay/testdata/ebay_find_by_upc.json")
-	case AmazonGetDetailUrl:
-		return readFile("offer/amazon/testdata/amazon_sample_get_detail_by_upc_response.xml")
-
+	case AmazonGetDetailByUpcUrl, AmazonCAGetDetailByUpcUrl:
+		return readFile("offer/amazon/testdata/amazon_sample_get_detail_by_upc_response.json")
+	case TargetGetDetailByUpcUrl:
+		return readFile("offer/target/testdata/target_sample_get_detail_by_upc_response.json")
+	case DealsExampleSearchUrl:
+		return readFile("offer/rest/testdata/deals_example_get_detail_by_upc_response.json")
+
+	case WalmartCAGetDetailByUpcUrl:
+		return readFile("offer/walmart/testdata/walmart_ca_sample_get_detail_by_upc_response.json")
+	case BestBuyCAGetDetailByUpcUrl:
+		return readFile("offer/bestbuy/testdata/bestbuy_ca_sample_get_detail_by_upc_response.json")
 	default:
 		return nil
 	}
@@ -166,18 +225,26 @@ func getJsonBytesGetDetailByUpcNoResultsMock(url string) []byte {
 		return readFile("offer/walmart/testdata/walmart_get_by_upc_not_found.json")
 	case BestBuyGetDetailByUpcUrl:
 		return readFile("offer/bestbuy/testdata/best_buy_get_by_upc_prod_detail_not_found.json")
-	case EbayGetDetailUrl:
+	case EbayGetDetailByUpcUrl:
 		return readFile("offer/ebay/testdata/ebay_find_by_upc_no_result.json")
-	case AmazonGetDetailUrl:
-		return readFile("offer/amazon/testdata/amazon_get_product_detail_by_upc_not_found.xml")
-
+	case AmazonGetDetailByUpcUrl:
+		return readFile("offer/amazon/testdata/amazon_get_product_detail_by_upc_not_found.json")
+	case TargetGetDetailByUpcUrl:
+		return readFile("offer/target/testdata/target_get_by_upc_not_found.json")
+	case AliExpressGetDetailUrl:
+		return readFile("offer/aliexpress/testdata/aliexpress_get_detail_not_found.json")
+
+	case WalmartCAGetDetailByUpcUrl:
+		return readFile("offer/walmart/testdata/walmart_ca_get_by_upc_not_found.json")
+	case BestBuyCAGetDetailByUpcUrl:
+		return readFile("offer/bestbuy/testdata/bestbuy_ca_search_no_results.json")
 	default:
 		return nil
 	}
 }
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
-	offer.GetInstance().Router.ServeHTTP(rr, req)
+	app.Router.ServeHTTP(rr, req)
 	return rr
 }
 
@@ -188,9 +255,17 @@ func assertCallsMade(t *testing.T, httpMethod, url string, expected int) {
 	log.Printf("Total External API Calls made to %s: %d", url, count)
 }
 
+// Registers the Mock OAuth token endpoint eBay Browse API calls authenticate with
+func registerMockResponderEbayToken(apiUrl string) {
+	if strings.HasPrefix(apiUrl, EbayBrowseUrl) {
+		httpmock.RegisterResponder(http.MethodPost, EbayTokenUrl, httpmock.NewBytesResponder(200, readFile("offer/ebay/testdata/ebay_oauth_token_response.json")))
+	}
+}
+
 // Registers Mock endpoint responders for Search based API calls
 func registerMockResponderSearch(httpMethod, apiUrl, apiType string, status int) {
 	log.Printf("Mocking Search: %s %d - %s", httpMethod, status, apiUrl)
+	registerMockResponderEbayToken(apiUrl)
 
 	switch apiType {
 	case model.Trending:
@@ -208,6 +283,7 @@ func registerMockResponderSearch(httpMethod, apiUrl, apiType string, status int)
 // Registers Mock endpoint responders for Get Detail based API calls
 func registerMockResponderGetDetail(httpMethod, apiUrl, apiType string, status int) {
 	log.Printf("Mocking GetDetail: %s %d - %s", httpMethod, status, apiUrl)
+	registerMockResponderEbayToken(apiUrl)
 
 	switch apiType {
 	case model.Id:
@@ -233,7 +309,9 @@ func TestSearch(t *testing.T) {
 	registerMockResponderSearch(http.MethodGet, WalmartTrendingUrl, model.Trending, 200)
 	registerMockResponderSearch(http.MethodGet, BestBuyTrendingUrl, model.Trending, 200)
 	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Trending, 200)
-	registerMockResponderSearch(http.MethodGet, AmazonSearchUrl, model.Trending, 200)
+	registerMockResponderSearch(http.MethodGet, EbayGetItemsUrl, model.Trending, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.Trending, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.Trending, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/"
@@ -258,11 +336,16 @@ func TestSearch(t *testing.T) {
 	bestBuySnippet := `"externalId":"5714687","upc":"","name":"Alienware - Aurora R6 Desktop - Intel Core i7 - 16GB Memory - NVIDIA GeForce GTX 1070 - 256GB Solid State Drive + 1TB Hard Drive - Silver","partyName":"bestbuy.com"`
 	assert.True(t, strings.Contains(body, bestBuySnippet))
 
+	targetSnippet := `"externalId":"53214968","upc":"885900539106","name":"Women's Rayna Sneakers - A New Day™ White","partyName":"target.com"`
+	assert.True(t, strings.Contains(body, targetSnippet))
+
 	// get the amount of calls for the registered responders
 	assertCallsMade(t, http.MethodGet, WalmartTrendingUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuyTrendingUrl, 1)
 	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetItemsUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 1)
 }
 
 // Tests Search with keywords that returns results from external APIs
@@ -275,7 +358,9 @@ func TestSearchWithKeywords(t *testing.T) {
 	registerMockResponderSearch(http.MethodGet, WalmartSearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, BestBuySearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
-	registerMockResponderSearch(http.MethodGet, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, EbayGetItemsUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.Search, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers"
@@ -297,17 +382,70 @@ func TestSearchWithKeywords(t *testing.T) {
 	bestBuySnippet := `"externalId":"5626200","upc":"600603210488","name":"The Elder Scrolls V: Skyrim Special Edition Best Buy Exclusive Dragonborn Bundle - Xbox One","partyName":"bestbuy.com"`
 	assert.True(t, strings.Contains(body, bestBuySnippet))
 
-	ebaySnippet := `"externalId":"223482818","upc":"","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
+	ebaySnippet := `"externalId":"322643800407","upc":"093155171251","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
 	assert.True(t, strings.Contains(body, ebaySnippet))
 
 	amazonSnippet := `"externalId":"B01GW8XJVU","upc":"093155171251","name":"The Elder Scrolls V: Skyrim - Special Edition - PlayStation 4","partyName":"amazon.com"`
 	assert.True(t, strings.Contains(body, amazonSnippet))
 
+	targetSnippet := `"externalId":"50833567","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition - PlayStation 4","partyName":"target.com"`
+	assert.True(t, strings.Contains(body, targetSnippet))
+
 	// get the amount of calls for the registered responders
 	assertCallsMade(t, http.MethodGet, WalmartSearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuySearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 1)
+}
+
+// Tests Search with keywords selecting facet values, facets of merged results are returned with native brand counts
+func TestSearchWithKeywordsFacets(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderSearch(http.MethodGet, WalmartSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, BestBuySearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.Search, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers"
+	var jsonRequest = []byte(`{"searchColumns":[{"name":"name","value":"skyrim"},{"name":"partyName","value":"walmart.com|bestbuy.com"}],"sortOrder":"asc","page":1,"rowsPerPage":10}`)
+
+	req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonRequest))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.Contains(body, `"partyName":"walmart.com"`))
+	assert.True(t, strings.Contains(body, `"partyName":"bestbuy.com"`))
+	assert.False(t, strings.Contains(body, `"partyName":"ebay.com"`))
+	assert.False(t, strings.Contains(body, `"partyName":"amazon.com","semanticName"`))
+
+	// only providers selected are searched and counted in the provider facet
+	assert.True(t, strings.Contains(body, `"facets":[{"name":"partyName","values":[{"value":"bestbuy.com","count":10},{"value":"walmart.com","count":10}]}`))
+
+	// brands are counted over offers, native brand facets are listed under their provider
+	assert.True(t, strings.Contains(body, `{"name":"brand","values":[{"value":"Bethesda","count":4},{"value":"Funko","count":2}]}`))
+	assert.True(t, strings.Contains(body, `{"name":"walmart.com:brand","values":[{"value":"Bethesda","count":31},{"value":"Funko","count":7}]}`))
+	assert.True(t, strings.Contains(body, `{"name":"price","values":[{"value":"0-25","count":`))
+
+	// totals are the ones of the providers searched
+	assert.True(t, strings.Contains(body, `"summary":{"page":1,"pageCount":7,"totalCount":56}`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, WalmartSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, BestBuySearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 0)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 0)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 0)
 }
 
 // Tests Search with keywords that returns results from external APIs - SORT by name DESC
@@ -320,7 +458,8 @@ func TestSearchWithKeywordsSortByNameDesc(t *testing.T) {
 	registerMockResponderSearch(http.MethodGet, WalmartSearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, BestBuySearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
-	registerMockResponderSearch(http.MethodGet, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.Search, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers"
@@ -343,7 +482,7 @@ func TestSearchWithKeywordsSortByNameDesc(t *testing.T) {
 	bestBuySnippet := `"externalId":"5626200","upc":"600603210488","name":"The Elder Scrolls V: Skyrim Special Edition Best Buy Exclusive Dragonborn Bundle - Xbox One","partyName":"bestbuy.com"`
 	assert.True(t, strings.Contains(body, bestBuySnippet))
 
-	ebaySnippet := `"externalId":"223482818","upc":"","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
+	ebaySnippet := `"externalId":"322643800407","upc":"","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
 	assert.True(t, strings.Contains(body, ebaySnippet))
 
 	amazonSnippet := `"externalId":"B01GW8XJVU","upc":"093155171251","name":"The Elder Scrolls V: Skyrim - Special Edition - PlayStation 4","partyName":"amazon.com"`
@@ -353,7 +492,8 @@ func TestSearchWithKeywordsSortByNameDesc(t *testing.T) {
 	assertCallsMade(t, http.MethodGet, WalmartSearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuySearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 1)
 }
 
 // Tests Search SORT by price ASC
@@ -366,7 +506,8 @@ func TestSearchWithKeywordsSortByPriceAsc(t *testing.T) {
 	registerMockResponderSearch(http.MethodGet, WalmartSearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, BestBuySearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
-	registerMockResponderSearch(http.MethodGet, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.Search, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers"
@@ -389,7 +530,7 @@ func TestSearchWithKeywordsSortByPriceAsc(t *testing.T) {
 	bestBuySnippet := `"externalId":"5626200","upc":"600603210488","name":"The Elder Scrolls V: Skyrim Special Edition Best Buy Exclusive Dragonborn Bundle - Xbox One","partyName":"bestbuy.com"`
 	assert.True(t, strings.Contains(body, bestBuySnippet))
 
-	ebaySnippet := `"externalId":"223482818","upc":"","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
+	ebaySnippet := `"externalId":"322643800407","upc":"","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
 	assert.True(t, strings.Contains(body, ebaySnippet))
 
 	amazonSnippet := `"externalId":"B01GW8XJVU","upc":"093155171251","name":"The Elder Scrolls V: Skyrim - Special Edition - PlayStation 4","partyName":"amazon.com"`
@@ -399,16 +540,14 @@ func TestSearchWithKeywordsSortByPriceAsc(t *testing.T) {
 	assertCallsMade(t, http.MethodGet, WalmartSearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuySearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 1)
 }
 
-// tests Reset and List from Datastore - Cassandra must be running
+// tests Load Fixtures and List from Datastore
 func TestSearchDatastore(t *testing.T) {
-	// 1st call Reset to reset database and re-create keystore
-	endpoint1 := "http://localhost:8080/reset"
-	req1, _ := http.NewRequest(http.MethodGet, endpoint1, nil)
-	response1 := executeRequest(req1)
-	assert.Equal(t, 200, response1.Code)
+	// 1st load sample offers fixture replacing all offers in database
+	loadFixturesDatastore(t)
 
 	// 2nd call our local server API to fetch list
 	endpoint := "http://localhost:8080/offerlist"
@@ -424,17 +563,57 @@ func TestSearchDatastore(t *testing.T) {
 	assert.True(t, strings.Contains(body, `"externalId":"4","upc":"upc42345678","name":"offer 4"`))
 }
 
-// tests Reset and Add to Datastore - Cassandra must be running
-func TestResetAddOfferDatastore(t *testing.T) {
-	// 1st call Reset to reset database and re-create keystore
-	endpoint1 := "http://localhost:8080/reset"
-	req1, _ := http.NewRequest(http.MethodGet, endpoint1, nil)
-	response1 := executeRequest(req1)
-	assert.Equal(t, 200, response1.Code)
+// tests Datastore search filters, sorts and pages offers
+func TestSearchDatastoreFilterSortPage(t *testing.T) {
+	loadFixturesDatastore(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?partyName=walmart.com", nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+	assert.True(t, strings.Contains(response.Body.String(), `"summary":{"page":1,"pageCount":1,"totalCount":1}`))
+	assert.True(t, strings.Contains(response.Body.String(), `"name":"offer 3"`))
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?sortBy=price&sortOrder=desc&rowsPerPage=3", nil)
+	response = executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	var list model.OfferList
+	json.Unmarshal(response.Body.Bytes(), &list)
+	assert.Equal(t, 3, len(list.List))
+	assert.Equal(t, 2, list.PageCount)
+	assert.Equal(t, "offer 3", list.List[0].Name)
+	assert.NotEmpty(t, list.NextPageState)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?page=2&rowsPerPage=3&pageState="+list.NextPageState, nil)
+	response = executeRequest(req)
+	assert.True(t, strings.Contains(response.Body.String(), `"name":"offer 4"`))
+	assert.True(t, strings.Contains(response.Body.String(), `"summary":{"page":2,"pageCount":-1,"totalCount":-1}`))
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?page=2&rowsPerPage=3&count=true&pageState="+list.NextPageState, nil)
+	response = executeRequest(req)
+	assert.True(t, strings.Contains(response.Body.String(), `"summary":{"page":2,"pageCount":2,"totalCount":4}`))
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?page=abc", nil)
+	assert.Equal(t, 400, executeRequest(req).Code)
+
+	// sorted listings are sorted within each page, they can only be paged by pageState
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?sortBy=price&sortOrder=desc&page=2&rowsPerPage=3", nil)
+	assert.Equal(t, 400, executeRequest(req).Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/offerlist?sortBy=price&sortOrder=asc&page=2&rowsPerPage=3&pageState="+list.NextPageState, nil)
+	response = executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+	assert.True(t, strings.Contains(response.Body.String(), `"name":"offer 4"`))
+}
+
+// tests Load Fixtures and Add to Datastore
+func TestLoadFixturesAddOfferDatastore(t *testing.T) {
+	// 1st load sample offers fixture replacing all offers in database
+	loadFixturesDatastore(t)
 
 	// call our local server API to add
 	endpoint := "http://localhost:8080/offerlist"
-	var jsonRequest = []byte(`{"externalId":"1", "upc":"upc999","name":"test record","partyName":"amazon.com","semanticName":"http:/item01","mainImageFileUrl":"http:/item01.jpg","partyImageFileUrl":"amazon-logo.jpg","productCategory":"laptops","price":500,"rating":3.88,"numReviews":120, "created": "2017-11-01T22:08:41+00:00"}`)
+	var jsonRequest = []byte(`{"externalId":"1", "upc":"upc999","name":"test record","partyName":"amazon.com","semanticName":"https://amazon.com/item01","mainImageFileUrl":"https://amazon.com/item01.jpg","partyImageFileUrl":"amazon-logo.jpg","productCategory":"laptops","price":500,"rating":3.88,"numReviews":120, "created": "2017-11-01T22:08:41+00:00"}`)
 
 	req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonRequest))
 	req.Header.Set("Content-Type", "application/json")
@@ -443,7 +622,95 @@ func TestResetAddOfferDatastore(t *testing.T) {
 
 	// verify responses
 	body := response.Body.String()
-	assert.True(t, strings.Contains(body, `"externalId":"1","upc":"upc999","name":"test record","partyName":"amazon.com","semanticName":"http:/item01"`))
+	assert.True(t, strings.Contains(body, `"externalId":"1","upc":"upc999","name":"test record","partyName":"amazon.com","semanticName":"https://amazon.com/item01"`))
+}
+
+// loads the sample offers fixture into Datastore
+func loadFixturesDatastore(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/admin/datastore/fixtures", nil)
+	response := executeRequest(req)
+	assert.Equal(t, 401, response.Code)
+
+	req.Header.Set(offer.AdminKeyHeader, app.Config.GetProperty("adminApiKey"))
+	response = executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, `{"offers":4}`, strings.TrimSpace(response.Body.String()))
+}
+
+// adds an offer to Datastore returning it
+func addOfferDatastore(t *testing.T) *model.Offer {
+	var jsonRequest = []byte(`{"externalId":"1","upc":"upc999","name":"test record","partyName":"amazon.com","semanticName":"https://amazon.com/item01","price":500,"rating":3.88,"numReviews":120}`)
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/offerlist", bytes.NewBuffer(jsonRequest))
+	response := executeRequest(req)
+	assert.Equal(t, 201, response.Code)
+	assert.Equal(t, `"1"`, response.Header().Get("ETag"))
+
+	var o model.Offer
+	json.Unmarshal(response.Body.Bytes(), &o)
+	return &o
+}
+
+// tests invalid offers are not added to Datastore
+func TestAddOfferDatastoreInvalid(t *testing.T) {
+	invalid := []string{
+		`{"name":"","partyName":"amazon.com"}`,
+		`{"name":"test","partyName":"amazon.com","price":-1}`,
+		`{"name":"test","partyName":"amazon.com","rating":5.5}`,
+		`{"name":"test","partyName":"amazon.com","semanticName":"item01"}`,
+		`{"name":"test","partyName":"unknown.com"}`,
+	}
+
+	for _, body := range invalid {
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/offerlist", bytes.NewBufferString(body))
+		response := executeRequest(req)
+		assert.Equal(t, 400, response.Code, body)
+	}
+}
+
+// tests Get, Update, Patch and Delete an offer from Datastore using its version
+func TestUpdateDeleteOfferDatastore(t *testing.T) {
+	o := addOfferDatastore(t)
+	endpoint := "http://localhost:8080/offerlist/" + o.Id
+
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+	assert.True(t, strings.Contains(response.Body.String(), `"name":"test record"`))
+
+	// update without version
+	req, _ = http.NewRequest(http.MethodPut, endpoint, bytes.NewBufferString(`{"name":"updated","partyName":"amazon.com","price":400}`))
+	response = executeRequest(req)
+	assert.Equal(t, 428, response.Code)
+
+	// update using version 1
+	req, _ = http.NewRequest(http.MethodPut, endpoint, bytes.NewBufferString(`{"name":"updated","partyName":"amazon.com","price":400,"version":1}`))
+	response = executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, `"2"`, response.Header().Get("ETag"))
+	assert.True(t, strings.Contains(response.Body.String(), `"name":"updated"`))
+
+	// patch with stale version
+	req, _ = http.NewRequest(http.MethodPatch, endpoint, bytes.NewBufferString(`{"price":300}`))
+	req.Header.Set("If-Match", `"1"`)
+	response = executeRequest(req)
+	assert.Equal(t, 412, response.Code)
+
+	// patch with current version
+	req.Header.Set("If-Match", `"2"`)
+	req.Body = ioutil.NopCloser(bytes.NewBufferString(`{"price":300}`))
+	response = executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+	assert.True(t, strings.Contains(response.Body.String(), `"name":"updated"`))
+	assert.True(t, strings.Contains(response.Body.String(), `"price":300`))
+
+	req, _ = http.NewRequest(http.MethodDelete, endpoint, nil)
+	req.Header.Set("If-Match", `"3"`)
+	response = executeRequest(req)
+	assert.Equal(t, 204, response.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, endpoint, nil)
+	response = executeRequest(req)
+	assert.Equal(t, 404, response.Code)
 }
 
 // Tests Search with keywords invalid expects Bad Request 400
@@ -456,7 +723,8 @@ func testSearchWithKeywordsInvalidRequest(t *testing.T, json []byte) {
 	registerMockResponderSearch(http.MethodGet, WalmartSearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, BestBuySearchUrl, model.Search, 200)
 	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
-	registerMockResponderSearch(http.MethodGet, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.Search, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers"
@@ -474,7 +742,8 @@ func testSearchWithKeywordsInvalidRequest(t *testing.T, json []byte) {
 	assertCallsMade(t, http.MethodGet, WalmartSearchUrl, 0)
 	assertCallsMade(t, http.MethodGet, BestBuySearchUrl, 0)
 	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 0)
-	assertCallsMade(t, http.MethodGet, AmazonSearchUrl, 0)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 0)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 0)
 }
 
 // Tests Search with keywords invalid expects Bad Request 400 - sort order
@@ -495,6 +764,131 @@ func TestSearchWithKeywordsInvalidRowsPerPage(t *testing.T) {
 	testSearchWithKeywordsInvalidRequest(t, jsonRequest)
 }
 
+// Tests Search with keywords invalid expects Bad Request 400 - country
+func TestSearchWithKeywordsInvalidCountry(t *testing.T) {
+	var jsonRequest = []byte(`{"searchColumns":[{"name":"name","value":"skyrim"},{"name":"country","value":"xyz"}],"sortOrder":"asc","page":1,"rowsPerPage":10}`)
+	testSearchWithKeywordsInvalidRequest(t, jsonRequest)
+}
+
+// Tests Search with keywords of a country without its own providers list, searching international providers
+func TestSearchWithKeywordsInternational(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, AliExpressSearchUrl, model.Search, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers"
+	var jsonRequest = []byte(`{"searchColumns":[{"name":"name","value":"skyrim"},{"name":"country","value":"GB"}],"sortOrder":"asc","page":1,"rowsPerPage":10}`)
+
+	req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonRequest))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"list":[{"`))
+
+	ebaySnippet := `"externalId":"322643800407","upc":"","name":"Elder Scrolls V: Skyrim - Special Edition With Bonus Steelbook Case PS4 ","partyName":"ebay.com"`
+	assert.True(t, strings.Contains(body, ebaySnippet))
+
+	aliExpressSnippet := `"externalId":"1005004531843040","upc":"","name":"Skyrim Dragon Amulet Necklace Elder Scrolls Cosplay Pendant","partyName":"aliexpress.com"`
+	assert.True(t, strings.Contains(body, aliExpressSnippet))
+
+	assert.False(t, strings.Contains(body, `"partyName":"walmart.com"`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, AliExpressSearchUrl, 1)
+}
+
+// Tests Search with keywords of a country served by a REST provider defined in a definition file
+func TestSearchWithKeywordsRestProvider(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, DealsExampleSearchUrl, model.Search, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers"
+	var jsonRequest = []byte(`{"searchColumns":[{"name":"name","value":"skyrim"},{"name":"country","value":"australia"}],"sortOrder":"asc","page":1,"rowsPerPage":10}`)
+
+	req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonRequest))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"list":[{"`))
+
+	dealsSnippet := `"externalId":"DX-20931","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition - PS4","partyName":"deals.example.com","semanticName":"https://deals.example.com/p/DX-20931?ref=TEST12345678"`
+	assert.True(t, strings.Contains(body, dealsSnippet))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, DealsExampleSearchUrl, 1)
+}
+
+// Tests Search with keywords in Canada, merging merchant feeds with marketplace results
+func TestSearchWithKeywordsFeed(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, AliExpressSearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonCASearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, BestBuyCASearchUrl, model.Search, 200)
+	registerMockResponderSearch(http.MethodGet, WalmartCASearchUrl, model.Search, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers"
+	var jsonRequest = []byte(`{"searchColumns":[{"name":"name","value":"skyrim"},{"name":"country","value":"ca"}],"sortOrder":"asc","page":1,"rowsPerPage":10}`)
+
+	req, _ := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonRequest))
+	req.Header.Set("Content-Type", "application/json")
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"list":[{"`))
+
+	feedSnippet := `"externalId":"acme-games:AG-1001","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition - PlayStation 4","partyName":"feed"`
+	assert.True(t, strings.Contains(body, feedSnippet))
+	assert.True(t, strings.Contains(body, `"externalId":"northwind:NW-77"`))
+	assert.True(t, strings.Contains(body, `"partyName":"aliexpress.com"`))
+
+	bestBuySnippet := `"externalId":"10441046","upc":"","name":"The Elder Scrolls V: Skyrim Special Edition (PS4)","partyName":"bestbuy.ca","semanticName":"https://www.bestbuy.ca/en-ca/product/the-elder-scrolls-v-skyrim-special-edition-ps4/10441046"`
+	assert.True(t, strings.Contains(body, bestBuySnippet))
+	assert.True(t, strings.Contains(body, `"seller":"Northern Replicas"`))
+
+	walmartSnippet := `"externalId":"6000197438161","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition (PS4)","partyName":"walmart.ca","semanticName":"https://www.walmart.ca/en/ip/the-elder-scrolls-v-skyrim-special-edition-ps4/6000197438161"`
+	assert.True(t, strings.Contains(body, walmartSnippet))
+	assert.False(t, strings.Contains(body, `"partyName":"walmart.com"`))
+	assert.False(t, strings.Contains(body, `"partyName":"bestbuy.com"`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, AliExpressSearchUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonCASearchUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 0)
+	assertCallsMade(t, http.MethodGet, BestBuyCASearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, WalmartCASearchUrl, 1)
+}
+
 // Tests Search No results
 func TestSearchNoResults(t *testing.T) {
 	// register mock for external API endpoints
@@ -505,7 +899,8 @@ func TestSearchNoResults(t *testing.T) {
 	registerMockResponderSearch(http.MethodGet, WalmartSearchUrl, model.NoResults, 200)
 	registerMockResponderSearch(http.MethodGet, BestBuySearchUrl, model.NoResults, 200)
 	registerMockResponderSearch(http.MethodGet, EbaySearchUrl, model.NoResults, 200)
-	registerMockResponderSearch(http.MethodGet, AmazonSearchUrl, model.NoResults, 200)
+	registerMockResponderSearch(http.MethodPost, AmazonSearchUrl, model.NoResults, 200)
+	registerMockResponderSearch(http.MethodGet, TargetSearchUrl, model.NoResults, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers"
@@ -524,7 +919,8 @@ func TestSearchNoResults(t *testing.T) {
 	assertCallsMade(t, http.MethodGet, WalmartSearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuySearchUrl, 1)
 	assertCallsMade(t, http.MethodGet, EbaySearchUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonSearchUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetSearchUrl, 1)
 }
 
 // Tests GetDetail By Id - walmart
@@ -536,8 +932,9 @@ func TestGetDetailByIdWalmart(t *testing.T) {
 	// External Vendor Apis
 	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailUrl, model.Id, 200)
 	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailByUpcUrl, model.Upc, 200)
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.Upc, 200)
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.Upc, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/53966162?idType=id&source=walmart.com"
@@ -559,14 +956,15 @@ func TestGetDetailByIdWalmart(t *testing.T) {
 	ebaySnippet := `{"partyName":"ebay.com","semanticName":"http://www.ebay.com/itm/New-Laptop-Toshiba-Satellite-L355-S7907-17-Intel-Pentium-Dual-core-T3400-2-16Gh`
 	assert.True(t, strings.Contains(body, ebaySnippet))
 
-	amazonSnippet := `{"partyName":"amazon.com","semanticName":"https://www.amazon.com/Elder-Scrolls-Skyrim-strategy-bundle-Playstation`
+	amazonSnippet := `{"partyName":"amazon.com","semanticName":"https://www.amazon.com/Elder-Scrolls-Skyrim-Special-PlayStation-4`
 	assert.True(t, strings.Contains(body, amazonSnippet))
 
 	// get the amount of calls for the registered responders
 	assertCallsMade(t, http.MethodGet, WalmartGetDetailUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuyGetDetailByUpcUrl, 1)
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
 }
 
 // Tests GetDetail By Upc Not Found Walmart
@@ -597,8 +995,9 @@ func TestGetDetailByIdWalmartNoDetailItems(t *testing.T) {
 	// External Vendor Apis
 	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailUrl, model.Id, 200)
 	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailByUpcUrl, model.NoResults, 200)
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.NoResults, 200)
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.NoResults, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/53966162?idType=id&source=walmart.com"
@@ -616,8 +1015,9 @@ func TestGetDetailByIdWalmartNoDetailItems(t *testing.T) {
 	// get the amount of calls for the registered responders
 	assertCallsMade(t, http.MethodGet, WalmartGetDetailUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuyGetDetailByUpcUrl, 1)
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
 }
 
 // Tests GetDetail By Id BestBuy
@@ -629,8 +1029,9 @@ func TestGetDetailByIdBestBuy(t *testing.T) {
 	// External Vendor Apis
 	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailUrl, model.Id, 200)
 	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailByUpcUrl, model.Upc, 200)
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.Upc, 200)
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.Upc, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/5529006?idType=id&source=bestbuy.com"
@@ -653,14 +1054,15 @@ func TestGetDetailByIdBestBuy(t *testing.T) {
 	ebaySnippet := `{"partyName":"ebay.com","semanticName":"http://www.ebay.com/itm`
 	assert.True(t, strings.Contains(body, ebaySnippet))
 
-	amazonSnippet := `{"partyName":"amazon.com","semanticName":"https://www.amazon.com/Elder-Scrolls-Skyrim-strategy-bundle-Playstation`
-	assert.True(t, strings.Contains(body, amazonSnippet))
+	// no amazon item has the upc of the product
+	assert.False(t, strings.Contains(body, `{"partyName":"amazon.com"`))
 
 	// get the amount of calls for the registered responders
 	assertCallsMade(t, http.MethodGet, BestBuyGetDetailUrl, 1)
 	assertCallsMade(t, http.MethodGet, WalmartGetDetailByUpcUrl, 1)
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
 }
 
 // Tests GetDetail By Upc Not Found - Best Buy
@@ -691,8 +1093,9 @@ func TestGetDetailByIdBestBuyNoDetailItems(t *testing.T) {
 	// External Vendor Apis
 	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailUrl, model.Id, 200)
 	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailByUpcUrl, model.NoResults, 200)
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.NoResults, 200)
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.NoResults, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/5529006?idType=id&source=bestbuy.com"
@@ -709,11 +1112,12 @@ func TestGetDetailByIdBestBuyNoDetailItems(t *testing.T) {
 	// get the amount of calls for the registered responders
 	assertCallsMade(t, http.MethodGet, BestBuyGetDetailUrl, 1)
 	assertCallsMade(t, http.MethodGet, WalmartGetDetailByUpcUrl, 1)
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
 }
 
-// Tests GetDetail By Id Ebay returns no UPC so product detail items is empty (not fetching others competitors prices)
+// Tests GetDetail By Id Ebay returns no GTIN so product detail items is empty (not fetching others competitors prices)
 func TestGetDetailByIdEbay(t *testing.T) {
 	// register mock for external API endpoints
 	httpmock.Activate()
@@ -723,7 +1127,7 @@ func TestGetDetailByIdEbay(t *testing.T) {
 	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.Id, 200)
 
 	// call our local server API
-	endpoint := "http://localhost:8080/offers/62923188?idType=id&source=ebay.com"
+	endpoint := "http://localhost:8080/offers/232135853334?idType=id&source=ebay.com"
 	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
 	response := executeRequest(req)
 	assert.Equal(t, 200, response.Code)
@@ -732,7 +1136,7 @@ func TestGetDetailByIdEbay(t *testing.T) {
 	body := response.Body.String()
 
 	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
-	assert.True(t, strings.Contains(body, `"externalId":"62923188","upc":"","name":"Harry Potter and the Order of the Phoenix-(DVD, Widescreen`))
+	assert.True(t, strings.Contains(body, `"externalId":"232135853334","upc":"","name":"Harry Potter and the Order of the Phoenix-(DVD, Widescreen`))
 
 	ebaySnippet := `partyName":"ebay.com","semanticName":"http://www.ebay.com/itm/Harry-Potter-and-Order-Phoenix-DVD-Widescreen-Edition-BRAND-NEW`
 	assert.True(t, strings.Contains(body, ebaySnippet))
@@ -749,7 +1153,7 @@ func TestGetDetailByUpcNotFoundEbay(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	// External Vendor Apis
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.NoResults, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/123456789?idType=upc&source=ebay.com"
@@ -758,7 +1162,7 @@ func TestGetDetailByUpcNotFoundEbay(t *testing.T) {
 	assert.Equal(t, 404, response.Code)
 
 	// get the amount of calls for the registered responders
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
 }
 
 // Tests GetDetail By Id Amazon
@@ -768,10 +1172,11 @@ func TestGetDetailByIdAmazon(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	// External Vendor Apis
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailUrl, model.Id, 200)
 	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailByUpcUrl, model.Upc, 200)
 	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailByUpcUrl, model.Upc, 200)
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.Upc, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/5529006?idType=id&source=amazon.com"
@@ -785,7 +1190,7 @@ func TestGetDetailByIdAmazon(t *testing.T) {
 	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
 	assert.True(t, strings.Contains(body, `"externalId":"B01GW8XJVU","upc":"065857174434","name":"The Elder Scrolls V: Skyrim - Special Edition - PlayStation 4"`))
 
-	desc := `"description":"All-new features include remastered art and effects, volumetric god rays, dynamic depth of field, screen-space reflections, and more."`
+	desc := `"description":"Winner of more than 200 Game of the Year Awards, Skyrim Special Edition brings the epic fantasy to life in stunning detail. The Special Edition includes the critically acclaimed game and add-ons with all-new features. All-new features include remastered art and effects, volumetric god rays, dynamic depth of field, screen-space reflections, and more."`
 	assert.True(t, strings.Contains(body, desc))
 	assert.True(t, strings.Contains(body, `"name":"manufacturer","value":"Bethesda"`))
 
@@ -801,11 +1206,15 @@ func TestGetDetailByIdAmazon(t *testing.T) {
 	amazonSnippet := `"partyName":"amazon.com","semanticName":"https://www.amazon.com/Elder-Scrolls-Skyrim-Special-PlayStation-4`
 	assert.True(t, strings.Contains(body, amazonSnippet))
 
+	targetSnippet := `{"partyName":"target.com","semanticName":"https://www.target.com/p/the-elder-scrolls-v-skyrim-special-edition-playstation-4-greatest-hits/-/A-52160342?afid=TEST12345678"`
+	assert.True(t, strings.Contains(body, targetSnippet))
+
 	// get the amount of calls for the registered responders
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuyGetDetailByUpcUrl, 1)
 	assertCallsMade(t, http.MethodGet, WalmartGetDetailByUpcUrl, 1)
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
 }
 
 // Tests GetDetail By Upc Not Found - Amazon
@@ -815,7 +1224,7 @@ func TestGetDetailByUpcNotFoundAmazon(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	// External Vendor Apis
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailByUpcUrl, model.NoResults, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/123456789?idType=upc&source=amazon.com"
@@ -824,7 +1233,7 @@ func TestGetDetailByUpcNotFoundAmazon(t *testing.T) {
 	assert.Equal(t, 404, response.Code)
 
 	// get the amount of calls for the registered responders
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailByUpcUrl, 1)
 }
 
 // Tests GetDetail By Id - No Competitors search by UPC detail items found - Amazon
@@ -834,10 +1243,11 @@ func TestGetDetailByIdAmazonNoDetailItems(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	// External Vendor Apis
-	registerMockResponderGetDetail(http.MethodGet, AmazonGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailUrl, model.Id, 200)
 	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailByUpcUrl, model.NoResults, 200)
 	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailByUpcUrl, model.NoResults, 200)
-	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.NoResults, 200)
 
 	// call our local server API
 	endpoint := "http://localhost:8080/offers/123456789?idType=id&source=amazon.com"
@@ -853,8 +1263,253 @@ func TestGetDetailByIdAmazonNoDetailItems(t *testing.T) {
 	assert.True(t, strings.Contains(body, `"productDetailItems":[]`))
 
 	// get the amount of calls for the registered responders
-	assertCallsMade(t, http.MethodGet, AmazonGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailUrl, 1)
 	assertCallsMade(t, http.MethodGet, BestBuyGetDetailByUpcUrl, 1)
 	assertCallsMade(t, http.MethodGet, WalmartGetDetailByUpcUrl, 1)
-	assertCallsMade(t, http.MethodGet, EbayGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
+}
+
+// Tests GetDetail By Id - Target
+func TestGetDetailByIdTarget(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodGet, WalmartGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, BestBuyGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonGetDetailByUpcUrl, model.Upc, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/50833567?idType=id&source=target.com"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
+	assert.True(t, strings.Contains(body, `"externalId":"50833567","upc":"065857174434","name":"The Elder Scrolls V: Skyrim Special Edition - PlayStation 4","partyName":"target.com"`))
+	assert.True(t, strings.Contains(body, `"description":"Winner of more than 200 Game of the Year Awards, Skyrim Special Edition brings the epic fantasy to life in stunning detail.`))
+	assert.True(t, strings.Contains(body, `"name":"manufacturer","value":"Bethesda Softworks"`))
+
+	amazonSnippet := `{"partyName":"amazon.com","semanticName":"https://www.amazon.com/Elder-Scrolls-Skyrim-Special-PlayStation-4`
+	assert.True(t, strings.Contains(body, amazonSnippet))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, TargetGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, WalmartGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, BestBuyGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonGetDetailByUpcUrl, 1)
+}
+
+// Tests GetDetail By Upc Not Found - Target
+func TestGetDetailByUpcNotFoundTarget(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, TargetGetDetailByUpcUrl, model.NoResults, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/123456789?idType=upc&source=target.com"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 404, response.Code)
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, TargetGetDetailByUpcUrl, 1)
+}
+
+// Tests GetDetail By Id - AliExpress, products have no upc so no competitors are fetched
+func TestGetDetailByIdAliExpress(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, AliExpressGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/1005004531843040?idType=id&source=aliexpress.com&country=de"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
+	assert.True(t, strings.Contains(body, `"externalId":"1005004531843040","upc":"","name":"Skyrim Dragon Amulet Necklace Elder Scrolls Cosplay Pendant","partyName":"aliexpress.com"`))
+	assert.True(t, strings.Contains(body, `"name":"shop","value":"https://www.aliexpress.com/store/1101234567"`))
+	assert.True(t, strings.Contains(body, `"productDetailItems":[]`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, AliExpressGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 0)
+}
+
+// Tests GetDetail By Id Not Found - AliExpress
+func TestGetDetailByIdNotFoundAliExpress(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, AliExpressGetDetailUrl, model.NoResults, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/123456789?idType=id&source=aliexpress.com&country=de"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 404, response.Code)
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, AliExpressGetDetailUrl, 1)
+}
+
+// Tests GetDetail with an unknown country expects Bad Request 400
+func TestGetDetailInvalidCountry(t *testing.T) {
+	endpoint := "http://localhost:8080/offers/53966162?idType=id&source=walmart.com&country=xyz"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 400, response.Code)
+}
+
+// Tests GetDetail By Id - REST provider, fetching competitors of the country by upc
+func TestGetDetailByIdRestProvider(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, DealsExampleGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/DX-20931?idType=id&source=deals.example.com&country=au"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
+	assert.True(t, strings.Contains(body, `"externalId":"DX-20931","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition - PS4","partyName":"deals.example.com"`))
+	assert.True(t, strings.Contains(body, `"description":"Winner of more than 200 Game of the Year Awards`))
+	assert.True(t, strings.Contains(body, `{"partyName":"ebay.com"`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, DealsExampleGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+}
+
+// Tests GetDetail By Id - feed, fetching competitors of the country by upc
+func TestGetDetailByIdFeed(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonCAGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, BestBuyCAGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, WalmartCAGetDetailByUpcUrl, model.Upc, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/acme-games:AG-1001?idType=id&source=feed&country=ca"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
+	assert.True(t, strings.Contains(body, `"externalId":"acme-games:AG-1001","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition - PlayStation 4","partyName":"feed"`))
+	assert.True(t, strings.Contains(body, `"seller":"acme-games"`))
+	assert.True(t, strings.Contains(body, `"name":"brand","value":"Bethesda"`))
+	assert.True(t, strings.Contains(body, `{"partyName":"ebay.com"`))
+	assert.True(t, strings.Contains(body, `{"partyName":"amazon.com"`))
+	assert.True(t, strings.Contains(body, `{"partyName":"bestbuy.ca"`))
+	assert.True(t, strings.Contains(body, `{"partyName":"walmart.ca"`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, EbayGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodPost, AmazonCAGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, BestBuyCAGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, WalmartCAGetDetailByUpcUrl, 1)
+}
+
+// Tests GetDetail By Id - bestbuy.ca, fetching competitors of Canada by upc
+func TestGetDetailByIdBestBuyCanada(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, BestBuyCAGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodGet, WalmartCAGetDetailByUpcUrl, model.Upc, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonCAGetDetailByUpcUrl, model.Upc, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/10441046?idType=id&source=bestbuy.ca&country=can"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
+	assert.True(t, strings.Contains(body, `"externalId":"10441046","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition (PS4)","partyName":"bestbuy.ca"`))
+	assert.True(t, strings.Contains(body, `"price":29.99`))
+	assert.True(t, strings.Contains(body, `"description":"Winner of more than 200 Game of the Year Awards`))
+	assert.True(t, strings.Contains(body, `"name":"brand","value":"Bethesda"`))
+	assert.True(t, strings.Contains(body, `{"partyName":"walmart.ca"`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, BestBuyCAGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, WalmartCAGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, BestBuyGetDetailByUpcUrl, 0)
+}
+
+// Tests GetDetail By Id - walmart.ca, fetching competitors of Canada by upc
+func TestGetDetailByIdWalmartCanada(t *testing.T) {
+	// register mock for external API endpoints
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// External Vendor Apis
+	registerMockResponderGetDetail(http.MethodGet, WalmartCAGetDetailUrl, model.Id, 200)
+	registerMockResponderGetDetail(http.MethodGet, BestBuyCAGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodGet, EbayGetDetailByUpcUrl, model.NoResults, 200)
+	registerMockResponderGetDetail(http.MethodPost, AmazonCAGetDetailByUpcUrl, model.Upc, 200)
+
+	// call our local server API
+	endpoint := "http://localhost:8080/offers/6000197438161?idType=id&source=walmart.ca&country=can"
+	req, _ := http.NewRequest(http.MethodGet, endpoint, nil)
+	response := executeRequest(req)
+	assert.Equal(t, 200, response.Code)
+
+	// verify responses
+	body := response.Body.String()
+
+	assert.True(t, strings.HasPrefix(body, `{"offer":{"id":"`))
+	assert.True(t, strings.Contains(body, `"externalId":"6000197438161","upc":"093155171251","name":"The Elder Scrolls V: Skyrim Special Edition (PS4)","partyName":"walmart.ca","semanticName":"https://www.walmart.ca/en/ip/the-elder-scrolls-v-skyrim-special-edition-ps4/6000197438161"`))
+	assert.False(t, strings.Contains(body, `{"partyName":"bestbuy.ca"`))
+
+	// get the amount of calls for the registered responders
+	assertCallsMade(t, http.MethodGet, WalmartCAGetDetailUrl, 1)
+	assertCallsMade(t, http.MethodGet, BestBuyCAGetDetailByUpcUrl, 1)
+	assertCallsMade(t, http.MethodGet, WalmartGetDetailByUpcUrl, 0)
 }
@@ -0,0 +1,136 @@
+package offer
+
+import (
+	"errors"
+	"github.com/guilhebl/go-offer/common/cache"
+	"github.com/guilhebl/go-offer/common/model"
+	"log"
+	"strings"
+)
+
+// returns the usage counters of the cache
+func (m *Module) GetCacheStats() (*cache.Stats, error) {
+	if !m.isCacheEnabled() {
+		return nil, errors.New(model.CacheDisabled)
+	}
+
+	stats := m.Cache.Stats()
+	return &stats, nil
+}
+
+// gets the cached results of a search request without calling marketplace
+func (m *Module) GetCachedSearch(r *model.ListRequest) (*model.CacheEntry, error) {
+	if !m.isCacheEnabled() {
+		return nil, errors.New(model.CacheDisabled)
+	}
+	if !r.IsValid() {
+		return nil, errors.New(model.InvalidRequest)
+	}
+
+	key := searchCacheKey(r.Canonical())
+	var obj *model.OfferList
+	status := m.getCached(key, &obj)
+	if status == CacheMiss {
+		return model.NewCacheEntry(key, status, nil), nil
+	}
+	return model.NewCacheEntry(key, status, obj), nil
+}
+
+// gets the cached product detail of a detail request without calling marketplace
+func (m *Module) GetCachedDetail(r *model.DetailRequest) (*model.CacheEntry, error) {
+	if !m.isCacheEnabled() {
+		return nil, errors.New(model.CacheDisabled)
+	}
+	if !r.IsValid() {
+		return nil, errors.New(model.InvalidRequest)
+	}
+
+	key := detailCacheKey(r)
+	var obj *model.OfferDetail
+	status := m.getCached(key, &obj)
+	if status == CacheMiss {
+		return model.NewCacheEntry(key, status, nil), nil
+	}
+	return model.NewCacheEntry(key, status, obj), nil
+}
+
+// removes cached entries of a provider, of a keyword or with keys starting with prefix, returns number of entries removed
+func (m *Module) InvalidateCache(provider, keyword, prefix string) (*model.CacheInvalidation, error) {
+	if !m.isCacheEnabled() {
+		return nil, errors.New(model.CacheDisabled)
+	}
+
+	patterns := make([]string, 0)
+	if provider != "" {
+		patterns = append(patterns, providerCachePatterns(provider)...)
+	}
+	if keyword != "" {
+		patterns = append(patterns, keywordCachePatterns(keyword)...)
+	}
+	if prefix != "" {
+		patterns = append(patterns, cache.EscapePattern(prefix)+"*")
+	}
+	if len(patterns) == 0 {
+		return nil, errors.New(model.InvalidRequest)
+	}
+
+	result := &model.CacheInvalidation{}
+	for _, p := range patterns {
+		n, err := m.Cache.DeletePattern(p)
+		result.Deleted += n
+		if err != nil {
+			return result, err
+		}
+	}
+
+	log.Printf("cache invalidated %d entries: %v", result.Deleted, patterns)
+	return result, nil
+}
+
+// builds the search requests used to warm up the cache out of the popular keywords in cacheWarmQueries
+func (m *Module) getWarmUpRequests() []*model.ListRequest {
+	reqs := make([]*model.ListRequest, 0)
+	for _, q := range strings.Split(m.Config.GetProperty("cacheWarmQueries"), ",") {
+		if q = strings.TrimSpace(q); q != "" {
+			columns := []model.NameValue{model.NewNameValue(model.Name, q)}
+			reqs = append(reqs, model.NewListRequest(columns, "", "", 1, m.Config.GetIntProperty("defaultRowsPerPage")))
+		}
+	}
+	return reqs
+}
+
+// warms up the cache in background running each valid search request, if none is given the popular
+// keywords in cacheWarmQueries are used. Returns number of searches scheduled, none once the module is stopping.
+func (m *Module) WarmCache(reqs []*model.ListRequest) (*model.CacheWarmUp, error) {
+	if !m.isCacheEnabled() {
+		return nil, errors.New(model.CacheDisabled)
+	}
+	if len(reqs) == 0 {
+		reqs = m.getWarmUpRequests()
+	}
+
+	valid := make([]*model.ListRequest, 0, len(reqs))
+	for _, r := range reqs {
+		if r != nil && r.IsValid() {
+			valid = append(valid, r)
+		}
+	}
+
+	if !m.goBackground(func() { m.warmCache(valid) }) {
+		return &model.CacheWarmUp{Queries: 0}, nil
+	}
+	return &model.CacheWarmUp{Queries: len(valid)}, nil
+}
+
+// runs each search one at a time, provider calls are scheduled through the worker pool
+// and searches already cached are skipped. The warm up ends early when the module is stopping
+func (m *Module) warmCache(reqs []*model.ListRequest) {
+	for i, r := range reqs {
+		if m.isStopping() {
+			log.Printf("cache warm up stopped after %d of %d queries", i, len(reqs))
+			return
+		}
+		m.searchOffersCached(r)
+	}
+	log.Printf("cache warmed up with %d queries", len(reqs))
+}
@@ -0,0 +1,122 @@
+package offer
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/guilhebl/go-offer/common/model"
+)
+
+// header holding the admin api key
+const AdminKeyHeader = "X-Admin-Key"
+
+// allows the request only if it carries the adminApiKey, admin endpoints are disabled if no key is set
+func (m *Module) requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := m.Config.GetProperty("adminApiKey")
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(r.Header.Get(AdminKeyHeader))) != 1 {
+			handleErr(model.Unauthorized, w)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// writes obj as a json response with status code
+func writeJson(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		panic(err)
+	}
+}
+
+// Gets cache usage counters
+func (m *Module) CacheStats(w http.ResponseWriter, r *http.Request) {
+	result, err := m.GetCacheStats()
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+	writeJson(w, http.StatusOK, result)
+}
+
+// Gets the cached results of a search request
+func (m *Module) CacheSearchEntry(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req model.ListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleErr(model.InvalidRequest, w)
+		return
+	}
+
+	result, err := m.GetCachedSearch(&req)
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+	writeJson(w, http.StatusOK, result)
+}
+
+// Gets the cached product detail of a detail request
+func (m *Module) CacheDetailEntry(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	request := model.NewDetailRequest(id, r.FormValue("idType"), r.FormValue("source"), r.FormValue("country"))
+
+	result, err := m.GetCachedDetail(request)
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+	writeJson(w, http.StatusOK, result)
+}
+
+// Invalidates cached entries by provider, keyword or key prefix
+func (m *Module) CacheInvalidate(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Cache Invalidate: %s", r.URL)
+
+	result, err := m.InvalidateCache(r.FormValue("provider"), r.FormValue("keyword"), r.FormValue("prefix"))
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+	writeJson(w, http.StatusOK, result)
+}
+
+// Warms up the cache with a list of search requests, popular queries from config are used if list is empty
+func (m *Module) CacheWarm(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Cache Warm: %s", r.URL)
+
+	defer r.Body.Close()
+
+	reqs := make([]*model.ListRequest, 0)
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			handleErr(model.InvalidRequest, w)
+			return
+		}
+	}
+
+	result, err := m.WarmCache(reqs)
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+	writeJson(w, http.StatusAccepted, result)
+}
+
+// Replaces all offers in Datastore with the sample offers fixture
+func (m *Module) LoadDatastoreFixtures(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Load Datastore Fixtures: %s", r.URL)
+
+	n, err := m.LoadFixturesDb()
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+	writeJson(w, http.StatusOK, model.FixturesLoad{Offers: n})
+}
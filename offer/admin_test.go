@@ -0,0 +1,93 @@
+package offer
+
+import (
+	"github.com/guilhebl/go-offer/common/cache"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tests cached entries are invalidated by provider, keyword and key prefix
+func TestInvalidateCache(t *testing.T) {
+	m, _ := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.SearchOffers(newSearchRequest("skyrim"))
+	m.SearchOffers(newSearchRequest("fallout"))
+
+	entry, err := m.GetCachedSearch(newSearchRequest("Skyrim"))
+	assert.Nil(t, err)
+	assert.Equal(t, CacheHit, entry.Status)
+
+	// merged and provider page of keyword
+	result, err := m.InvalidateCache("", "SKYRIM", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Deleted)
+
+	entry, _ = m.GetCachedSearch(newSearchRequest("skyrim"))
+	assert.Equal(t, CacheMiss, entry.Status)
+
+	// provider page and all merged results
+	result, _ = m.InvalidateCache(model.Walmart, "", "")
+	assert.Equal(t, int64(2), result.Deleted)
+
+	m.SearchOffers(newSearchRequest("fallout"))
+	result, _ = m.InvalidateCache("", "", searchKeyPrefix)
+	assert.Equal(t, int64(1), result.Deleted)
+
+	_, err = m.InvalidateCache("", "", "")
+	assert.Equal(t, model.InvalidRequest, err.Error())
+}
+
+// tests warm up searches popular queries skipping the ones already cached
+func TestWarmCache(t *testing.T) {
+	m, p := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.Config.SetProperty("cacheWarmQueries", "skyrim, fallout,")
+	m.SearchOffers(newSearchRequest("skyrim"))
+
+	reqs := m.getWarmUpRequests()
+	assert.Equal(t, 2, len(reqs))
+
+	m.warmCache(reqs)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&p.searches))
+
+	entry, _ := m.GetCachedSearch(newSearchRequest("fallout"))
+	assert.Equal(t, CacheHit, entry.Status)
+}
+
+// tests a warm up running when the module stops ends before the job queue is closed and none starts afterwards
+func TestWarmCacheStop(t *testing.T) {
+	m, p := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	p.delay = 20 * time.Millisecond
+	m.Config.SetProperty("cacheWarmQueries", "skyrim,fallout,zelda,mario,halo")
+
+	warm, err := m.WarmCache(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, warm.Queries)
+
+	time.Sleep(10 * time.Millisecond)
+	m.Stop()
+	assert.True(t, atomic.LoadInt32(&p.searches) < 5)
+
+	warm, err = m.WarmCache(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, warm.Queries)
+}
+
+// tests admin endpoints require the admin key
+func TestAdminEndpointsRequireKey(t *testing.T) {
+	m, _ := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/cache/stats", nil)
+	rr := httptest.NewRecorder()
+	m.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req.Header.Set(AdminKeyHeader, m.Config.GetProperty("adminApiKey"))
+	rr = httptest.NewRecorder()
+	m.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"backend":"memory"`)
+}
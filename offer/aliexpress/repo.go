@@ -0,0 +1,320 @@
+package aliexpress
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/guilhebl/go-worker-pool"
+	"html"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resp_code of a successful call, any other code means no products were returned
+const respCodeSuccess = 200
+
+// Repo is the AliExpress marketplace provider, it holds the dependencies required to query the AliExpress affiliate API
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+}
+
+// builds a new AliExpress provider using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+	}
+}
+
+// Creates Job for Searching offers from AliExpress and returns a Channel with jobResults
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewSearchTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Searches for offers from aliexpress
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(model.AliExpress) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil, monitor.ErrThrottled
+	}
+
+	// format vendor specific params
+	p := repo.filterParams(m)
+	page, err := strconv.Atoi(p[model.Page])
+	if err != nil || page < 1 {
+		log.Printf("format page error: %s", p[model.Page])
+		return nil, errors.New(model.InvalidRequest)
+	}
+	pageSize := repo.config.GetIntProperty("aliexpressDefaultPageSize")
+
+	q := repo.baseQuery()
+	q.Set("keywords", p[model.Keywords])
+	q.Set("page_no", strconv.Itoa(page))
+	q.Set("page_size", strconv.Itoa(pageSize))
+	q.Set("ship_to_country", model.CountryAlpha2(p[model.Country]))
+
+	var entity SearchResponse
+	if err := repo.call(repo.config.GetProperty("aliexpressProductSearchMethod"), q, &entity); err != nil {
+		log.Printf("%s", err.Error())
+		return nil, err
+	}
+	if entity.Error != nil {
+		log.Printf("aliexpress error: %s - %s", entity.Error.Code, entity.Error.Msg)
+		return nil, fmt.Errorf("aliexpress error: %s - %s", entity.Error.Code, entity.Error.Msg)
+	}
+	if entity.Result == nil || entity.Result.RespResult.RespCode != respCodeSuccess {
+		return model.NewOfferList(make([]model.Offer, 0), page, 0, 0), nil
+	}
+	return repo.buildSearchResponse(&entity.Result.RespResult.Result, page, pageSize), nil
+}
+
+// sets the params common to every call, currency and language of the offers and affiliate tracking id
+func (repo *Repo) baseQuery() url.Values {
+	q := url.Values{}
+	q.Set("app_key", repo.config.GetProperty("aliexpressAppKey"))
+	q.Set("sign_method", "sha256")
+	q.Set("target_currency", repo.config.GetProperty("aliexpressTargetCurrency"))
+	q.Set("target_language", repo.config.GetProperty("aliexpressTargetLanguage"))
+	q.Set("tracking_id", repo.config.GetProperty("aliexpressTrackingId"))
+	return q
+}
+
+// calls an affiliate method with params q signing the request, decoding the json response into entity
+func (repo *Repo) call(method string, q url.Values, entity interface{}) error {
+	q.Set("method", method)
+	q.Set("timestamp", strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10))
+	q.Set("sign", sign(q, repo.config.GetProperty("aliexpressAppSecret")))
+
+	req, err := http.NewRequest("GET", repo.config.GetProperty("aliexpressEndpoint"), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.URL.RawQuery = q.Encode()
+	log.Printf("AliExpress %s: %s", method, q.Get("keywords")+q.Get("product_ids"))
+
+	resp, err := repo.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("aliexpress error: %d - %s", resp.StatusCode, method)
+	}
+	return json.NewDecoder(resp.Body).Decode(entity)
+}
+
+// signs params concatenating each name and value sorted by name, using HMAC-SHA256 of the app secret
+func sign(q url.Values, secret string) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(q.Get(k))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(b.String()))
+	return strings.ToUpper(hex.EncodeToString(mac.Sum(nil)))
+}
+
+// builds Offer list response mapping from vendor specific params
+func (repo *Repo) buildSearchResponse(r *ProductPage, page, pageSize int) *model.OfferList {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = (r.TotalRecordCount + pageSize - 1) / pageSize
+	}
+
+	list := repo.buildSearchItemList(r.Products.Product)
+	o := model.NewOfferList(list, page, totalPages, r.TotalRecordCount)
+	return o
+}
+
+func (repo *Repo) buildSearchItemList(items []Product) []model.Offer {
+	list := make([]model.Offer, 0)
+	proxyRequired := repo.config.IsProxyRequired(model.AliExpress)
+
+	for _, item := range items {
+		o := repo.buildOffer(&item, proxyRequired)
+		list = append(list, *o)
+	}
+
+	return list
+}
+
+func (repo *Repo) buildOffer(p *Product, proxyRequired bool) *model.Offer {
+	price, _ := strconv.ParseFloat(p.TargetSalePrice, 32)
+
+	// products are rated by the percentage of positive feedback
+	rating := float32(0.0)
+	if pct, err := strconv.ParseFloat(strings.TrimSuffix(p.EvaluateRate, "%"), 32); err == nil {
+		rating = float32(pct) * model.MaxRating / 100
+	}
+
+	category := p.SecondLevelCategoryName
+	if category == "" {
+		category = p.FirstLevelCategoryName
+	}
+
+	// promotion links carry the affiliate tracking id
+	link := p.PromotionLink
+	if link == "" {
+		link = p.ProductDetailUrl
+	}
+
+	o := model.NewOffer(
+		"",
+		strconv.FormatInt(p.ProductId, 10),
+		"",
+		html.UnescapeString(p.ProductTitle),
+		model.AliExpress,
+		link,
+		repo.config.BuildImgUrlExternal(p.ProductMainImageUrl, proxyRequired),
+		repo.config.BuildImgUrl("aliexpress-logo.png"),
+		category,
+		float32(price),
+		rating,
+		0,
+		time.Now(),
+	)
+	if p.ShopId != 0 {
+		o.Seller = strconv.FormatInt(p.ShopId, 10)
+	}
+	return o
+}
+
+// filters vendor specific params from generic offer model params
+func (repo *Repo) filterParams(m map[string]string) map[string]string {
+	p := make(map[string]string)
+
+	// get search keyword phrase
+	if m[model.Name] != "" {
+		p[model.Keywords] = m[model.Name]
+	} else {
+		// aliexpress does not have a trending api so we need to fetch random query searches
+		p[model.Keywords] = repo.getRandomSearchQuery()
+	}
+
+	// get page - defaults to 1
+	if m[model.Page] != "" {
+		p[model.Page] = m[model.Page]
+	} else {
+		p[model.Page] = "1"
+	}
+
+	p[model.Country] = m[model.Country]
+	return p
+}
+
+// gets a random string inside an array of strings of queries
+func (repo *Repo) getRandomSearchQuery() string {
+	query := repo.config.GetProperty("aliexpressDefaultSearchQuery")
+	keywords := strings.Split(query, ",")
+	i := rand.Intn(len(keywords))
+	return keywords[i]
+}
+
+// Creates Job for fetching Product Detail and returns a Channel with jobResult
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
+	// convert to map for job to consume
+	m := make(map[string]string)
+	m["id"], m["idType"], m["country"] = id, idType, country
+
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewGetDetailTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Search for a specific product detail by Id, products have no upc so upc lookups are not supported
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
+
+	if idType != model.Id {
+		return nil
+	}
+
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(model.AliExpress) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil
+	}
+
+	q := repo.baseQuery()
+	q.Set("product_ids", id)
+	q.Set("country", model.CountryAlpha2(country))
+
+	var entity ProductDetailResponse
+	if err := repo.call(repo.config.GetProperty("aliexpressProductDetailMethod"), q, &entity); err != nil {
+		log.Println(err)
+		return nil
+	}
+	if entity.Error != nil {
+		log.Printf("aliexpress error: %s - %s", entity.Error.Code, entity.Error.Msg)
+		return nil
+	}
+	if entity.Result == nil || entity.Result.RespResult.RespCode != respCodeSuccess {
+		return nil
+	}
+
+	products := entity.Result.RespResult.Result.Products.Product
+	if len(products) == 0 {
+		return nil
+	}
+	return repo.buildProductDetail(&products[0])
+}
+
+func (repo *Repo) buildProductDetail(p *Product) *model.OfferDetail {
+	o := repo.buildOffer(p, repo.config.IsProxyRequired(model.AliExpress))
+
+	attrs := make(map[string]string)
+	if p.ShopUrl != "" {
+		attrs["shop"] = p.ShopUrl
+	}
+	if p.LastestVolume > 0 {
+		attrs["sold"] = strconv.Itoa(p.LastestVolume)
+	}
+
+	detItems := make([]model.OfferDetailItem, 0)
+	det := model.NewOfferDetail(
+		*o,
+		html.UnescapeString(p.ProductTitle),
+		attrs,
+		detItems,
+	)
+
+	return det
+}
@@ -0,0 +1,55 @@
+package aliexpress
+
+import (
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	q := url.Values{}
+	q.Set("app_key", "12345678")
+	q.Set("method", "aliexpress.affiliate.product.query")
+	q.Set("sign_method", "sha256")
+	q.Set("timestamp", "1697012345678")
+	q.Set("keywords", "skyrim")
+
+	expected := "FA17361CC50F79ED3774F37C5BC11ED7DB1D4BB9D40BADCD4DE712B885E28BA4"
+	assert.Equal(t, expected, sign(q, "helloworld"))
+
+	// a previous signature is not signed
+	q.Set("sign", "ABC")
+	assert.Equal(t, expected, sign(q, "helloworld"))
+}
+
+func TestBuildOffer(t *testing.T) {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	repo := &Repo{config: c}
+	p := Product{
+		ProductId:               1005004531843040,
+		ProductTitle:            "Skyrim Dragon Amulet &amp; Chain",
+		ProductDetailUrl:        "https://www.aliexpress.com/item/1005004531843040.html",
+		PromotionLink:           "https://s.click.aliexpress.com/s/43040",
+		TargetSalePrice:         "8.99",
+		EvaluateRate:            "96.0%",
+		FirstLevelCategoryName:  "Jewelry & Accessories",
+		SecondLevelCategoryName: "Necklaces & Pendants",
+		ShopId:                  1101234567,
+	}
+
+	o := repo.buildOffer(&p, false)
+	assert.Equal(t, "1005004531843040", o.ExternalId)
+	assert.Equal(t, "Skyrim Dragon Amulet & Chain", o.Name)
+	assert.Equal(t, "https://s.click.aliexpress.com/s/43040", o.SemanticName)
+	assert.Equal(t, float32(8.99), o.Price)
+	assert.Equal(t, float32(4.8), o.Rating)
+	assert.Equal(t, "Necklaces & Pendants", o.ProductCategory)
+	assert.Equal(t, "1101234567", o.Seller)
+
+	p.PromotionLink, p.EvaluateRate = "", ""
+	o = repo.buildOffer(&p, false)
+	assert.Equal(t, "https://www.aliexpress.com/item/1005004531843040.html", o.SemanticName)
+	assert.Equal(t, float32(0), o.Rating)
+}
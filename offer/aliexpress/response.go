@@ -0,0 +1,59 @@
+package aliexpress
+
+// SearchResponse is the response of aliexpress.affiliate.product.query, products matching keywords
+type SearchResponse struct {
+	Result *RespResult    `json:"aliexpress_affiliate_product_query_response"`
+	Error  *ErrorResponse `json:"error_response"`
+}
+
+// ProductDetailResponse is the response of aliexpress.affiliate.productdetail.get, products by id
+type ProductDetailResponse struct {
+	Result *RespResult    `json:"aliexpress_affiliate_productdetail_get_response"`
+	Error  *ErrorResponse `json:"error_response"`
+}
+
+// RespResult wraps the result of an affiliate method, RespCode is 200 on success and 405 if no products were found
+type RespResult struct {
+	RespResult struct {
+		RespCode int         `json:"resp_code"`
+		RespMsg  string      `json:"resp_msg"`
+		Result   ProductPage `json:"result"`
+	} `json:"resp_result"`
+	RequestId string `json:"request_id"`
+}
+
+// ProductPage is a page of products
+type ProductPage struct {
+	CurrentPageNo      int `json:"current_page_no"`
+	CurrentRecordCount int `json:"current_record_count"`
+	TotalRecordCount   int `json:"total_record_count"`
+	Products           struct {
+		Product []Product `json:"product"`
+	} `json:"products"`
+}
+
+// Product is an affiliate product, prices are strings in the target currency
+type Product struct {
+	ProductId               int64  `json:"product_id"`
+	ProductTitle            string `json:"product_title"`
+	ProductDetailUrl        string `json:"product_detail_url"`
+	PromotionLink           string `json:"promotion_link"`
+	ProductMainImageUrl     string `json:"product_main_image_url"`
+	TargetSalePrice         string `json:"target_sale_price"`
+	TargetSalePriceCurrency string `json:"target_sale_price_currency"`
+	TargetOriginalPrice     string `json:"target_original_price"`
+	EvaluateRate            string `json:"evaluate_rate"`
+	LastestVolume           int    `json:"lastest_volume"`
+	FirstLevelCategoryName  string `json:"first_level_category_name"`
+	SecondLevelCategoryName string `json:"second_level_category_name"`
+	ShopId                  int64  `json:"shop_id"`
+	ShopUrl                 string `json:"shop_url"`
+}
+
+// ErrorResponse is returned instead of a result when a call is rejected
+type ErrorResponse struct {
+	Type      string `json:"type"`
+	Code      string `json:"code"`
+	Msg       string `json:"msg"`
+	RequestId string `json:"request_id"`
+}
@@ -1,267 +1,148 @@
 package amazon
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/xml"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 )
 
-// ItemLookupQuery describes the allowed parameters for a ItemLookup request
-type ItemLookupQuery struct {
-	Condition             string
-	IDType                string
-	IncludeReviewsSummary string
-	ItemIDs               []string
-	MerchantID            string
-	RelatedItemPage       string
-	RelationshipType      string
-	SearchIndex           string
-	TruncateReviewsAt     string
-	VariationPage         string
-	ResponseGroups        []string
-}
-
-// ItemSearchQuery describes the allowed parameters for a ItemSearch request
-type ItemSearchQuery struct {
-	Actor                 string
-	Artist                string
-	AudienceRatings       []string
-	Author                string
-	Availability          string
-	Brand                 string
-	BrowseNode            string
-	Composer              string
-	Condition             string
-	Conductor             string
-	Director              string
-	IncludeReviewsSummary string
-	ItemPage              string
-	Keywords              string
-	Manufacturer          string
-	MaximumPrice          string
-	MerchantID            string
-	MinimumPrice          string
-	MinPercentageOff      string
-	Orchestra             string
-	Power                 string
-	Publisher             string
-	RelatedItemPage       string
-	RelationshipType      string
-	SearchIndex           string
-	Sort                  string
-	Title                 string
-	TruncateReviewsAt     string
-	VariationPage         string
-	ResponseGroups        []string
-}
-
-type BrowseNodeLookupQuery struct {
-	BrowseNodeID   string
-	ResponseGroups []string
-}
+// x-amz-target prefix of PA-API 5.0 operations
+const targetPrefix = "com.amazon.paapi5.v1.ProductAdvertisingAPIv1."
 
 // Client provides the functions to interact with the API
 type Client struct {
-	config Config
+	config     Config
+	httpClient *http.Client
 }
 
 // NewClient returns a new Client
-func NewClient(config Config) *Client {
-	c := Client{config}
+func NewClient(config Config, httpClient *http.Client) *Client {
+	c := Client{config, httpClient}
 
 	return &c
 }
 
-// NewRequest returns a request with basic parameters
-func (client Client) NewRequest(operation string) *Request {
-
-	request := Request{}
-
-	if client.config.Secure {
-		request.scheme = "https"
-	} else {
-		request.scheme = "http"
+// returns the marketplace of the configured region, defaults to US
+func (client Client) marketplace() Marketplace {
+	if m, ok := Marketplaces[strings.ToUpper(client.config.Region)]; ok {
+		return m
 	}
+	return Marketplaces["US"]
+}
 
-	request.endpoint = Endpoints[client.config.Region]
-	request.endpointURI = EndpointURI
-
-	request.parameters = map[string]string{
-		"Service":        "AWSECommerceService",
-		"AWSAccessKeyId": client.config.AccessKey,
-		"AssociateTag":   client.config.AssociateTag,
-		"Version":        "2013-08-01",
-		"Operation":      operation,
-		"Timestamp":      time.Now().Format(time.RFC3339),
+// returns the partner params of a request to the configured marketplace
+func (client Client) partnerRequest() PartnerRequest {
+	return PartnerRequest{
+		PartnerTag:  client.config.AssociateTag,
+		PartnerType: PartnerTypeAssociates,
+		Marketplace: client.marketplace().Marketplace,
 	}
-
-	return &request
 }
 
-// SignRequest produces the signature for the given query string
-func (client Client) SignRequest(request *Request) {
-	signable := fmt.Sprintf("GET\n%s\n%s\n%s", request.endpoint, request.endpointURI, request.QueryString())
-
-	hasher := hmac.New(sha256.New, []byte(client.config.AccessSecret))
-	hasher.Write([]byte(signable))
-
-	request.signature = base64.StdEncoding.EncodeToString(hasher.Sum(nil))
+// builds the url of an operation
+func (client Client) operationURL(operation string) string {
+	scheme := "http"
+	if client.config.Secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s%s", scheme, client.marketplace().Host, EndpointPath, strings.ToLower(operation))
 }
 
-// ProcessRequest takes a request and queries the API
-func (client Client) ProcessRequest(request *Request, timeout time.Duration) ([]byte, error) {
-
-	// Sign the request
-	client.SignRequest(request)
-
-	// Execute the HTTP request
-	var httpResponse *http.Response
-	var err error
-	var contents []byte
-
-	requestURL, err := request.SignedURL()
+// ProcessRequest posts the signed json payload of an operation and decodes the response into out,
+// returns an *Error if the API rejects the request
+func (client Client) ProcessRequest(operation string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return nil, errors.New("error: cannot get the signed request URL")
+		return err
 	}
 
-	httpClient := &http.Client{
-		Timeout: timeout,
-	}
-
-	req, err := http.NewRequest("GET", requestURL, nil)
+	req, err := http.NewRequest(http.MethodPost, client.operationURL(operation), bytes.NewReader(body))
 	if err != nil {
-		return nil, errors.New("error on building request")
+		return errors.New("error on building request")
 	}
+	req.Header.Set("Content-Encoding", "amz-1.0")
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Amz-Target", targetPrefix+operation)
+	signV4(req, body, client.config.AccessKey, client.config.AccessSecret, client.marketplace().Region, ServiceName, time.Now())
 
-	httpResponse, err = httpClient.Do(req)
+	httpResponse, err := client.httpClient.Do(req)
 	if err != nil {
-		log.Fatal("Do: ", err)
-		return nil, errors.New(fmt.Sprintf("error on request: %s - error: %s", requestURL, err.Error()))
+		return fmt.Errorf("error on request: %s - error: %s", req.URL, err.Error())
 	}
-	contents, err = ioutil.ReadAll(httpResponse.Body)
+	contents, err := ioutil.ReadAll(httpResponse.Body)
 	httpResponse.Body.Close()
-
 	if err != nil {
-		return nil, errors.New("error while reading the server response")
+		return errors.New("error while reading the server response")
 	}
 
-	return contents, nil
-}
-
-// ItemLookup performs an ItemLookup request
-func (client Client) ItemLookup(query ItemLookupQuery, timeout time.Duration) (*ItemLookupResponse, error) {
-
-	request := client.NewRequest("ItemLookup")
+	if httpResponse.StatusCode != http.StatusOK {
+		var e struct {
+			Errors []ErrorData `json:"Errors"`
+		}
+		json.Unmarshal(contents, &e)
+		return newError(httpResponse.StatusCode, e.Errors)
+	}
 
-	request.SetParameter("Condition", query.Condition)
-	request.SetParameter("IdType", query.IDType)
-	request.SetParameter("IncludeReviewsSummary", query.IncludeReviewsSummary)
-	request.SetParameter("ItemId", strings.Join(query.ItemIDs, ","))
-	request.SetParameter("MerchantId", query.MerchantID)
-	request.SetParameter("RelatedItemPage", query.RelatedItemPage)
-	request.SetParameter("RelationshipType", query.RelationshipType)
-	request.SetParameter("SearchIndex", query.SearchIndex)
-	request.SetParameter("TruncateReviewsAt", query.TruncateReviewsAt)
-	request.SetParameter("VariationPage", query.VariationPage)
-	request.SetParameter("ResponseGroup", strings.Join(query.ResponseGroups, ","))
+	return json.Unmarshal(contents, out)
+}
 
-	xmlData, err := client.ProcessRequest(request, timeout)
+// SearchItems performs a SearchItems request
+func (client Client) SearchItems(query SearchItemsRequest) (*SearchItemsResponse, error) {
+	query.PartnerRequest = client.partnerRequest()
 
-	if err != nil {
+	var response SearchItemsResponse
+	if err := client.ProcessRequest(SearchItemsOperation, query, &response); err != nil {
 		return nil, err
 	}
-
-	var response ItemLookupResponse
-	xml.Unmarshal(xmlData, &response)
-
-	if !response.Items.Request.IsValid {
-		return &response, errors.New("error: request is invalid")
+	if response.SearchResult == nil {
+		return &response, newError(http.StatusOK, response.Errors)
 	}
-
 	return &response, nil
 }
 
-// ItemLookup performs an ItemLookup request
-func (client Client) ItemSearch(query ItemSearchQuery, timeout time.Duration) (*ItemSearchResponse, error) {
-
-	request := client.NewRequest("ItemSearch")
-
-	request.SetParameter("Actor", query.Actor)
-	request.SetParameter("Artist", query.Artist)
-	request.SetParameter("AudienceRating", strings.Join(query.AudienceRatings, ","))
-	request.SetParameter("Author", query.Author)
-	request.SetParameter("Availability", query.Availability)
-	request.SetParameter("Brand", query.Brand)
-	request.SetParameter("BrowseNode", query.BrowseNode)
-	request.SetParameter("Composer", query.Composer)
-	request.SetParameter("Condition", query.Condition)
-	request.SetParameter("Conductor", query.Conductor)
-	request.SetParameter("Director", query.Director)
-	request.SetParameter("IncludeReviewsSummary", query.IncludeReviewsSummary)
-	request.SetParameter("ItemPage", query.ItemPage)
-	request.SetParameter("Keywords", query.Keywords)
-	request.SetParameter("Manufacturer", query.Manufacturer)
-	request.SetParameter("MaximumPrice", query.MaximumPrice)
-	request.SetParameter("MerchantId", query.MerchantID)
-	request.SetParameter("MinimumPrice", query.MinimumPrice)
-	request.SetParameter("MinPercentageOff", query.MinPercentageOff)
-	request.SetParameter("Orchestra", query.Orchestra)
-	request.SetParameter("Power", query.Power)
-	request.SetParameter("Publisher", query.Publisher)
-	request.SetParameter("RelatedItemPage", query.RelatedItemPage)
-	request.SetParameter("RelationshipType", query.RelationshipType)
-	request.SetParameter("SearchIndex", query.SearchIndex)
-	request.SetParameter("Sort", query.Sort)
-	request.SetParameter("Title", query.Title)
-	request.SetParameter("TruncateReviewsAt", query.TruncateReviewsAt)
-	request.SetParameter("VariationPage", query.VariationPage)
-	request.SetParameter("ResponseGroup", strings.Join(query.ResponseGroups, ","))
+// GetItems performs a GetItems request
+func (client Client) GetItems(query GetItemsRequest) (*GetItemsResponse, error) {
+	query.PartnerRequest = client.partnerRequest()
 
-	xmlData, err := client.ProcessRequest(request, timeout)
-
-	if err != nil {
+	var response GetItemsResponse
+	if err := client.ProcessRequest(GetItemsOperation, query, &response); err != nil {
 		return nil, err
 	}
+	if response.ItemsResult == nil {
+		return &response, newError(http.StatusOK, response.Errors)
+	}
+	return &response, nil
+}
+
+// GetVariations performs a GetVariations request
+func (client Client) GetVariations(query GetVariationsRequest) (*GetVariationsResponse, error) {
+	query.PartnerRequest = client.partnerRequest()
 
-	var response ItemSearchResponse
-	if err = xml.Unmarshal(xmlData, &response); err != nil {
+	var response GetVariationsResponse
+	if err := client.ProcessRequest(GetVariationsOperation, query, &response); err != nil {
 		return nil, err
 	}
-
-	if !response.Items.Request.IsValid {
-		return &response, errors.New("error: request is invalid")
+	if response.VariationsResult == nil {
+		return &response, newError(http.StatusOK, response.Errors)
 	}
 	return &response, nil
 }
 
-// ItemLookup performs an ItemLookup request
-func (client Client) BrowseNodeLookup(query BrowseNodeLookupQuery, timeout time.Duration) (*BrowseNodeLookupResponse, error) {
-
-	request := client.NewRequest("BrowseNodeLookup")
-
-	request.SetParameter("BrowseNodeId", query.BrowseNodeID)
-	request.SetParameter("ResponseGroup", strings.Join(query.ResponseGroups, ","))
+// GetBrowseNodes performs a GetBrowseNodes request
+func (client Client) GetBrowseNodes(query GetBrowseNodesRequest) (*GetBrowseNodesResponse, error) {
+	query.PartnerRequest = client.partnerRequest()
 
-	xmlData, err := client.ProcessRequest(request, timeout)
-
-	if err != nil {
+	var response GetBrowseNodesResponse
+	if err := client.ProcessRequest(GetBrowseNodesOperation, query, &response); err != nil {
 		return nil, err
 	}
-
-	var response BrowseNodeLookupResponse
-	xml.Unmarshal(xmlData, &response)
-
-	if !response.BrowseNodes.Request.IsValid {
-		return &response, errors.New("error: request is invalid")
+	if response.BrowseNodesResult == nil {
+		return &response, newError(http.StatusOK, response.Errors)
 	}
-
 	return &response, nil
 }
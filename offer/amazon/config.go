@@ -1,23 +1,45 @@
 package amazon
 
-// Endpoints are the Amazon API endpoints by region
-var Endpoints = map[string]string{
-	"BR": "webservices.amazon.com.br",
-	"CA": "webservices.amazon.ca",
-	"CN": "webservices.amazon.cn",
-	"DE": "webservices.amazon.de",
-	"ES": "webservices.amazon.es",
-	"FR": "webservices.amazon.fr",
-	"IN": "webservices.amazon.in",
-	"IT": "webservices.amazon.it",
-	"JP": "webservices.amazon.co.jp",
-	"MX": "webservices.amazon.com.mx",
-	"UK": "webservices.amazon.co.uk",
-	"US": "webservices.amazon.com",
+import "github.com/guilhebl/go-offer/common/model"
+
+// Marketplace is the PA-API host, AWS region used to sign requests and marketplace domain of a locale
+type Marketplace struct {
+	Host        string
+	Region      string
+	Marketplace string
+}
+
+// Marketplaces are the Amazon PA-API 5.0 marketplaces by locale
+var Marketplaces = map[string]Marketplace{
+	"AU": {"webservices.amazon.com.au", "us-west-2", "www.amazon.com.au"},
+	"BR": {"webservices.amazon.com.br", "us-east-1", "www.amazon.com.br"},
+	"CA": {"webservices.amazon.ca", "us-east-1", "www.amazon.ca"},
+	"DE": {"webservices.amazon.de", "eu-west-1", "www.amazon.de"},
+	"ES": {"webservices.amazon.es", "eu-west-1", "www.amazon.es"},
+	"FR": {"webservices.amazon.fr", "eu-west-1", "www.amazon.fr"},
+	"IN": {"webservices.amazon.in", "eu-west-1", "www.amazon.in"},
+	"IT": {"webservices.amazon.it", "eu-west-1", "www.amazon.it"},
+	"JP": {"webservices.amazon.co.jp", "us-west-2", "www.amazon.co.jp"},
+	"MX": {"webservices.amazon.com.mx", "us-east-1", "www.amazon.com.mx"},
+	"UK": {"webservices.amazon.co.uk", "eu-west-1", "www.amazon.co.uk"},
+	"US": {"webservices.amazon.com", "us-east-1", "www.amazon.com"},
 }
 
-// EndpointURI is the fixed request URI of the API
-const EndpointURI = "/onca/xml"
+// returns the marketplace locale of a country, ok is false if Amazon has no marketplace in country
+func RegionOf(country string) (string, bool) {
+	region := model.CountryAlpha2(country)
+	if region == "GB" {
+		region = "UK"
+	}
+	_, ok := Marketplaces[region]
+	return region, ok
+}
+
+// service name used to sign PA-API requests
+const ServiceName = "ProductAdvertisingAPI"
+
+// EndpointPath is the path of PA-API 5.0 operations, followed by the lowercase operation name
+const EndpointPath = "/paapi5/"
 
 // Config describes the service configuration
 type Config struct {
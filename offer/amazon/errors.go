@@ -0,0 +1,66 @@
+package amazon
+
+import "fmt"
+
+// API error codes
+const (
+	NoResultsCode             = "NoResults"
+	InvalidParameterValueCode = "InvalidParameterValue"
+	ItemNotAccessibleCode     = "ItemNotAccessible"
+	TooManyRequestsCode       = "TooManyRequests"
+	UnrecognizedClientCode    = "UnrecognizedClient"
+	InvalidSignatureCode      = "InvalidSignature"
+	IncompleteSignatureCode   = "IncompleteSignature"
+	AccessDeniedCode          = "AccessDenied"
+	InvalidPartnerTagCode     = "InvalidPartnerTag"
+	InvalidAssociateCode      = "InvalidAssociate"
+)
+
+// Error is an error returned by the API with the http status of the response
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+// builds the error of a response from its status and the first of its errors
+func newError(statusCode int, errs []ErrorData) *Error {
+	e := &Error{StatusCode: statusCode}
+	if len(errs) > 0 {
+		e.Code, e.Message = errs[0].Code, errs[0].Message
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("amazon error: %d %s - %s", e.StatusCode, e.Code, e.Message)
+}
+
+// checks if no items match the request or the items requested don't exist
+func (e *Error) IsNotFound() bool {
+	switch e.Code {
+	case NoResultsCode, ItemNotAccessibleCode, InvalidParameterValueCode:
+		return true
+	}
+	return false
+}
+
+// checks if requests are being throttled
+func (e *Error) IsThrottled() bool {
+	return e.Code == TooManyRequestsCode || e.StatusCode == 429
+}
+
+// checks if credentials or partner tag were refused
+func (e *Error) IsUnauthorized() bool {
+	switch e.Code {
+	case UnrecognizedClientCode, InvalidSignatureCode, IncompleteSignatureCode, AccessDeniedCode, InvalidPartnerTagCode, InvalidAssociateCode:
+		return true
+	}
+	return e.StatusCode == 401 || e.StatusCode == 403
+}
+
+// checks if err is an API error that no items were found
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.IsNotFound()
+}
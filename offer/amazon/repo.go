@@ -3,163 +3,239 @@ package amazon
 import (
 	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
-	"github.com/guilhebl/go-offer/common/util"
 	"github.com/guilhebl/go-offer/offer/monitor"
 	"github.com/guilhebl/go-worker-pool"
 	"log"
 	"math/rand"
-	"regexp"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// items requested per search page, the PA-API 5.0 maximum
+const pageSize = 10
+
+// Repo is the Amazon marketplace provider, it holds the dependencies required to query the Amazon API
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+}
+
+// builds a new Amazon provider using config, request monitor and http client, registering a request monitor
+// bucket for each marketplace region as each region has its own request quota
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	repo := &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+	}
+
+	for region := range Marketplaces {
+		waitInterval, _ := strconv.Atoi(repo.regionProperty("amazonRequestWaitIntervalMilis", region))
+		m.Register(monitorName(region), waitInterval)
+	}
+	return repo
+}
+
+// returns the request monitor bucket of a marketplace region
+func monitorName(region string) string {
+	return model.Amazon + "/" + region
+}
+
+// returns the marketplace region of country if it has its own credentials in config, otherwise amazonDefaultRegion
+func (repo *Repo) region(country string) string {
+	defaultRegion := strings.ToUpper(repo.config.GetProperty("amazonDefaultRegion"))
+	if region, ok := RegionOf(country); ok && (region == defaultRegion || repo.hasCredentials(region)) {
+		return region
+	}
+	return defaultRegion
+}
+
+// returns if region has its own access key, secret key and associate tag in config, e.g. amazonAssociateTag.CA
+func (repo *Repo) hasCredentials(region string) bool {
+	for _, key := range []string{"amazonAccessKeyId", "amazonSecretKey", "amazonAssociateTag"} {
+		if repo.config.GetProperty(key+"."+region) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the property of region, falling back to the property shared by all regions
+func (repo *Repo) regionProperty(key, region string) string {
+	if v := repo.config.GetProperty(key + "." + region); v != "" {
+		return v
+	}
+	return repo.config.GetProperty(key)
+}
+
+// Amazon serves the countries of the marketplaces with their own credentials in config, even if not listed
+// in the providers of the country
+func (repo *Repo) ServesCountry(country string) bool {
+	region, ok := RegionOf(country)
+	return ok && repo.hasCredentials(region)
+}
+
 // Creates Job for Searching offers from Amazon and returns a Channel with jobResults
-func SearchOffers(m map[string]string) *job.Job {
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
 	// create output channel
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewSearchTask()
+	task := NewSearchTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
-// Searches for offers from amazon
-func search(m map[string]string) *model.OfferList {
+// Searches for offers from amazon in the marketplace of the request country
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
+	// format vendor specific params
+	p := repo.filterParams(m)
+	region := repo.region(p[model.Country])
+
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.Amazon) {
+	if !repo.monitor.IsServiceAvailable(monitorName(region)) {
 		log.Printf("Unable to acquire lock from Request Monitor")
-		return nil
+		return nil, monitor.ErrThrottled
 	}
 
-	// format vendor specific params
-	p := filterParams(m)
 	page, err := strconv.Atoi(p[model.Page])
 
 	if err != nil {
 		log.Printf("format page error: %s", err.Error())
-		return nil
+		return nil, err
 	}
 
-	region := config.GetProperty("amazonDefaultRegion")
-	accessKeyId := config.GetProperty("amazonAccessKeyId")
-	secretKey := config.GetProperty("amazonSecretKey")
-	associateTag := config.GetProperty("amazonAssociateTag")
-	timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
-
-	cfg := NewConfig(accessKeyId, secretKey, associateTag, region, true)
-	client := NewClient(cfg)
-
-	query := ItemSearchQuery{
-		SearchIndex:    "All",
-		Keywords:       p[model.Keywords],
-		ItemPage:       p[model.Page],
-		ResponseGroups: []string{"Images", "ItemAttributes", "Offers"},
+	client := repo.newClient(region)
+	query := SearchItemsRequest{
+		SearchIndex: "All",
+		Keywords:    p[model.Keywords],
+		ItemCount:   pageSize,
+		ItemPage:    page,
+		Resources:   ItemResources,
 	}
-	response, err := client.ItemSearch(query, timeout)
+	response, err := client.SearchItems(query)
 
 	if err != nil {
+		if IsNotFound(err) {
+			return model.NewOfferList(make([]model.Offer, 0), page, 0, 0), nil
+		}
 		log.Printf("%s", err.Error())
-		return nil
+		if e, ok := err.(*Error); ok && e.IsThrottled() {
+			return nil, monitor.ErrThrottled
+		}
+		return nil, err
 	}
 
-	return buildSearchResponse(response, page)
+	return repo.buildSearchResponse(response, page), nil
+}
+
+// builds a PA-API client of the marketplace region with the credentials and associate tag of the region
+func (repo *Repo) newClient(region string) *Client {
+	accessKeyId := repo.regionProperty("amazonAccessKeyId", region)
+	secretKey := repo.regionProperty("amazonSecretKey", region)
+	associateTag := repo.regionProperty("amazonAssociateTag", region)
+
+	cfg := NewConfig(accessKeyId, secretKey, associateTag, region, true)
+	return NewClient(cfg, repo.client)
 }
 
 // builds Offer list response mapping from vendor specific params
-func buildSearchResponse(r *ItemSearchResponse, page int) *model.OfferList {
-	items := r.Items
-	total := len(items.Items)
-	if total == 0 {
-		return nil
-	}
-	totalPages := items.TotalPages
+func (repo *Repo) buildSearchResponse(r *SearchItemsResponse, page int) *model.OfferList {
+	result := r.SearchResult
+	totalPages := (result.TotalResultCount + pageSize - 1) / pageSize
 
-	list := buildSearchItemList(items.Items)
-	o := model.NewOfferList(list, page, totalPages, total)
+	list := repo.buildSearchItemList(result.Items)
+	o := model.NewOfferList(list, page, totalPages, result.TotalResultCount)
 	return o
 }
 
-func buildSearchItemList(items []Item) []model.Offer {
+func (repo *Repo) buildSearchItemList(items []Item) []model.Offer {
 	list := make([]model.Offer, 0)
-	proxyRequired := config.IsProxyRequired(model.Amazon)
+	proxyRequired := repo.config.IsProxyRequired(model.Amazon)
 
 	for _, item := range items {
-		o := buildOffer(&item, proxyRequired)
+		o := repo.buildOffer(&item, proxyRequired)
 		list = append(list, *o)
 	}
 
 	return list
 }
 
-func buildOffer(item *Item, proxyRequired bool) *model.Offer {
-	itemAttrs := item.ItemAttributes
-	summary := item.OfferSummary
-
+func (repo *Repo) buildOffer(item *Item, proxyRequired bool) *model.Offer {
 	imgUrl := ""
-	if item.LargeImage != nil {
-		imgUrl = item.LargeImage.URL
-	}
-
-	upc := ""
-	if itemAttrs != nil && itemAttrs.UPC != "" {
-		upc = itemAttrs.UPC
+	if item.Images != nil && item.Images.Primary.Large != nil {
+		imgUrl = item.Images.Primary.Large.URL
 	}
 
-	title := ""
-	if itemAttrs != nil && itemAttrs.Title != "" {
-		title = itemAttrs.Title
+	upc, title, productGroup, brand := "", "", "", ""
+	if info := item.ItemInfo; info != nil {
+		if info.ExternalIds != nil && info.ExternalIds.UPCs != nil && len(info.ExternalIds.UPCs.DisplayValues) > 0 {
+			upc = info.ExternalIds.UPCs.DisplayValues[0]
+		}
+		if info.Title != nil {
+			title = info.Title.DisplayValue
+		}
+		if info.Classifications != nil && info.Classifications.ProductGroup != nil {
+			productGroup = info.Classifications.ProductGroup.DisplayValue
+		}
+		if info.ByLineInfo != nil && info.ByLineInfo.Brand != nil {
+			brand = info.ByLineInfo.Brand.DisplayValue
+		}
 	}
 
-	productGroup := ""
-	if itemAttrs != nil && itemAttrs.ProductGroup != "" {
-		productGroup = itemAttrs.ProductGroup
+	rating, numReviews := float32(0.0), 0
+	if item.CustomerReviews != nil {
+		numReviews = item.CustomerReviews.Count
+		if item.CustomerReviews.StarRating != nil {
+			rating = item.CustomerReviews.StarRating.Value
+		}
 	}
 
 	o := model.NewOffer(
-		util.GenerateStringUUID(),
+		"",
 		item.ASIN,
 		upc,
 		title,
 		model.Amazon,
 		item.DetailPageURL,
-		config.BuildImgUrlExternal(imgUrl, proxyRequired),
-		config.BuildImgUrl("amazon-logo.png"),
+		repo.config.BuildImgUrlExternal(imgUrl, proxyRequired),
+		repo.config.BuildImgUrl("amazon-logo.png"),
 		productGroup,
-		buildPrice(summary.LowestNewPrice, summary.LowerUsedPrice),
-		0.0,
-		0,
+		buildPrice(item.Offers),
+		rating,
+		numReviews,
 		time.Now(),
 	)
+	o.Brand = brand
 
 	return o
 }
 
-// builds amazon item price based on lowestNew or lowestUsed
-func buildPrice(lowestNew, lowestUsed Price) float32 {
-	if lowestNew.FormattedPrice != "" {
-		return getFormattedPriceValue(lowestNew.FormattedPrice)
-	} else if lowestUsed.FormattedPrice != "" {
-		return getFormattedPriceValue(lowestUsed.FormattedPrice)
+// builds amazon item price based on the lowest new price, then lowest used price, then first listing
+func buildPrice(offers *Offers) float32 {
+	if offers == nil {
+		return 0.0
 	}
 
-	return 0.0
-}
+	for _, condition := range []string{"New", "Used"} {
+		for _, s := range offers.Summaries {
+			if s.Condition != nil && s.Condition.Value == condition && s.LowestPrice != nil {
+				return s.LowestPrice.Amount
+			}
+		}
+	}
 
-func getFormattedPriceValue(priceStr string) float32 {
-	var re = regexp.MustCompile(`[^\d.]`)
-	formatted := re.ReplaceAllString(priceStr, "")
-	price, err := strconv.ParseFloat(formatted, 32)
-	if err != nil {
-		log.Printf("error on parsing price for item string: %s", priceStr)
-		return 0.0
+	if len(offers.Listings) > 0 && offers.Listings[0].Price != nil {
+		return offers.Listings[0].Price.Amount
 	}
-	return float32(price)
+	return 0.0
 }
 
 // filters vendor specific params from generic offer model params
-func filterParams(m map[string]string) map[string]string {
+func (repo *Repo) filterParams(m map[string]string) map[string]string {
 	p := make(map[string]string)
 
 	// get search keyword phrase
@@ -167,7 +243,7 @@ func filterParams(m map[string]string) map[string]string {
 		p[model.Keywords] = m[model.Name]
 	} else {
 		// amazon does not have a trending api so we need to fetch random query searches
-		p[model.Keywords] = getRandomSearchQuery()
+		p[model.Keywords] = repo.getRandomSearchQuery()
 	}
 
 	// get page - defaults to 1
@@ -176,19 +252,21 @@ func filterParams(m map[string]string) map[string]string {
 	} else {
 		p[model.Page] = "1"
 	}
+
+	p[model.Country] = m[model.Country]
 	return p
 }
 
 // gets a random string inside an array of strings of queries
-func getRandomSearchQuery() string {
-	query := config.GetProperty("amazonDefaultSearchQuery")
+func (repo *Repo) getRandomSearchQuery() string {
+	query := repo.config.GetProperty("amazonDefaultSearchQuery")
 	keywords := strings.Split(query, ",")
 	i := rand.Intn(len(keywords))
 	return keywords[i]
 }
 
 // Creates Job for fetching Product Detail and returns a Channel with jobResult
-func GetDetailJob(id, idType, country string) *job.Job {
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
 	// convert to map for job to consume
 	m := make(map[string]string)
 	m["id"], m["idType"], m["country"] = id, idType, country
@@ -197,117 +275,141 @@ func GetDetailJob(id, idType, country string) *job.Job {
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewGetDetailTask()
+	task := NewGetDetailTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
-// filters Id Type for this vendor
-func getIdTypeVendor(idType string) string {
-	switch idType {
-	case model.Id:
-		return "ASIN"
-	case model.Upc:
-		return "UPC"
-	case model.Ean:
-		return "EAN"
-	case model.Isbn:
-		return "ISBN"
-	default:
-		return ""
-	}
-}
-
 // Search for a specific product detail either by Id or Upc
-func GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
 	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
 
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.Amazon) {
+	region := repo.region(country)
+	if !repo.monitor.IsServiceAvailable(monitorName(region)) {
 		log.Printf("Unable to acquire lock from Request Monitor")
 		return nil
 	}
 
-	if idType == model.Id || idType == model.Upc {
-		idTypeVendor := getIdTypeVendor(idType)
+	var item *Item
+	var err error
+	switch idType {
+	case model.Id:
+		item, err = repo.getItemByAsin(id, region)
+	case model.Upc:
+		item, err = repo.getItemByUpc(id, region)
+	default:
+		return nil
+	}
 
-		region := config.GetProperty("amazonDefaultRegion")
-		accessKeyId := config.GetProperty("amazonAccessKeyId")
-		secretKey := config.GetProperty("amazonSecretKey")
-		associateTag := config.GetProperty("amazonAssociateTag")
-		timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
+	if err != nil {
+		if !IsNotFound(err) {
+			log.Printf("error: %s", err)
+		}
+		return nil
+	}
+	if item == nil {
+		return nil
+	}
+	return repo.buildProductDetailResponse(item)
+}
 
-		cfg := NewConfig(accessKeyId, secretKey, associateTag, region, true)
-		client := NewClient(cfg)
+// looks up an item by its ASIN
+func (repo *Repo) getItemByAsin(asin, region string) (*Item, error) {
+	query := GetItemsRequest{
+		ItemIds:    []string{asin},
+		ItemIdType: "ASIN",
+		Resources:  ItemResources,
+	}
+	response, err := repo.newClient(region).GetItems(query)
+	if err != nil {
+		return nil, err
+	}
 
-		indexSearch := ""
-		if idTypeVendor != "ASIN" {
-			indexSearch = "All"
-		}
+	items := response.ItemsResult.Items
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
 
-		query := ItemLookupQuery{
-			SearchIndex:    indexSearch,
-			IDType:         idTypeVendor,
-			ItemIDs:        []string{id},
-			ResponseGroups: []string{"Images", "ItemAttributes", "Offers"},
-		}
+// PA-API 5.0 only looks up items by ASIN, so upc lookups search for the upc and pick the item with a matching upc,
+// returns nil if no item has the upc
+func (repo *Repo) getItemByUpc(upc, region string) (*Item, error) {
+	query := SearchItemsRequest{
+		SearchIndex: "All",
+		Keywords:    upc,
+		Resources:   ItemResources,
+	}
+	response, err := repo.newClient(region).SearchItems(query)
+	if err != nil {
+		return nil, err
+	}
 
-		response, err := client.ItemLookup(query, timeout)
-		if err != nil {
-			log.Printf("error: %s", err)
-			return nil
+	items := response.SearchResult.Items
+	for i := range items {
+		if hasUpc(&items[i], upc) {
+			return &items[i], nil
 		}
-		return buildProductDetailResponse(response)
 	}
+	return nil, nil
+}
 
-	return nil
+// checks if upc is one of the UPCs of item
+func hasUpc(item *Item, upc string) bool {
+	if item.ItemInfo == nil || item.ItemInfo.ExternalIds == nil || item.ItemInfo.ExternalIds.UPCs == nil {
+		return false
+	}
+	for _, v := range item.ItemInfo.ExternalIds.UPCs.DisplayValues {
+		if v == upc {
+			return true
+		}
+	}
+	return false
 }
 
-func buildAttributes(a *ItemAttributes) map[string]string {
+func buildAttributes(info *ItemInfo) map[string]string {
 	attrs := make(map[string]string)
 
-	if a == nil {
+	if info == nil {
 		return attrs
 	}
 
-	m := a.Model
-	if m != "" {
-		attrs[model.Model] = m
-	}
-
-	manufacturer := a.Manufacturer
-	if manufacturer != "" {
-		attrs[model.Manufacturer] = manufacturer
-	}
-
-	brand := a.Brand
-	if brand != "" {
-		attrs[model.Brand] = brand
+	if info.ManufactureInfo != nil && info.ManufactureInfo.Model != nil && info.ManufactureInfo.Model.DisplayValue != "" {
+		attrs[model.Model] = info.ManufactureInfo.Model.DisplayValue
 	}
 
-	publisher := a.Publisher
-	if publisher != "" {
-		attrs[model.Publisher] = publisher
+	if b := info.ByLineInfo; b != nil {
+		if b.Manufacturer != nil && b.Manufacturer.DisplayValue != "" {
+			attrs[model.Manufacturer] = b.Manufacturer.DisplayValue
+		}
+		if b.Brand != nil && b.Brand.DisplayValue != "" {
+			attrs[model.Brand] = b.Brand.DisplayValue
+		}
+		for _, c := range b.Contributors {
+			if c.RoleType == "publisher" && c.Name != "" {
+				attrs[model.Publisher] = c.Name
+				break
+			}
+		}
 	}
 
 	return attrs
 }
 
-func buildProductDetailResponse(response *ItemLookupResponse) *model.OfferDetail {
-	items := response.Items
-	item := items.Item
+func (repo *Repo) buildProductDetailResponse(item *Item) *model.OfferDetail {
 	if item.ASIN == "" {
 		return nil
 	}
 
-	o := buildOffer(&item, config.IsProxyRequired(model.Amazon))
+	o := repo.buildOffer(item, repo.config.IsProxyRequired(model.Amazon))
 
 	desc := ""
-	if item.ItemAttributes != nil {
-		desc = item.ItemAttributes.Feature
+	if item.ItemInfo != nil && item.ItemInfo.Features != nil {
+		desc = strings.Join(item.ItemInfo.Features.DisplayValues, " ")
 	}
 
-	attrs := buildAttributes(item.ItemAttributes)
+	attrs := buildAttributes(item.ItemInfo)
 	detItems := make([]model.OfferDetailItem, 0)
 	return model.NewOfferDetail(*o, desc, attrs, detItems)
 }
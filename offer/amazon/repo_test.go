@@ -0,0 +1,92 @@
+package amazon
+
+import (
+	"bytes"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// fileTransport answers every request with the testdata file it names
+type fileTransport string
+
+func (f fileTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	data, err := ioutil.ReadFile("testdata/" + string(f))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(data)), Request: r}, nil
+}
+
+// builds an Amazon provider whose requests are answered with testdata file
+func newFileRepo(t *testing.T, file string) *Repo {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	return NewRepo(c, monitor.NewRequestMonitor(c), &http.Client{Transport: fileTransport(file)})
+}
+
+// tests countries use the marketplace of their region when it has its own credentials
+func TestRegion(t *testing.T) {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	repo := NewRepo(c, monitor.NewRequestMonitor(c), http.DefaultClient)
+
+	assert.Equal(t, "US", repo.region(model.UnitedStates))
+	assert.Equal(t, "CA", repo.region(model.Canada))
+	assert.Equal(t, "US", repo.region("gbr"))
+	assert.Equal(t, "US", repo.region("bra"))
+	assert.Equal(t, "US", repo.region("nzl"))
+
+	assert.True(t, repo.ServesCountry("ca"))
+	assert.False(t, repo.ServesCountry("gb"))
+
+	c.SetProperty("amazonAccessKeyId.UK", "uk-key")
+	c.SetProperty("amazonSecretKey.UK", "uk-secret")
+	c.SetProperty("amazonAssociateTag.UK", "uk-tag")
+	assert.Equal(t, "UK", repo.region("gb"))
+	assert.True(t, repo.ServesCountry("gb"))
+
+	client := repo.newClient("CA")
+	assert.Equal(t, "www.amazon.ca", client.partnerRequest().Marketplace)
+	assert.Equal(t, "TEST12345678-CA", client.partnerRequest().PartnerTag)
+	assert.Equal(t, "https://webservices.amazon.ca/paapi5/searchitems", client.operationURL(SearchItemsOperation))
+
+	client = repo.newClient("US")
+	assert.Equal(t, "TEST12345678", client.partnerRequest().PartnerTag)
+
+	// each region has its own request monitor bucket
+	assert.True(t, repo.monitor.IsServiceAvailable(monitorName("US")))
+	assert.True(t, repo.monitor.IsServiceAvailable(monitorName("CA")))
+}
+
+// tests search totals are the total results of the search, not the items of a page
+func TestSearch(t *testing.T) {
+	repo := newFileRepo(t, "amazon_sample_search_response.json")
+
+	list, err := repo.search(map[string]string{model.Name: "smartwatch"})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(list.List))
+	assert.Equal(t, 63847, list.TotalCount)
+	assert.Equal(t, 6385, list.PageCount)
+}
+
+// tests upc lookups return the item with the upc and nothing if no item has it
+func TestGetItemByUpc(t *testing.T) {
+	repo := newFileRepo(t, "amazon_sample_get_detail_by_upc_response.json")
+
+	item, err := repo.getItemByUpc("093155171251", "US")
+	assert.Nil(t, err)
+	assert.Equal(t, "B01GW8XJVU", item.ASIN)
+
+	item, err = repo.getItemByUpc("065857174434", "US")
+	assert.Nil(t, err)
+	assert.Equal(t, "B01GW8XJVU", item.ASIN)
+
+	item, err = repo.getItemByUpc("849803052423", "US")
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+}
@@ -1,66 +1,84 @@
 package amazon
 
-// Request provides a generic API request
-import (
-	"errors"
-	"fmt"
-	"net/url"
-	"sort"
-	"strings"
+// PA-API 5.0 operations
+const (
+	SearchItemsOperation    = "SearchItems"
+	GetItemsOperation       = "GetItems"
+	GetVariationsOperation  = "GetVariations"
+	GetBrowseNodesOperation = "GetBrowseNodes"
 )
 
-// Request represents an API request
-type Request struct {
-	scheme      string
-	endpoint    string
-	endpointURI string
-	signature   string
-	parameters  map[string]string
-}
+// partner type of associates partner tags
+const PartnerTypeAssociates = "Associates"
 
-// SetParameter adds a parameter to the request
-func (request Request) SetParameter(key string, value string) {
-	request.parameters[key] = value
+// item resources requested to build offers and offer details
+var ItemResources = []string{
+	"BrowseNodeInfo.BrowseNodes",
+	"CustomerReviews.Count",
+	"CustomerReviews.StarRating",
+	"Images.Primary.Large",
+	"ItemInfo.ByLineInfo",
+	"ItemInfo.Classifications",
+	"ItemInfo.ExternalIds",
+	"ItemInfo.Features",
+	"ItemInfo.ManufactureInfo",
+	"ItemInfo.Title",
+	"Offers.Listings.Condition",
+	"Offers.Listings.Price",
+	"Offers.Summaries.LowestPrice",
 }
 
-// Parameters returns the request parameters
-func (request Request) Parameters() map[string]string {
-	return request.parameters
+// browse node resources requested on GetBrowseNodes
+var BrowseNodeResources = []string{
+	"BrowseNodes.Ancestor",
+	"BrowseNodes.Children",
 }
 
-// QueryString returns the query string of the request
-func (request Request) QueryString() string {
-	var queryString string
-
-	// Sort the parameters keys
-	var keys []string
-	for key := range request.parameters {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
-
-	// Build the query string
-	for _, key := range keys {
-		if url.QueryEscape(request.parameters[key]) == "" {
-			continue // Skip empty params
-		}
-		queryString += fmt.Sprintf("%s=%s&", url.QueryEscape(key), url.QueryEscape(request.parameters[key]))
-	}
+// PartnerRequest holds the partner and marketplace params common to every operation
+type PartnerRequest struct {
+	PartnerTag  string `json:"PartnerTag"`
+	PartnerType string `json:"PartnerType"`
+	Marketplace string `json:"Marketplace,omitempty"`
+}
 
-	// Remove last '&'
-	return strings.TrimRight(queryString, "&")
+// SearchItemsRequest describes the allowed parameters of a SearchItems request
+type SearchItemsRequest struct {
+	PartnerRequest
+	Keywords     string   `json:"Keywords,omitempty"`
+	SearchIndex  string   `json:"SearchIndex,omitempty"`
+	BrowseNodeId string   `json:"BrowseNodeId,omitempty"`
+	Brand        string   `json:"Brand,omitempty"`
+	Condition    string   `json:"Condition,omitempty"`
+	ItemCount    int      `json:"ItemCount,omitempty"`
+	ItemPage     int      `json:"ItemPage,omitempty"`
+	MaxPrice     int      `json:"MaxPrice,omitempty"`
+	MinPrice     int      `json:"MinPrice,omitempty"`
+	SortBy       string   `json:"SortBy,omitempty"`
+	Resources    []string `json:"Resources,omitempty"`
 }
 
-// URL gives the complete URL of the request without the signature
-func (request Request) URL() string {
-	return fmt.Sprintf("%s://%s%s?%s", request.scheme, request.endpoint, request.endpointURI, request.QueryString())
+// GetItemsRequest describes the allowed parameters of a GetItems request, items are looked up by ASIN
+type GetItemsRequest struct {
+	PartnerRequest
+	ItemIds    []string `json:"ItemIds"`
+	ItemIdType string   `json:"ItemIdType,omitempty"`
+	Condition  string   `json:"Condition,omitempty"`
+	Resources  []string `json:"Resources,omitempty"`
 }
 
-// SignedURL returns the full signed request URL
-func (request Request) SignedURL() (string, error) {
-	if request.signature == "" {
-		return "", errors.New("request is unsigned")
-	}
+// GetVariationsRequest describes the allowed parameters of a GetVariations request
+type GetVariationsRequest struct {
+	PartnerRequest
+	ASIN           string   `json:"ASIN"`
+	Condition      string   `json:"Condition,omitempty"`
+	VariationCount int      `json:"VariationCount,omitempty"`
+	VariationPage  int      `json:"VariationPage,omitempty"`
+	Resources      []string `json:"Resources,omitempty"`
+}
 
-	return fmt.Sprintf("%s&Signature=%s", request.URL(), url.QueryEscape(request.signature)), nil
+// GetBrowseNodesRequest describes the allowed parameters of a GetBrowseNodes request
+type GetBrowseNodesRequest struct {
+	PartnerRequest
+	BrowseNodeIds []string `json:"BrowseNodeIds"`
+	Resources     []string `json:"Resources,omitempty"`
 }
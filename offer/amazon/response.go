@@ -1,201 +1,215 @@
 package amazon
 
-// Response describes the generic API Response
-type Response struct {
-	OperationRequest struct {
-		RequestID             string     `xml:"RequestId"`
-		Arguments             []Argument `xml:"Arguments>Argument"`
-		RequestProcessingTime float64
-	}
+// ErrorData describes an error returned by the API
+type ErrorData struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
 }
 
-// Argument todo
-type Argument struct {
-	Name  string `xml:"Name,attr"`
-	Value string `xml:"Value,attr"`
+// SearchItemsResponse is the response of a SearchItems request
+type SearchItemsResponse struct {
+	SearchResult *SearchResult `json:"SearchResult"`
+	Errors       []ErrorData   `json:"Errors"`
 }
 
-// Image todo
-type Image struct {
-	URL    string
-	Height uint16
-	Width  uint16
+// SearchResult holds a page of items matching a search
+type SearchResult struct {
+	Items            []Item `json:"Items"`
+	SearchURL        string `json:"SearchURL"`
+	TotalResultCount int    `json:"TotalResultCount"`
 }
 
-// Price describes the product price as
-// Amount of cents in CurrencyCode
-type Price struct {
-	Amount         uint
-	CurrencyCode   string
-	FormattedPrice string
+// GetItemsResponse is the response of a GetItems request
+type GetItemsResponse struct {
+	ItemsResult *ItemsResult `json:"ItemsResult"`
+	Errors      []ErrorData  `json:"Errors"`
+}
+
+// ItemsResult holds the items looked up
+type ItemsResult struct {
+	Items []Item `json:"Items"`
+}
+
+// GetVariationsResponse is the response of a GetVariations request
+type GetVariationsResponse struct {
+	VariationsResult *VariationsResult `json:"VariationsResult"`
+	Errors           []ErrorData       `json:"Errors"`
+}
+
+// VariationsResult holds a page of variations of an item
+type VariationsResult struct {
+	Items            []Item           `json:"Items"`
+	VariationSummary VariationSummary `json:"VariationSummary"`
+}
+
+// VariationSummary describes the variations of an item
+type VariationSummary struct {
+	PageCount      int `json:"PageCount"`
+	VariationCount int `json:"VariationCount"`
 }
 
-type TopSeller struct {
-	ASIN  string
-	Title string
+// GetBrowseNodesResponse is the response of a GetBrowseNodes request
+type GetBrowseNodesResponse struct {
+	BrowseNodesResult *BrowseNodesResult `json:"BrowseNodesResult"`
+	Errors            []ErrorData        `json:"Errors"`
+}
+
+// BrowseNodesResult holds the browse nodes looked up
+type BrowseNodesResult struct {
+	BrowseNodes []BrowseNode `json:"BrowseNodes"`
 }
 
 // Item represents a product returned by the API
 type Item struct {
-	ASIN             string
-	URL              string
-	DetailPageURL    string
-	ItemAttributes   *ItemAttributes
-	OfferSummary     OfferSummary
-	Offers           Offers
-	SalesRank        int
-	SmallImage       *Image
-	MediumImage      *Image
-	LargeImage       *Image
-	ImageSets        *ImageSets
-	EditorialReviews EditorialReviews
-	BrowseNodes      struct {
-		BrowseNode []BrowseNode
-	}
-}
-
-// BrowseNode represents a browse node returned by API
-type BrowseNode struct {
-	BrowseNodeID string `xml:"BrowseNodeId"`
-	Name         string
-	TopSellers   struct {
-		TopSeller []TopSeller
-	}
-	Ancestors struct {
-		BrowseNode []BrowseNode
-	}
-}
-
-// ItemAttributes response group
-type ItemAttributes struct {
-	Author          string
-	Binding         string
-	Brand           string
-	Color           string
-	EAN             string
-	Creator         string
-	Title           string
-	ListPrice       Price
-	Manufacturer    string
-	Publisher       string
-	NumberOfItems   int
-	PackageQuantity int
-	Feature         string
-	Model           string
-	ProductGroup    string
-	ReleaseDate     string
-	Studio          string
-	Warranty        string
-	Size            string
-	UPC             string
-}
-
-// Offer response attribute
-type Offer struct {
-	Condition       string `xml:"OfferAttributes>Condition"`
-	ID              string `xml:"OfferListing>OfferListingId"`
-	Price           Price  `xml:"OfferListing>Price"`
-	PercentageSaved uint   `xml:"OfferListing>PercentageSaved"`
-	Availability    string `xml:"OfferListing>Availability"`
-}
-
-// Offers response group
+	ASIN            string           `json:"ASIN"`
+	ParentASIN      string           `json:"ParentASIN"`
+	DetailPageURL   string           `json:"DetailPageURL"`
+	Images          *Images          `json:"Images"`
+	ItemInfo        *ItemInfo        `json:"ItemInfo"`
+	Offers          *Offers          `json:"Offers"`
+	CustomerReviews *CustomerReviews `json:"CustomerReviews"`
+	BrowseNodeInfo  *BrowseNodeInfo  `json:"BrowseNodeInfo"`
+}
+
+// Images holds the primary and variant images of an item
+type Images struct {
+	Primary  ImageSizes   `json:"Primary"`
+	Variants []ImageSizes `json:"Variants"`
+}
+
+// ImageSizes holds an image in each available size
+type ImageSizes struct {
+	Small  *Image `json:"Small"`
+	Medium *Image `json:"Medium"`
+	Large  *Image `json:"Large"`
+}
+
+// Image describes an image url and its dimensions
+type Image struct {
+	URL    string `json:"URL"`
+	Height int    `json:"Height"`
+	Width  int    `json:"Width"`
+}
+
+// ItemInfo holds the attributes of an item
+type ItemInfo struct {
+	ByLineInfo      *ByLineInfo      `json:"ByLineInfo"`
+	Classifications *Classifications `json:"Classifications"`
+	ExternalIds     *ExternalIds     `json:"ExternalIds"`
+	Features        *MultiValued     `json:"Features"`
+	ManufactureInfo *ManufactureInfo `json:"ManufactureInfo"`
+	Title           *SingleValued    `json:"Title"`
+}
+
+// SingleValued is an attribute with a single display value
+type SingleValued struct {
+	DisplayValue string `json:"DisplayValue"`
+	Label        string `json:"Label"`
+	Locale       string `json:"Locale"`
+}
+
+// MultiValued is an attribute with many display values
+type MultiValued struct {
+	DisplayValues []string `json:"DisplayValues"`
+	Label         string   `json:"Label"`
+	Locale        string   `json:"Locale"`
+}
+
+// ByLineInfo holds the brand, manufacturer and contributors of an item
+type ByLineInfo struct {
+	Brand        *SingleValued `json:"Brand"`
+	Manufacturer *SingleValued `json:"Manufacturer"`
+	Contributors []Contributor `json:"Contributors"`
+}
+
+// Contributor is a person or company contributing to an item such as an author or publisher
+type Contributor struct {
+	Name     string `json:"Name"`
+	Role     string `json:"Role"`
+	RoleType string `json:"RoleType"`
+	Locale   string `json:"Locale"`
+}
+
+// Classifications holds the binding and product group of an item
+type Classifications struct {
+	Binding      *SingleValued `json:"Binding"`
+	ProductGroup *SingleValued `json:"ProductGroup"`
+}
+
+// ExternalIds holds the EANs, ISBNs and UPCs of an item
+type ExternalIds struct {
+	EANs  *MultiValued `json:"EANs"`
+	ISBNs *MultiValued `json:"ISBNs"`
+	UPCs  *MultiValued `json:"UPCs"`
+}
+
+// ManufactureInfo holds the model and part number of an item
+type ManufactureInfo struct {
+	ItemPartNumber *SingleValued `json:"ItemPartNumber"`
+	Model          *SingleValued `json:"Model"`
+	Warranty       *SingleValued `json:"Warranty"`
+}
+
+// Offers holds the listings of an item and a summary of its prices by condition
 type Offers struct {
-	TotalOffers     int
-	TotalOfferPages int
-	MoreOffersURL   string  `xml:"MoreOffersUrl"`
-	Offers          []Offer `xml:"Offer"`
+	Listings  []Listing      `json:"Listings"`
+	Summaries []OfferSummary `json:"Summaries"`
 }
 
-// OfferSummary response group
+// Listing is an offer of an item by a merchant
+type Listing struct {
+	Id           string        `json:"Id"`
+	Condition    *Condition    `json:"Condition"`
+	Price        *Price        `json:"Price"`
+	Availability *Availability `json:"Availability"`
+}
+
+// OfferSummary holds the lowest price of an item in a condition
 type OfferSummary struct {
-	LowestNewPrice   Price
-	LowerUsedPrice   Price
-	TotalNew         int
-	TotalUsed        int
-	TotalCollectible int
-	TotalRefurbished int
-}
-
-// EditorialReview response attribute
-type EditorialReview struct {
-	Source  string
-	Content string
-}
-
-// EditorialReviews response group
-type EditorialReviews struct {
-	EditorialReview EditorialReview
-}
-
-// BrowseNodeLookupRequest is the confirmation of a BrowseNodeInfo request
-type BrowseNodeLookupRequest struct {
-	BrowseNodeId  string
-	ResponseGroup string
-}
-
-// ItemLookupRequest is the confirmation of a ItemLookup request
-type ItemLookupRequest struct {
-	IDType        string `xml:"IdType"`
-	ItemID        string `xml:"ItemId"`
-	ResponseGroup string `xml:"ResponseGroup"`
-	VariationPage string
-}
-
-// ItemLookupResponse describes the API response for the ItemLookup operation
-type ItemLookupResponse struct {
-	Response
-	Items struct {
-		Request struct {
-			IsValid           bool
-			ItemLookupRequest ItemLookupRequest
-		}
-		Item Item `xml:"Item"`
-	}
-}
-
-// ItemSearchRequest is the confirmation of a ItemSearch request
-type ItemSearchRequest struct {
-	Keywords      string `xml:"Keywords"`
-	SearchIndex   string `xml:"SearchIndex"`
-	ResponseGroup string `xml:"ResponseGroup"`
-}
-
-type ItemSearchResponse struct {
-	Response
-	Items struct {
-		Request struct {
-			IsValid           bool
-			ItemSearchRequest ItemSearchRequest
-		}
-		Items                []Item `xml:"Item"`
-		TotalResult          int
-		TotalPages           int
-		MoreSearchResultsUrl string
-	}
-}
-
-type BrowseNodeLookupResponse struct {
-	Response
-	BrowseNodes struct {
-		Request struct {
-			IsValid                 bool
-			BrowseNodeLookupRequest BrowseNodeLookupRequest
-		}
-		BrowseNode BrowseNode
-	}
-}
-
-type ImageSets struct {
-	ImageSet []ImageSet
-}
-
-type ImageSet struct {
-	Category       string `xml:",attr"`
-	SwatchImage    *Image
-	SmallImage     *Image
-	ThumbnailImage *Image
-	TinyImage      *Image
-	MediumImage    *Image
-	LargeImage     *Image
+	Condition   *Condition `json:"Condition"`
+	LowestPrice *Price     `json:"LowestPrice"`
+	OfferCount  int        `json:"OfferCount"`
+}
+
+// Condition of an offer: New, Used, Collectible or Refurbished
+type Condition struct {
+	Value string `json:"Value"`
+}
+
+// Price describes an amount in Currency
+type Price struct {
+	Amount        float32 `json:"Amount"`
+	Currency      string  `json:"Currency"`
+	DisplayAmount string  `json:"DisplayAmount"`
+}
+
+// Availability describes when an offer ships
+type Availability struct {
+	Message string `json:"Message"`
+}
+
+// CustomerReviews holds the number of reviews and average rating of an item
+type CustomerReviews struct {
+	Count      int                 `json:"Count"`
+	StarRating *CustomerStarRating `json:"StarRating"`
+}
+
+// CustomerStarRating is the average rating of an item out of 5 stars
+type CustomerStarRating struct {
+	Value float32 `json:"Value"`
+}
+
+// BrowseNodeInfo holds the browse nodes an item belongs to
+type BrowseNodeInfo struct {
+	BrowseNodes []BrowseNode `json:"BrowseNodes"`
+}
+
+// BrowseNode represents a category of the Amazon catalog
+type BrowseNode struct {
+	Id              string       `json:"Id"`
+	DisplayName     string       `json:"DisplayName"`
+	ContextFreeName string       `json:"ContextFreeName"`
+	IsRoot          bool         `json:"IsRoot"`
+	Ancestor        *BrowseNode  `json:"Ancestor"`
+	Children        []BrowseNode `json:"Children"`
 }
@@ -0,0 +1,186 @@
+package bestbuy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-strutil"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Searches for offers from bestbuy.ca, without keywords bestbuyCaDefaultSearchQuery is searched
+// as the bestbuy.ca api has no trending products
+func (repo *Repo) searchCanada(m map[string]string) (*model.OfferList, error) {
+	query := m[model.Name]
+	if query == "" {
+		query = repo.config.GetProperty("bestbuyCaDefaultSearchQuery")
+	}
+
+	page := 1
+	if m[model.Page] != "" {
+		p, err := strconv.Atoi(m[model.Page])
+		if err != nil || p < 1 {
+			log.Printf("format page error: %s", m[model.Page])
+			return nil, errors.New(model.InvalidRequest)
+		}
+		page = p
+	}
+
+	q := url.Values{}
+	q.Add("query", query)
+	q.Add("page", strconv.Itoa(page))
+	q.Add("pageSize", repo.config.GetProperty("bestbuyCaDefaultPageSize"))
+
+	var entity CanadaSearchResponse
+	if !repo.getCanada(repo.config.GetProperty("bestbuyCaProductSearchPath"), q, &entity) {
+		return nil, errors.New("bestbuy.ca search failed")
+	}
+	return repo.buildCanadaSearchResponse(&entity), nil
+}
+
+// Search for a specific bestbuy.ca product detail either by Sku or Upc, upc lookups search the upc
+// as the product api only accepts skus
+func (repo *Repo) getOfferDetailCanada(id, idType string) *model.OfferDetail {
+	switch idType {
+	case model.Id:
+		path := fmt.Sprintf("%s/%s", repo.config.GetProperty("bestbuyCaProductDetailPath"), url.PathEscape(id))
+
+		var entity CanadaProduct
+		if !repo.getCanada(path, url.Values{}, &entity) || entity.Sku == "" {
+			return nil
+		}
+		return repo.buildCanadaProductDetail(&entity)
+
+	case model.Upc:
+		q := url.Values{}
+		q.Add("query", id)
+		q.Add("page", "1")
+		q.Add("pageSize", "1")
+
+		var entity CanadaSearchResponse
+		if !repo.getCanada(repo.config.GetProperty("bestbuyCaProductSearchPath"), q, &entity) || len(entity.Products) == 0 {
+			return nil
+		}
+		p := entity.Products[0]
+		p.UpcNumber = id
+		return repo.buildCanadaProductDetail(&p)
+	}
+
+	return nil
+}
+
+// calls path of the bestbuy.ca api decoding the json response into v, returns false if the call fails
+func (repo *Repo) getCanada(path string, q url.Values, v interface{}) bool {
+	endpoint := repo.config.GetProperty("bestbuyCaEndpoint")
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", endpoint, path), nil)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	req.Header.Set("Accept", "application/json")
+	q.Add("lang", repo.config.GetProperty("bestbuyCaLanguage"))
+	req.URL.RawQuery = q.Encode()
+	log.Printf("BestBuy Canada: %s", req.URL.String())
+
+	resp, err := repo.client.Do(req)
+	if err != nil {
+		log.Printf("Do: %s", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("BestBuy Canada error status: %d", resp.StatusCode)
+		return false
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
+func (repo *Repo) buildCanadaSearchResponse(r *CanadaSearchResponse) *model.OfferList {
+	list := make([]model.Offer, 0, len(r.Products))
+	proxyRequired := repo.config.IsProxyRequired(model.BestBuyCanada)
+
+	for _, p := range r.Products {
+		list = append(list, repo.buildCanadaOffer(&p, proxyRequired))
+	}
+	return model.NewOfferList(list, r.CurrentPage, r.TotalPages, r.Total)
+}
+
+// maps a bestbuy.ca product to an offer, product urls are relative to bestbuyCaWebsite and prices in CAD
+func (repo *Repo) buildCanadaOffer(p *CanadaProduct, proxyRequired bool) model.Offer {
+	productUrl := p.ProductUrl
+	if strings.HasPrefix(productUrl, "/") {
+		productUrl = repo.config.GetProperty("bestbuyCaWebsite") + productUrl
+	}
+
+	image := p.HighResImage
+	if image == "" {
+		image = p.ThumbnailImage
+	}
+
+	price := p.SalePrice
+	if price == 0 {
+		price = p.RegularPrice
+	}
+
+	o := model.NewOffer(
+		"",
+		p.Sku,
+		p.UpcNumber,
+		html.UnescapeString(p.Name),
+		model.BestBuyCanada,
+		productUrl,
+		repo.config.BuildImgUrlExternal(image, proxyRequired),
+		repo.config.BuildImgUrl("best-buy-ca-logo.png"),
+		p.CategoryName,
+		price,
+		p.CustomerRating,
+		p.CustomerRatingCount,
+		time.Now(),
+	)
+
+	// marketplace products are sold by third party sellers
+	if p.IsMarketplace && p.Seller != nil {
+		o.Seller = p.Seller.Name
+	}
+
+	return *o
+}
+
+func (repo *Repo) buildCanadaProductDetail(p *CanadaProduct) *model.OfferDetail {
+	proxyRequired := repo.config.IsProxyRequired(model.BestBuyCanada)
+	o := repo.buildCanadaOffer(p, proxyRequired)
+
+	description := p.LongDescription
+	if description == "" {
+		description = p.ShortDescription
+	}
+
+	attrs := make(map[string]string)
+	if p.BrandName != "" {
+		attrs[model.Brand] = p.BrandName
+	}
+
+	detItems := make([]model.OfferDetailItem, 0)
+	det := model.NewOfferDetail(
+		o,
+		html.UnescapeString(strutil.FilterHtmlTags(description)),
+		attrs,
+		detItems,
+	)
+
+	return det
+}
@@ -0,0 +1,27 @@
+package bestbuy
+
+// CanadaProduct is a product of the bestbuy.ca api, only the product api returns its upc, brand and long description
+type CanadaProduct struct {
+	Sku                 string        `json:"sku"`
+	Name                string        `json:"name"`
+	UpcNumber           string        `json:"upcNumber"`
+	BrandName           string        `json:"brandName"`
+	ShortDescription    string        `json:"shortDescription"`
+	LongDescription     string        `json:"longDescription"`
+	ProductUrl          string        `json:"productUrl"`
+	RegularPrice        float32       `json:"regularPrice"`
+	SalePrice           float32       `json:"salePrice"`
+	HighResImage        string        `json:"highResImage"`
+	ThumbnailImage      string        `json:"thumbnailImage"`
+	CustomerRating      float32       `json:"customerRating"`
+	CustomerRatingCount int           `json:"customerRatingCount"`
+	CategoryName        string        `json:"categoryName"`
+	IsMarketplace       bool          `json:"isMarketplace"`
+	Seller              *CanadaSeller `json:"seller"`
+}
+
+// CanadaSeller is the marketplace seller of a bestbuy.ca product
+type CanadaSeller struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,10 @@
+package bestbuy
+
+// CanadaSearchResponse is the response of the bestbuy.ca search api
+type CanadaSearchResponse struct {
+	CurrentPage int             `json:"currentPage"`
+	Total       int             `json:"total"`
+	TotalPages  int             `json:"totalPages"`
+	PageSize    int             `json:"pageSize"`
+	Products    []CanadaProduct `json:"products"`
+}
@@ -5,55 +5,82 @@ import (
 	"fmt"
 	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
-	"github.com/guilhebl/go-offer/common/util"
 	"github.com/guilhebl/go-offer/offer/monitor"
 	"github.com/guilhebl/go-worker-pool"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Repo is the BestBuy marketplace provider, it holds the dependencies required to query the BestBuy API
+// of a country: bestbuy.com or bestbuy.ca
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+	party   string
+}
+
+// builds a new BestBuy provider of bestbuy.com using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		party:   model.BestBuy,
+	}
+}
+
+// builds a new BestBuy provider of bestbuy.ca using config, request monitor and http client
+func NewCanadaRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		party:   model.BestBuyCanada,
+	}
+}
+
 // Creates Job for Searching offers and returns a Channel with jobResults
-func SearchOffers(m map[string]string) *job.Job {
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
 	// create output channel
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewSearchTask()
+	task := NewSearchTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
 // Searches for offers from BBY
-func search(m map[string]string) *model.OfferList {
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
 
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.BestBuy) {
+	if !repo.monitor.IsServiceAvailable(repo.party) {
 		log.Printf("Unable to acquire lock from Request Monitor")
-		return nil
+		return nil, monitor.ErrThrottled
+	}
+
+	if repo.party == model.BestBuyCanada {
+		return repo.searchCanada(m)
 	}
 
 	// format vendor specific params
 	p := filterParams(m)
 
-	endpoint := config.GetProperty("bestbuyEndpoint")
+	endpoint := repo.config.GetProperty("bestbuyEndpoint")
 	isKeywordSearch := p[model.Keywords] != ""
 	page, _ := strconv.ParseInt(p[model.Page], 10, 0)
-	pageSize := int(config.GetIntProperty("bestbuyDefaultPageSize"))
-	apiKey := config.GetProperty("bestbuyApiKey")
-	affiliateId := config.GetProperty("bestbuyLinkShareId")
-	timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
-
-	var start = 1
-	if page > 1 {
-		start = int(page) - 1*int(pageSize) + 1
-	}
+	pageSize := int(repo.config.GetIntProperty("bestbuyDefaultPageSize"))
+	apiKey := repo.config.GetProperty("bestbuyApiKey")
+	affiliateId := repo.config.GetProperty("bestbuyLinkShareId")
 
 	if isKeywordSearch {
-		listFields := config.GetProperty("bestbuyListFields")
-		path := config.GetProperty("bestbuyProductSearchPath")
+		listFields := repo.config.GetProperty("bestbuyListFields")
+		path := repo.config.GetProperty("bestbuyProductSearchPath")
 		url := fmt.Sprintf("%s/%s%s", endpoint, path, p[model.Keywords])
 		req, err := http.NewRequest("GET", url, nil)
 		req.Header.Set("Accept", "application/json")
@@ -62,20 +89,17 @@ func search(m map[string]string) *model.OfferList {
 		q.Add("apiKey", apiKey)
 		q.Add("LID", affiliateId)
 		q.Add("show", listFields)
-		q.Add("page", strconv.Itoa(start))
+		q.Add("page", strconv.Itoa(int(page)))
 		q.Add("pageSize", strconv.Itoa(pageSize))
+		q.Add("facet", manufacturer)
 		req.URL.RawQuery = q.Encode()
 		url = fmt.Sprintf(req.URL.String())
 		log.Printf("BestBuy search: %s", url)
 
-		client := &http.Client{
-			Timeout: timeout,
-		}
-
-		resp, err := client.Do(req)
+		resp, err := repo.client.Do(req)
 		if err != nil {
-			log.Fatal("Do: ", err)
-			return nil
+			log.Printf("Do: %s", err)
+			return nil, err
 		}
 		defer resp.Body.Close()
 
@@ -83,13 +107,13 @@ func search(m map[string]string) *model.OfferList {
 
 		if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
 			log.Println(err)
-			return nil
+			return nil, err
 		}
-		return buildSearchResponse(&entity)
+		return repo.buildSearchResponse(&entity), nil
 	} else {
 
 		// search trending items if no keyword provided
-		url := endpoint + "/" + config.GetProperty("bestbuyProductTrendingPath")
+		url := endpoint + "/" + repo.config.GetProperty("bestbuyProductTrendingPath")
 		req, err := http.NewRequest("GET", url, nil)
 
 		req.Header.Set("Accept", "application/json")
@@ -101,14 +125,10 @@ func search(m map[string]string) *model.OfferList {
 		url = fmt.Sprintf(req.URL.String())
 		log.Printf("BestBuy trending: %s", url)
 
-		client := &http.Client{
-			Timeout: timeout,
-		}
-
-		resp, err := client.Do(req)
+		resp, err := repo.client.Do(req)
 		if err != nil {
-			log.Fatal("Do: ", err)
-			return nil
+			log.Printf("Do: %s", err)
+			return nil, err
 		}
 		defer resp.Body.Close()
 
@@ -116,34 +136,34 @@ func search(m map[string]string) *model.OfferList {
 
 		if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
 			log.Println(err)
-			return nil
+			return nil, err
 		}
-		return buildTrendingResponse(&entity)
+		return repo.buildTrendingResponse(&entity), nil
 	}
 
-	return nil
+	return nil, nil
 }
 
-func buildTrendingResponse(r *TrendingResponse) *model.OfferList {
-	list := buildTrendingItemList(r.Results)
+func (repo *Repo) buildTrendingResponse(r *TrendingResponse) *model.OfferList {
+	list := repo.buildTrendingItemList(r.Results)
 	o := model.NewOfferList(list, 1, 1, r.Metadata.ResultSet.Count)
 	return o
 }
 
-func buildTrendingItemList(items []TrendingItem) []model.Offer {
+func (repo *Repo) buildTrendingItemList(items []TrendingItem) []model.Offer {
 	list := make([]model.Offer, 0)
-	proxyRequired := config.IsProxyRequired(model.BestBuy)
+	proxyRequired := repo.config.IsProxyRequired(model.BestBuy)
 
 	for _, item := range items {
 		o := model.NewOffer(
-			util.GenerateStringUUID(),
+			"",
 			item.Sku,
 			"",
 			item.Names.Title,
 			model.BestBuy,
 			item.Links.Web,
-			config.BuildImgUrlExternal(item.Images.Standard, proxyRequired),
-			config.BuildImgUrl("best-buy-logo.png"),
+			repo.config.BuildImgUrlExternal(item.Images.Standard, proxyRequired),
+			repo.config.BuildImgUrl("best-buy-logo.png"),
 			model.SpecialOffer,
 			item.Prices.Current,
 			item.CustomerReviews.AverageScore,
@@ -157,18 +177,44 @@ func buildTrendingItemList(items []TrendingItem) []model.Offer {
 	return list
 }
 
-func buildSearchResponse(r *SearchResponse) *model.OfferList {
-	list := buildSearchItemList(r.Products)
+func (repo *Repo) buildSearchResponse(r *SearchResponse) *model.OfferList {
+	list := repo.buildSearchItemList(r.Products)
 	o := model.NewOfferList(list, r.CurrentPage, r.TotalPages, r.Total)
+	o.Facets = buildFacets(r.Facets)
 	return o
 }
 
-func buildSearchItemList(items []SearchItem) []model.Offer {
+// BestBuy manufacturer attribute, its facet is the brand facet of offers
+const manufacturer = "manufacturer"
+
+// maps the manufacturer facet of a search response to the brand facet sorted by count then value
+func buildFacets(facets map[string]map[string]int) []model.Facet {
+	counts := facets[manufacturer]
+	if len(counts) == 0 {
+		return nil
+	}
+
+	values := make([]model.FacetValue, 0, len(counts))
+	for v, c := range counts {
+		if c > 0 {
+			values = append(values, model.FacetValue{Value: v, Count: c})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return []model.Facet{model.NewFacet(model.Brand, values)}
+}
+
+func (repo *Repo) buildSearchItemList(items []SearchItem) []model.Offer {
 	list := make([]model.Offer, 0)
-	proxyRequired := config.IsProxyRequired(model.BestBuy)
+	proxyRequired := repo.config.IsProxyRequired(model.BestBuy)
 
 	for _, item := range items {
-		o := buildOffer(&item, proxyRequired)
+		o := repo.buildOffer(&item, proxyRequired)
 		list = append(list, o)
 	}
 
@@ -186,9 +232,9 @@ func buildCategoryPath(c []CategoryPath) string {
 func filterParams(m map[string]string) map[string]string {
 	p := make(map[string]string)
 
-	// get search keyword phrase
+	// get search keyword phrase refined by brand
 	if m[model.Name] != "" {
-		p[model.Keywords] = buildSearchPath(m[model.Name])
+		p[model.Keywords] = buildSearchPath(m[model.Name], m[model.Brand])
 	}
 
 	// get page - defaults to 1
@@ -201,9 +247,10 @@ func filterParams(m map[string]string) map[string]string {
 	return p
 }
 
-// Builds search path pattern for US best buy api
+// Builds search path pattern for US best buy api, brands are matched by manufacturer
 // sample: input 'deals of the day' : output -> (search=deals&search=of&search=the&search=day)
-func buildSearchPath(str string) string {
+// sample: input 'skyrim', 'Bethesda' : output -> (search=skyrim&manufacturer=Bethesda)
+func buildSearchPath(str, brand string) string {
 	s := "("
 	keywords := strings.Split(str, " ")
 	for _, keyword := range keywords {
@@ -211,6 +258,9 @@ func buildSearchPath(str string) string {
 			s += fmt.Sprintf("search=%s&", keyword)
 		}
 	}
+	if brand != "" {
+		s += fmt.Sprintf("%s=%s&", manufacturer, brand)
+	}
 	// remove last &
 	s = strings.TrimSuffix(s, "&")
 	s += ")"
@@ -219,7 +269,7 @@ func buildSearchPath(str string) string {
 }
 
 // Creates Job for fetching Product Detail and returns a Channel with jobResult
-func GetDetailJob(id, idType, country string) *job.Job {
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
 	// convert to map for job to consume
 	m := make(map[string]string)
 	m["id"], m["idType"], m["country"] = id, idType, country
@@ -228,7 +278,7 @@ func GetDetailJob(id, idType, country string) *job.Job {
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewGetDetailTask()
+	task := NewGetDetailTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
@@ -252,20 +302,23 @@ func filterIdType(t string) string {
 }
 
 // Search for a specific product detail either by Id or Upc
-func GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
 	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
 
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.BestBuy) {
+	if !repo.monitor.IsServiceAvailable(repo.party) {
 		log.Printf("Unable to acquire lock from Request Monitor")
 		return nil
 	}
 
-	endpoint := config.GetProperty("bestbuyEndpoint")
-	path := config.GetProperty("bestbuyProductSearchPath")
-	apiKey := config.GetProperty("bestbuyApiKey")
-	affiliateId := config.GetProperty("bestbuyLinkShareId")
-	timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
+	if repo.party == model.BestBuyCanada {
+		return repo.getOfferDetailCanada(id, idType)
+	}
+
+	endpoint := repo.config.GetProperty("bestbuyEndpoint")
+	path := repo.config.GetProperty("bestbuyProductSearchPath")
+	apiKey := repo.config.GetProperty("bestbuyApiKey")
+	affiliateId := repo.config.GetProperty("bestbuyLinkShareId")
 	var idTypeProvider string
 
 	if idTypeProvider = filterIdType(idType); idTypeProvider == "" {
@@ -284,13 +337,9 @@ func GetOfferDetail(id string, idType string, country string) *model.OfferDetail
 	url = fmt.Sprintf(req.URL.String())
 	log.Printf("BestBuy get: %s", url)
 
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	resp, err := client.Do(req)
+	resp, err := repo.client.Do(req)
 	if err != nil {
-		log.Fatal("Do: ", err)
+		log.Printf("Do: %s", err)
 		return nil
 	}
 	defer resp.Body.Close()
@@ -301,41 +350,42 @@ func GetOfferDetail(id string, idType string, country string) *model.OfferDetail
 		log.Println(err)
 		return nil
 	}
-	return buildProductDetail(&entity)
+	return repo.buildProductDetail(&entity)
 
 	return nil
 }
 
-func buildOffer(item *SearchItem, proxyRequired bool) model.Offer {
+func (repo *Repo) buildOffer(item *SearchItem, proxyRequired bool) model.Offer {
 
 	o := model.NewOffer(
-		util.GenerateStringUUID(),
+		"",
 		strconv.Itoa(item.ProductId),
 		item.Upc,
 		item.Name,
 		model.BestBuy,
 		item.Url,
-		config.BuildImgUrlExternal(item.Image, proxyRequired),
-		config.BuildImgUrl("best-buy-logo.png"),
+		repo.config.BuildImgUrlExternal(item.Image, proxyRequired),
+		repo.config.BuildImgUrl("best-buy-logo.png"),
 		buildCategoryPath(item.CategoryPath),
 		item.SalePrice,
 		item.CustomerReviewAverage,
 		item.CustomerReviewCount,
 		time.Now(),
 	)
+	o.Brand = item.Manufacturer
 
 	return *o
 }
 
-func buildProductDetail(r *SearchResponse) *model.OfferDetail {
+func (repo *Repo) buildProductDetail(r *SearchResponse) *model.OfferDetail {
 	if len(r.Products) == 0 {
 		return nil
 	}
 
 	item := r.Products[0]
-	proxyRequired := config.IsProxyRequired(model.BestBuy)
+	proxyRequired := repo.config.IsProxyRequired(model.BestBuy)
 
-	o := buildOffer(&item, proxyRequired)
+	o := repo.buildOffer(&item, proxyRequired)
 	detItems := make([]model.OfferDetailItem, 0)
 	det := model.NewOfferDetail(
 		o,
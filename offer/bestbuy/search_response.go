@@ -11,4 +11,6 @@ type SearchResponse struct {
 	Partial      bool         `json:"partial"`
 	CanonicalUrl string       `json:"canonicalUrl"`
 	Products     []SearchItem `json:"products"`
+	// counts of each value of the facets requested by name
+	Facets map[string]map[string]int `json:"facets"`
 }
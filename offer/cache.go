@@ -0,0 +1,136 @@
+package offer
+
+import (
+	"encoding/json"
+	"github.com/guilhebl/go-offer/common/cache"
+	"github.com/guilhebl/go-offer/common/model"
+	"log"
+	"time"
+)
+
+// cache lookup results, sent back to clients in the X-Cache response header
+const (
+	CacheHeader = "X-Cache"
+	CacheHit    = "HIT"
+	CacheMiss   = "MISS"
+	CacheStale  = "STALE"
+)
+
+// cacheEntry wraps a cached object with its soft expiry, past it the entry is stale: it's still served
+// while a refresh runs in background until the cache backend drops it at the hard expiry.
+type cacheEntry struct {
+	Value   json.RawMessage `json:"value"`
+	Expires time.Time       `json:"expires"`
+}
+
+// reads a cached object by key into obj returning CacheHit, CacheStale if past its soft expiry or CacheMiss
+// cache failures are logged and handled as a miss so the request falls through to the marketplace providers
+func (m *Module) getCached(key string, obj interface{}) string {
+	if !m.isCacheEnabled() {
+		return CacheMiss
+	}
+
+	data, err := m.Cache.Get(key)
+	if err != nil {
+		if err != cache.ErrNotFound {
+			log.Printf("cache get failed for key %s: %s", key, err.Error())
+		}
+		return CacheMiss
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal([]byte(data), &entry); err == nil {
+		err = json.Unmarshal(entry.Value, obj)
+	}
+	if err != nil {
+		log.Printf("cache entry invalid for key %s: %s", key, err.Error())
+		return CacheMiss
+	}
+
+	if time.Now().After(entry.Expires) {
+		return CacheStale
+	}
+	return CacheHit
+}
+
+// stores obj in cache using key, it's fresh for cacheExpirationSeconds
+func (m *Module) setCached(key string, obj interface{}) {
+	m.setCachedFor(key, obj, time.Duration(m.Config.GetIntProperty("cacheExpirationSeconds"))*time.Second)
+}
+
+// stores obj in cache using key, it's fresh for the given duration and may be served stale
+// for cacheMaxStaleSeconds more. cache failures are logged and ignored
+func (m *Module) setCachedFor(key string, obj interface{}, fresh time.Duration) {
+	if !m.isCacheEnabled() {
+		return
+	}
+
+	value, err := json.Marshal(obj)
+	if err != nil {
+		log.Printf("cache entry not serializable for key %s: %s", key, err.Error())
+		return
+	}
+
+	stale := time.Duration(m.Config.GetIntProperty("cacheMaxStaleSeconds")) * time.Second
+	data, _ := json.Marshal(cacheEntry{Value: value, Expires: time.Now().Add(fresh)})
+
+	if err := m.Cache.Set(key, string(data), fresh+stale); err != nil {
+		log.Printf("cache set failed for key %s: %s", key, err.Error())
+	}
+}
+
+// config keys of the expiration of each provider search pages in cache
+var providerCacheExpirationKeys = map[string]string{
+	model.Walmart:       "walmartCacheExpirationSeconds",
+	model.WalmartCanada: "walmartCaCacheExpirationSeconds",
+	model.BestBuy:       "bestbuyCacheExpirationSeconds",
+	model.BestBuyCanada: "bestbuyCaCacheExpirationSeconds",
+	model.Ebay:          "eBayCacheExpirationSeconds",
+	model.Amazon:        "amazonCacheExpirationSeconds",
+	model.Target:        "targetCacheExpirationSeconds",
+	model.AliExpress:    "aliexpressCacheExpirationSeconds",
+	model.Feed:          "feedCacheExpirationSeconds",
+}
+
+// returns how long a search page of provider is fresh, defaults to cacheExpirationSeconds
+func (m *Module) providerCacheExpiration(provider string) time.Duration {
+	seconds := m.Config.GetIntProperty(providerCacheExpirationKeys[provider])
+	if seconds <= 0 {
+		seconds = m.Config.GetIntProperty("cacheExpirationSeconds")
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// refreshes a stale entry in background, the provider calls of fetch are scheduled through the worker pool like
+// any other request. At most cacheMaxRefreshes keys are refreshed at once and a key is refreshed once at a time,
+// stale entries left over are served until a later request refreshes them. No refresh starts once the module is stopping
+func (m *Module) refresh(key string, fetch func() interface{}, load func() (interface{}, bool)) {
+	if _, running := m.refreshing.LoadOrStore(key, true); running {
+		return
+	}
+
+	select {
+	case m.refreshes <- struct{}{}:
+	default:
+		m.refreshing.Delete(key)
+		log.Printf("refresh of stale key %s skipped, too many refreshes running", key)
+		return
+	}
+
+	release := func() {
+		<-m.refreshes
+		m.refreshing.Delete(key)
+	}
+	started := m.goBackground(func() {
+		defer release()
+		log.Printf("refreshing stale key %s", key)
+		m.coalesce(key, fetch, load)
+	})
+	if !started {
+		release()
+	}
+}
+
+func (m *Module) isCacheEnabled() bool {
+	return m.Cache != nil
+}
@@ -0,0 +1,76 @@
+package offer
+
+import (
+	"encoding/json"
+	"github.com/guilhebl/go-offer/common/cache"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/xcrypto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Cache keys are namespaced by kind of entry followed by the fields entries are invalidated by and the hash of the request:
+//
+//	search:{keyword}:{hash}          merged search results
+//	page:{provider}:{keyword}:{hash} search page of a single provider
+//	detail:{source}:{hash}           product detail
+//
+// keywords are query escaped so they never contain ':' or glob characters.
+const (
+	searchKeyPrefix = "search:"
+	pageKeyPrefix   = "page:"
+	detailKeyPrefix = "detail:"
+)
+
+// builds the cache key of the merged results of a canonical search request
+func searchCacheKey(r *model.ListRequest) string {
+	jsonReq, _ := json.Marshal(r)
+	return searchKeyPrefix + keywordSegment(r.Map()[model.Name]) + ":" + xcrypto.GenerateSHA1(string(jsonReq))
+}
+
+// builds the cache key of a provider search page from the query, page and country params,
+// sort params are left out since pages are merged and sorted locally unless the provider sorts its pages,
+// filters of the facets computed over offers are applied locally unless they're sent to providers
+func pageCacheKey(provider string, params map[string]string, sorted bool) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if isOfferFacet(name) && !providerFacets[name] {
+			continue
+		}
+		if sorted || (name != model.SortBy && name != model.SortOrder) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	query := make([]string, len(names))
+	for i, name := range names {
+		query[i] = name + "=" + params[name]
+	}
+
+	return pageKeyPrefix + provider + ":" + keywordSegment(params[model.Name]) + ":" + xcrypto.GenerateSHA1(strings.Join(query, "&"))
+}
+
+// builds the cache key of a product detail request
+func detailCacheKey(r *model.DetailRequest) string {
+	jsonReq, _ := json.Marshal(r)
+	return detailKeyPrefix + r.Source + ":" + xcrypto.GenerateSHA1(string(jsonReq))
+}
+
+// returns the patterns matching every entry of a provider, merged search results are included
+// since they hold the provider offers
+func providerCachePatterns(provider string) []string {
+	p := cache.EscapePattern(provider)
+	return []string{pageKeyPrefix + p + ":*", detailKeyPrefix + p + ":*", searchKeyPrefix + "*"}
+}
+
+// returns the patterns matching every search entry of a keyword
+func keywordCachePatterns(keyword string) []string {
+	k := cache.EscapePattern(keywordSegment(model.NormalizeKeywords(keyword)))
+	return []string{searchKeyPrefix + k + ":*", pageKeyPrefix + "*:" + k + ":*"}
+}
+
+func keywordSegment(keyword string) string {
+	return url.QueryEscape(keyword)
+}
@@ -0,0 +1,107 @@
+package offer
+
+import (
+	"github.com/guilhebl/go-offer/common/model"
+	"log"
+)
+
+// refs of offer ids kept in memory if offerRefCacheSize is not set
+const defaultOfferRefCacheSize = 10000
+
+// represents an offer or offer detail observed in a marketplace waiting to be upserted into the catalog,
+// or the refs of minted offer ids waiting to be stored
+type catalogItem struct {
+	offer  *model.Offer
+	detail *model.OfferDetail
+	refs   []model.OfferRef
+}
+
+// starts the background ingestion into the datastore: refs of minted offer ids are always stored and offers
+// observed are upserted into the catalog if catalogEnabled. items are queued up to catalogQueueSize and written
+// by a single goroutine so requests never wait on the datastore
+func (m *Module) startCatalog() {
+	queue, done := make(chan catalogItem, m.Config.GetIntProperty("catalogQueueSize")), make(chan struct{})
+	m.catalog, m.catalogDone = queue, done
+	m.catalogEnabled = m.Config.GetBoolProperty("catalogEnabled")
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case item := <-queue:
+				m.upsertCatalog(item)
+			}
+		}
+	}()
+}
+
+// stops the catalog ingestion, queued offers are dropped
+func (m *Module) stopCatalog() {
+	if m.catalogDone != nil {
+		close(m.catalogDone)
+	}
+}
+
+// upserts an observed offer or offer detail into the catalog or stores offer refs, failures are logged and ignored.
+// refs not stored are forgotten so they're queued again the next time their offers are seen
+func (m *Module) upsertCatalog(item catalogItem) {
+	var err error
+	switch {
+	case item.refs != nil:
+		if err = m.Store.PutOfferRefs(item.refs); err != nil {
+			m.forgetOfferRefs(item.refs)
+		}
+	case item.detail != nil:
+		err = m.Store.UpsertCatalogDetail(item.detail)
+	default:
+		err = m.Store.UpsertCatalogOffer(item.offer)
+	}
+	if err != nil {
+		log.Printf("catalog upsert failed: %s", err.Error())
+	}
+}
+
+// queues the offers of a provider search page for ingestion
+func (m *Module) ingestOffers(list *model.OfferList) {
+	if !m.catalogEnabled {
+		return
+	}
+	for i := range list.List {
+		o := list.List[i]
+		if o.ExternalId != "" {
+			m.ingest(catalogItem{offer: &o})
+		}
+	}
+}
+
+// queues an offer detail for ingestion, the detail is copied as callers keep changing it
+func (m *Module) ingestDetail(d *model.OfferDetail) {
+	if !m.catalogEnabled || d.Offer.ExternalId == "" {
+		return
+	}
+	m.ingest(catalogItem{detail: &model.OfferDetail{Offer: d.Offer, Description: d.Description, Attributes: d.Attributes}})
+}
+
+// queues item for ingestion, if the queue is full item is dropped so requests are never slowed down.
+// returns false if item was dropped
+func (m *Module) ingest(item catalogItem) bool {
+	if m.catalog == nil {
+		return false
+	}
+
+	select {
+	case <-m.catalogDone:
+		return false
+	case m.catalog <- item:
+		return true
+	default:
+		log.Printf("catalog queue full, dropping offer")
+		return false
+	}
+}
+
+// Gets an offer detail from the catalog by source provider and external id
+func (m *Module) GetCatalogOffer(source, externalId string) (*model.OfferDetail, error) {
+	return m.Store.GetCatalogOffer(source, externalId)
+}
@@ -0,0 +1,156 @@
+package offer
+
+import (
+	"github.com/guilhebl/go-offer/common/db"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waits until the catalog offer of provider and external id is ingested matching ok, fails on timeout
+func waitForCatalogOffer(t *testing.T, m *Module, partyName, externalId string, ok func(d *model.OfferDetail) bool) *model.OfferDetail {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if d, err := m.GetCatalogOffer(partyName, externalId); err == nil && ok(d) {
+			return d
+		}
+	}
+	t.Fatalf("catalog offer %s %s not ingested", partyName, externalId)
+	return nil
+}
+
+// tests offers of provider search results and details are upserted into the catalog
+func TestCatalogIngestion(t *testing.T) {
+	m, _ := newFakeProviderModule(t, nil)
+	defer m.Stop()
+
+	_, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	waitForCatalogOffer(t, m, model.Walmart, "1", func(d *model.OfferDetail) bool {
+		return d.Offer.Name == "offer skyrim"
+	})
+
+	o := model.NewOffer("1", "1", "", "offer skyrim", model.Walmart, "", "", "", "", 10, 0, 0, time.Now())
+	m.ingestDetail(model.NewOfferDetail(*o, "description", map[string]string{"color": "red"}, nil))
+	waitForCatalogOffer(t, m, model.Walmart, "1", func(d *model.OfferDetail) bool {
+		return d.Description != ""
+	})
+
+	// a new search observation keeps the description of the detail
+	_, err = m.SearchOffers(newSearchRequest("fallout"))
+	assert.Nil(t, err)
+	d := waitForCatalogOffer(t, m, model.Walmart, "1", func(d *model.OfferDetail) bool {
+		return d.Offer.Name == "offer fallout"
+	})
+	assert.Equal(t, "description", d.Description)
+	assert.Equal(t, []model.NameValue{model.NewNameValue("color", "red")}, d.Attributes)
+
+	req := httptest.NewRequest(http.MethodGet, "/catalog/walmart.com/1", nil)
+	w := httptest.NewRecorder()
+	m.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/catalog/walmart.com/2", nil)
+	w = httptest.NewRecorder()
+	m.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// builds a module searching the fake provider of newFakeProviderModule with catalog ingestion disabled
+func newCatalogDisabledModule(t *testing.T) *Module {
+	c := newTestConfig(t)
+	c.SetProperty("catalogEnabled", "false")
+	m := newTestModuleFromConfig(c)
+	m.Config.SetProperty("marketplaceProviders", model.Walmart)
+	m.Providers = ProviderRegistry{model.Walmart: &fakeProvider{name: model.Walmart}}
+	return m
+}
+
+// tests offers are not ingested when catalog is disabled
+func TestCatalogDisabled(t *testing.T) {
+	m := newCatalogDisabledModule(t)
+	defer m.Stop()
+
+	_, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	_, err = m.GetCatalogOffer(model.Walmart, "1")
+	assert.NotNil(t, err)
+}
+
+// refCountingStore counts the calls storing offer refs
+type refCountingStore struct {
+	db.OfferStore
+	puts int32
+}
+
+func (s *refCountingStore) PutOfferRefs(refs []model.OfferRef) error {
+	atomic.AddInt32(&s.puts, 1)
+	return s.OfferStore.PutOfferRefs(refs)
+}
+
+// tests refs of minted offer ids are stored in background once
+func TestOfferRefsStored(t *testing.T) {
+	m, _ := newFakeProviderModule(t, nil)
+	defer m.Stop()
+	store := &refCountingStore{OfferStore: m.Store}
+	m.Store = store
+
+	list, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	id := list.List[0].Id
+
+	var ref *model.OfferRef
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && ref == nil; time.Sleep(10 * time.Millisecond) {
+		ref, _ = store.GetOfferRef(id)
+	}
+	assert.NotNil(t, ref)
+	assert.Equal(t, "1", ref.ExternalId)
+
+	// refs already cached are not stored again
+	_, err = m.SearchOffers(newSearchRequest("fallout"))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&store.puts))
+}
+
+// tests offers can be fetched by id straight after a search when catalog is disabled
+func TestShowOfferByIdCatalogDisabled(t *testing.T) {
+	m := newCatalogDisabledModule(t)
+	defer m.Stop()
+
+	list, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	id := list.List[0].Id
+
+	w := httptest.NewRecorder()
+	m.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/offers/"+id, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), `{"offer":{"id":"`+id+`"`))
+}
+
+// tests offers get the same id on every search and can be fetched by id alone once observed
+func TestShowOfferById(t *testing.T) {
+	m, _ := newFakeProviderModule(t, nil)
+	defer m.Stop()
+
+	list, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	id := list.List[0].Id
+	assert.Equal(t, model.NewOfferId(model.Walmart, "1", model.UnitedStates), id)
+	assert.NotEqual(t, model.NewOfferId(model.Walmart, "1", model.Canada), id)
+
+	list, _ = m.SearchOffers(newSearchRequest("fallout"))
+	assert.Equal(t, id, list.List[0].Id)
+
+	w := httptest.NewRecorder()
+	m.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/offers/"+id, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), `{"offer":{"id":"`+id+`"`))
+
+	w = httptest.NewRecorder()
+	m.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/offers/"+model.NewOfferId(model.Walmart, "2", ""), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,57 @@
+package offer
+
+import (
+	"github.com/guilhebl/go-offer/common/cache"
+	"log"
+	"time"
+)
+
+// fetches the result of key coalescing identical concurrent requests so providers are called only once:
+// requests of this instance share a single call through the flight group and if the cache is shared between
+// instances a short lock makes only one instance call fetch while the others wait for its cached result.
+// fetch calls the providers and stores its result in cache, load reads the result from cache.
+func (m *Module) coalesce(key string, fetch func() interface{}, load func() (interface{}, bool)) interface{} {
+	v, shared := m.flights.Do(key, func() interface{} {
+		return m.fetchLocked(key, fetch, load)
+	})
+	if shared {
+		log.Printf("coalesced request for key %s", key)
+	}
+	return v
+}
+
+// calls fetch holding the shared lock of key, if another instance holds it waits for the result in cache
+// falling back to fetch when the lock can't be used or no result shows up before the lock expires
+func (m *Module) fetchLocked(key string, fetch func() interface{}, load func() (interface{}, bool)) interface{} {
+	locker, ok := m.Cache.(cache.Locker)
+	if !ok {
+		return fetch()
+	}
+
+	ttl := time.Duration(m.Config.GetIntProperty("cacheLockMillis")) * time.Millisecond
+	token, acquired, err := locker.Lock(key, ttl)
+	if err != nil {
+		return fetch()
+	}
+
+	if acquired {
+		defer locker.Unlock(key, token)
+
+		// another instance may have stored the result right before releasing its lock
+		if v, ok := load(); ok {
+			return v
+		}
+		return fetch()
+	}
+
+	poll := time.Duration(m.Config.GetIntProperty("cacheLockPollMillis")) * time.Millisecond
+	for deadline := time.Now().Add(ttl); time.Now().Before(deadline); {
+		time.Sleep(poll)
+		if v, ok := load(); ok {
+			return v
+		}
+	}
+
+	log.Printf("timeout waiting for lock of key %s", key)
+	return fetch()
+}
@@ -0,0 +1,118 @@
+package ebay
+
+// SearchPagedCollection is a page of item summaries returned by item_summary/search
+type SearchPagedCollection struct {
+	Href          string        `json:"href"`
+	Total         int           `json:"total"`
+	Limit         int           `json:"limit"`
+	Offset        int           `json:"offset"`
+	Next          string        `json:"next"`
+	Prev          string        `json:"prev"`
+	ItemSummaries []ItemSummary `json:"itemSummaries"`
+	Warnings      []ErrorData   `json:"warnings"`
+}
+
+// ItemSummary is an item listed in search results
+type ItemSummary struct {
+	ItemId                   string           `json:"itemId"`
+	LegacyItemId             string           `json:"legacyItemId"`
+	Title                    string           `json:"title"`
+	Image                    *Image           `json:"image"`
+	Price                    *Amount          `json:"price"`
+	Condition                string           `json:"condition"`
+	ConditionId              string           `json:"conditionId"`
+	Categories               []Category       `json:"categories"`
+	ItemWebUrl               string           `json:"itemWebUrl"`
+	ItemAffiliateWebUrl      string           `json:"itemAffiliateWebUrl"`
+	ItemLocation             *ItemLocation    `json:"itemLocation"`
+	Seller                   *Seller          `json:"seller"`
+	ShippingOptions          []ShippingOption `json:"shippingOptions"`
+	BuyingOptions            []string         `json:"buyingOptions"`
+	TopRatedBuyingExperience bool             `json:"topRatedBuyingExperience"`
+	Epid                     string           `json:"epid"`
+}
+
+// Items is the list of items returned by getItems
+type Items struct {
+	Total    int         `json:"total"`
+	Items    []Item      `json:"items"`
+	Warnings []ErrorData `json:"warnings"`
+}
+
+// Item is the full listing of an item returned by getItem and getItemByLegacyId
+type Item struct {
+	ItemId                   string           `json:"itemId"`
+	LegacyItemId             string           `json:"legacyItemId"`
+	Title                    string           `json:"title"`
+	ShortDescription         string           `json:"shortDescription"`
+	Image                    *Image           `json:"image"`
+	Price                    *Amount          `json:"price"`
+	Condition                string           `json:"condition"`
+	ConditionId              string           `json:"conditionId"`
+	CategoryPath             string           `json:"categoryPath"`
+	CategoryId               string           `json:"categoryId"`
+	ItemWebUrl               string           `json:"itemWebUrl"`
+	ItemAffiliateWebUrl      string           `json:"itemAffiliateWebUrl"`
+	ItemLocation             *ItemLocation    `json:"itemLocation"`
+	Seller                   *Seller          `json:"seller"`
+	ShippingOptions          []ShippingOption `json:"shippingOptions"`
+	BuyingOptions            []string         `json:"buyingOptions"`
+	TopRatedBuyingExperience bool             `json:"topRatedBuyingExperience"`
+	Brand                    string           `json:"brand"`
+	Mpn                      string           `json:"mpn"`
+	Gtin                     string           `json:"gtin"`
+	Color                    string           `json:"color"`
+	Epid                     string           `json:"epid"`
+	ReviewRating             *ReviewRating    `json:"primaryProductReviewRating"`
+}
+
+// Image is the url of an item picture
+type Image struct {
+	ImageUrl string `json:"imageUrl"`
+}
+
+// Amount is a monetary value in Currency, eBay returns values as strings
+type Amount struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+// Category is the id and name of an eBay category
+type Category struct {
+	CategoryId   string `json:"categoryId"`
+	CategoryName string `json:"categoryName"`
+}
+
+// ItemLocation is where an item ships from
+type ItemLocation struct {
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// Seller is the account selling an item and its feedback
+type Seller struct {
+	Username           string `json:"username"`
+	FeedbackPercentage string `json:"feedbackPercentage"`
+	FeedbackScore      int    `json:"feedbackScore"`
+}
+
+// ShippingOption is a shipping service offered by the seller
+type ShippingOption struct {
+	ShippingCostType string  `json:"shippingCostType"`
+	ShippingCost     *Amount `json:"shippingCost"`
+}
+
+// ReviewRating is the rating of the catalog product of an item
+type ReviewRating struct {
+	AverageRating string `json:"averageRating"`
+	ReviewCount   int    `json:"reviewCount"`
+}
+
+// ErrorData is an error or warning returned by the API
+type ErrorData struct {
+	ErrorId     int    `json:"errorId"`
+	Domain      string `json:"domain"`
+	Category    string `json:"category"`
+	Message     string `json:"message"`
+	LongMessage string `json:"longMessage"`
+}
@@ -0,0 +1,218 @@
+package ebay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Browse API resources
+const (
+	itemSummarySearchPath   = "/item_summary/search"
+	itemPath                = "/item/"
+	itemByLegacyIdPath      = "/item/get_item_by_legacy_id"
+	maxItemIds              = 20
+	marketplaceIdHeader     = "X-EBAY-C-MARKETPLACE-ID"
+	endUserContextHeader    = "X-EBAY-C-ENDUSERCTX"
+	affiliateCampaignIdKey  = "affiliateCampaignId"
+	affiliateReferenceIdKey = "affiliateReferenceId"
+)
+
+// SearchRequest describes the allowed parameters of an item_summary/search request, either Q, Gtin or CategoryIds is required
+type SearchRequest struct {
+	Q           string
+	Gtin        string
+	CategoryIds string
+	Filter      string
+	Sort        string
+	Limit       int
+	Offset      int
+}
+
+// Error is an error returned by the API with the http status of the response
+type Error struct {
+	StatusCode int
+	Errors     []ErrorData
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("ebay error: %d %d - %s", e.StatusCode, e.Errors[0].ErrorId, e.Errors[0].Message)
+	}
+	return fmt.Sprintf("ebay error: %d", e.StatusCode)
+}
+
+// checks if the item requested doesn't exist
+func (e *Error) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// checks if err is an API error that the item was not found
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.IsNotFound()
+}
+
+// Client provides the functions to interact with the Browse API of a marketplace
+type Client struct {
+	endpoint      string
+	marketplaceId string
+	campaignId    string
+	referenceId   string
+	tokens        *TokenSource
+	httpClient    *http.Client
+}
+
+// NewClient returns a new Client querying the marketplace, affiliate campaign and reference ids are optional
+func NewClient(endpoint, marketplaceId, campaignId, referenceId string, tokens *TokenSource, httpClient *http.Client) *Client {
+	return &Client{
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		marketplaceId: marketplaceId,
+		campaignId:    campaignId,
+		referenceId:   referenceId,
+		tokens:        tokens,
+		httpClient:    httpClient,
+	}
+}
+
+// Search performs an item_summary/search request
+func (c *Client) Search(r SearchRequest) (*SearchPagedCollection, error) {
+	q := url.Values{}
+	if r.Q != "" {
+		q.Set("q", r.Q)
+	}
+	if r.Gtin != "" {
+		q.Set("gtin", r.Gtin)
+	}
+	if r.CategoryIds != "" {
+		q.Set("category_ids", r.CategoryIds)
+	}
+	if r.Filter != "" {
+		q.Set("filter", r.Filter)
+	}
+	if r.Sort != "" {
+		q.Set("sort", r.Sort)
+	}
+	if r.Limit > 0 {
+		q.Set("limit", strconv.Itoa(r.Limit))
+	}
+	if r.Offset > 0 {
+		q.Set("offset", strconv.Itoa(r.Offset))
+	}
+
+	var out SearchPagedCollection
+	if err := c.get(itemSummarySearchPath, q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchByGtin searches items of the product with the UPC, EAN or ISBN gtin
+func (c *Client) SearchByGtin(gtin string, limit int) (*SearchPagedCollection, error) {
+	return c.Search(SearchRequest{Gtin: gtin, Limit: limit})
+}
+
+// GetItem performs a getItem request by RESTful item id such as v1|123456789|0
+func (c *Client) GetItem(itemId string) (*Item, error) {
+	var out Item
+	if err := c.get(itemPath+url.PathEscape(itemId), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetItems performs a getItems request by up to maxItemIds RESTful item ids
+func (c *Client) GetItems(itemIds []string) (*Items, error) {
+	q := url.Values{}
+	q.Set("item_ids", strings.Join(itemIds, ","))
+
+	var out Items
+	if err := c.get(itemPath, q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetItemByLegacyId performs a getItemByLegacyId request by the item id of a listing
+func (c *Client) GetItemByLegacyId(legacyItemId string) (*Item, error) {
+	q := url.Values{}
+	q.Set("legacy_item_id", legacyItemId)
+
+	var out Item
+	if err := c.get(itemByLegacyIdPath, q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// sends a GET request to path, a rejected token is refreshed and the request retried once
+func (c *Client) get(path string, q url.Values, out interface{}) error {
+	u := c.endpoint + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	status, contents, err := c.do(u)
+	if err == nil && status == http.StatusUnauthorized {
+		c.tokens.Invalidate()
+		status, contents, err = c.do(u)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		var e struct {
+			Errors []ErrorData `json:"errors"`
+		}
+		json.Unmarshal(contents, &e)
+		return &Error{StatusCode: status, Errors: e.Errors}
+	}
+	return json.Unmarshal(contents, out)
+}
+
+func (c *Client) do(u string) (int, []byte, error) {
+	token, err := c.tokens.Token()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(marketplaceIdHeader, c.marketplaceId)
+	if ctx := c.endUserContext(); ctx != "" {
+		req.Header.Set(endUserContextHeader, ctx)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error on request: %s - error: %s", u, err.Error())
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, contents, nil
+}
+
+// builds the end user context with the affiliate ids so item urls are affiliate links
+func (c *Client) endUserContext() string {
+	ctx := make([]string, 0)
+	if c.campaignId != "" {
+		ctx = append(ctx, affiliateCampaignIdKey+"="+c.campaignId)
+	}
+	if c.referenceId != "" {
+		ctx = append(ctx, affiliateReferenceIdKey+"="+c.referenceId)
+	}
+	return strings.Join(ctx, ",")
+}
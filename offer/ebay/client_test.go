@@ -0,0 +1,159 @@
+package ebay
+
+import (
+	"fmt"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serves tokens numbered by the amount of tokens minted and a search rejecting every token but the latest
+func newBrowseServer(minted *int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "client" || secret != "secret" || r.FormValue("grant_type") != clientCredentialsGrant {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		*minted++
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":7200,"token_type":"Application Access Token"}`, *minted)
+	})
+	mux.HandleFunc("/item_summary/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token%d", *minted) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"total":1,"limit":1,"offset":0,"itemSummaries":[{"itemId":"v1|1|0","legacyItemId":"1","title":"%s"}]}`, r.Header.Get(marketplaceIdHeader))
+	})
+	mux.HandleFunc("/item/", func(w http.ResponseWriter, r *http.Request) {
+		ids := strings.Split(r.URL.Query().Get("item_ids"), ",")
+		items := make([]string, 0, len(ids))
+		for i, id := range ids {
+			items = append(items, fmt.Sprintf(`{"itemId":"%s","gtin":"%012d"}`, id, i))
+		}
+		fmt.Fprintf(w, `{"total":%d,"items":[%s]}`, len(items), strings.Join(items, ","))
+	})
+	mux.HandleFunc("/item/get_item_by_legacy_id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors":[{"errorId":11001,"domain":"API_BROWSE","category":"REQUEST","message":"The specified item Id was not found."}]}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+// tests tokens are cached until they are about to expire
+func TestTokenSource(t *testing.T) {
+	minted := 0
+	server := newBrowseServer(&minted)
+	defer server.Close()
+
+	now := time.Now()
+	tokens := NewTokenSource(server.URL+"/token", "client", "secret", "scope", 5*time.Minute, server.Client())
+	tokens.now = func() time.Time { return now }
+
+	token, err := tokens.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "token1", token)
+
+	token, _ = tokens.Token()
+	assert.Equal(t, "token1", token)
+
+	// within the refresh margin of expiry
+	now = now.Add(116 * time.Minute)
+	token, _ = tokens.Token()
+	assert.Equal(t, "token2", token)
+	assert.Equal(t, 2, minted)
+
+	_, err = NewTokenSource(server.URL+"/token", "client", "wrong", "scope", 0, server.Client()).Token()
+	assert.NotNil(t, err)
+}
+
+// tests a rejected token is refreshed and the request retried, and API errors are mapped
+func TestClient(t *testing.T) {
+	minted := 0
+	server := newBrowseServer(&minted)
+	defer server.Close()
+
+	tokens := NewTokenSource(server.URL+"/token", "client", "secret", "scope", 0, server.Client())
+	client := NewClient(server.URL, "EBAY_CA", "", "", tokens, server.Client())
+
+	r, err := client.Search(SearchRequest{Q: "skyrim", Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "EBAY_CA", r.ItemSummaries[0].Title)
+
+	// token revoked by eBay
+	minted++
+	r, err = client.Search(SearchRequest{Q: "skyrim", Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, r.Total)
+	assert.Equal(t, 3, minted)
+
+	_, err = client.GetItemByLegacyId("1")
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, 11001, err.(*Error).Errors[0].ErrorId)
+}
+
+// tests gtins of search results are fetched in batches of maxItemIds
+func TestGetGtins(t *testing.T) {
+	minted := 0
+	server := newBrowseServer(&minted)
+	defer server.Close()
+
+	tokens := NewTokenSource(server.URL+"/token", "client", "secret", "scope", 0, server.Client())
+	client := NewClient(server.URL, "EBAY_US", "", "", tokens, server.Client())
+
+	items := make([]ItemSummary, 25)
+	for i := range items {
+		items[i].ItemId = fmt.Sprintf("v1|%d|0", i)
+	}
+
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	m := monitor.NewRequestMonitor(c)
+	repo := &Repo{config: c, monitor: m}
+
+	gtins := repo.getGtins(client, items)
+	assert.Equal(t, 25, len(gtins))
+	assert.Equal(t, "000000000000", gtins["v1|0|0"])
+	assert.Equal(t, "000000000004", gtins["v1|24|0"])
+
+	// batches are skipped once eBay calls are throttled by the request monitor
+	m.Register(model.Ebay, 60000)
+	assert.True(t, m.IsServiceAvailable(model.Ebay))
+	assert.Empty(t, repo.getGtins(client, items))
+}
+
+// tests condition, seller, shipping and gtin are mapped into offers
+func TestBuildOffer(t *testing.T) {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	repo := &Repo{config: c}
+	item := Item{
+		LegacyItemId:    "1",
+		Gtin:            "065857174434",
+		Price:           &Amount{Value: "25.50", Currency: "USD"},
+		Condition:       "Used",
+		CategoryPath:    "Video Games & Consoles|Video Games",
+		Seller:          &Seller{Username: "seller1", FeedbackPercentage: "99.5", FeedbackScore: 120},
+		ShippingOptions: []ShippingOption{{ShippingCostType: "FIXED", ShippingCost: &Amount{Value: "4.99"}}, {ShippingCostType: "FIXED", ShippingCost: &Amount{Value: "0.0"}}},
+		ReviewRating:    &ReviewRating{AverageRating: "4.5", ReviewCount: 10},
+	}
+
+	o := repo.buildItemOffer(&item, false)
+	assert.Equal(t, "065857174434", o.Upc)
+	assert.Equal(t, float32(25.5), o.Price)
+	assert.Equal(t, "Used", o.Condition)
+	assert.Equal(t, "seller1", o.Seller)
+	assert.Equal(t, float32(0), *o.ShippingCost)
+	assert.Equal(t, "Video Games", o.ProductCategory)
+	assert.Equal(t, float32(4.5), o.Rating)
+	assert.Equal(t, 10, o.NumReviews)
+
+	assert.Nil(t, shippingCost([]ShippingOption{{ShippingCostType: "CALCULATED", ShippingCost: &Amount{Value: "9.99"}}}))
+}
@@ -6,11 +6,13 @@ import (
 )
 
 // Executable Task implementation for get detail
-type GetDetailTask struct{}
+type GetDetailTask struct {
+	repo *Repo
+}
 
 func (t *GetDetailTask) Run(payload job.Payload) job.JobResult {
 	m := payload.Params
-	r := GetOfferDetail(m["id"], m["idType"], m["country"])
+	r := t.repo.GetOfferDetail(m["id"], m["idType"], m["country"])
 	if r == nil {
 		return job.NewJobResult(nil, errors.New("error on search"))
 	}
@@ -18,6 +20,6 @@ func (t *GetDetailTask) Run(payload job.Payload) job.JobResult {
 	return job.NewJobResult(r, nil)
 }
 
-func NewGetDetailTask() GetDetailTask {
-	return GetDetailTask{}
+func NewGetDetailTask(repo *Repo) GetDetailTask {
+	return GetDetailTask{repo: repo}
 }
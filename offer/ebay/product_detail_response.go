@@ -1,5 +0,0 @@
-package ebay
-
-type ProductDetailResponse struct {
-	FindItemsByProductResponse []SearchResponseResult `json:"findItemsByProductResponse"`
-}
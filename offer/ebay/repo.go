@@ -1,11 +1,9 @@
 package ebay
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
-	"github.com/guilhebl/go-offer/common/util"
 	"github.com/guilhebl/go-offer/offer/monitor"
 	"github.com/guilhebl/go-worker-pool"
 	"log"
@@ -16,183 +14,279 @@ import (
 	"time"
 )
 
+// items per search page when eBayDefaultPageSize is not set
+const defaultPageSize = 10
+
+// prefix of RESTful item ids, other ids are legacy item ids
+const restItemIdPrefix = "v1|"
+
+// Repo is the Ebay marketplace provider, it holds the dependencies required to query the Ebay API
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+	tokens  *TokenSource
+}
+
+// builds a new Ebay provider using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	margin := c.GetIntProperty("eBayTokenRefreshMarginSeconds")
+	tokens := NewTokenSource(
+		c.GetProperty("eBayOAuthTokenUrl"),
+		c.GetProperty("eBayClientId"),
+		c.GetProperty("eBayClientSecret"),
+		c.GetProperty("eBayOAuthScope"),
+		time.Duration(margin)*time.Second,
+		client,
+	)
+
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		tokens:  tokens,
+	}
+}
+
 // Creates Job for Searching offers from Ebay and returns a Channel with jobResults
-func SearchOffers(m map[string]string) *job.Job {
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
 	// create output channel
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewSearchTask()
+	task := NewSearchTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
 // Searches for offers from ebay
-func search(m map[string]string) *model.OfferList {
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.Ebay) {
+	if !repo.monitor.IsServiceAvailable(model.Ebay) {
 		log.Printf("Unable to acquire lock from Request Monitor")
-		return nil
+		return nil, monitor.ErrThrottled
 	}
 
 	// format vendor specific params
-	p := filterParams(m)
-
-	endpoint := config.GetProperty("eBayEndpoint")
-	path := config.GetProperty("eBayProductSearchPath")
-	pageSize := config.GetProperty("eBayDefaultPageSize")
-	securityAppName := config.GetProperty("eBaySecurityAppName")
-	defaultDataFormat := config.GetProperty("eBayDefaultDataFormat")
-	affiliateNetworkId := config.GetProperty("eBayAffiliateNetworkId")
-	affiliateTrackingId := config.GetProperty("eBayAffiliateTrackingId")
-	affiliateCustomId := config.GetProperty("ebayAffiliateCustomId")
-	timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
-
-	url := fmt.Sprintf("%s/%s", endpoint, path)
-
-	req, err := http.NewRequest("GET", url, nil)
-	req.Header.Set("Accept", "application/json")
-	q := req.URL.Query()
-	q.Add("OPERATION-NAME", "findItemsByKeywords")
-	q.Add("SERVICE-VERSION", "1.0.0")
-	q.Add("SECURITY-APPNAME", securityAppName)
-	q.Add("GLOBAL-ID", getGlobalId(p[model.Country]))
-	q.Add("RESPONSE-DATA-FORMAT", defaultDataFormat)
-	q.Add("affiliate.networkId", affiliateNetworkId)
-	q.Add("affiliate.trackingId", affiliateTrackingId)
-	q.Add("affiliate.customId", affiliateCustomId)
-	q.Add("outputSelector", "PictureURLLarge") // add large picture to standard result
-	q.Add("paginationInput.pageNumber", p[model.Page])
-	q.Add("paginationInput.entriesPerPage", pageSize)
-	q.Add(model.Keywords, p[model.Keywords])
-
-	req.URL.RawQuery = q.Encode()
-	url = fmt.Sprintf(req.URL.String())
-
-	client := &http.Client{
-		Timeout: timeout,
+	p := repo.filterParams(m)
+	page, err := strconv.Atoi(p[model.Page])
+	if err != nil || page < 1 {
+		log.Printf("format page error: %s", p[model.Page])
+		return nil, errors.New(model.InvalidRequest)
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatal("Do: ", err)
-		return nil
+	pageSize := repo.config.GetIntProperty("eBayDefaultPageSize")
+	if pageSize < 1 {
+		pageSize = defaultPageSize
 	}
-	defer resp.Body.Close()
 
-	var entity SearchResponse
-
-	if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
-		log.Println(err)
-		return nil
+	query := SearchRequest{
+		Q:      p[model.Keywords],
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+	client := repo.newClient(p[model.Country])
+	r, err := client.Search(query)
+	if err != nil {
+		log.Printf("%s", err.Error())
+		return nil, err
 	}
-	return buildSearchResponse(&entity)
+
+	return repo.buildSearchResponse(r, repo.getGtins(client, r.ItemSummaries)), nil
 }
 
-// get Ebay global market Id
-func getGlobalId(country string) string {
-	switch country {
+// gets the gtins of items by item id, search results don't carry them so the items are fetched in batches
+// of maxItemIds. every batch acquires the request monitor, items of a failed or throttled batch have no gtin
+func (repo *Repo) getGtins(client *Client, items []ItemSummary) map[string]string {
+	gtins := make(map[string]string)
+	for i := 0; i < len(items); i += maxItemIds {
+		if !repo.monitor.IsServiceAvailable(model.Ebay) {
+			log.Printf("Unable to acquire lock from Request Monitor, items fetched without gtins")
+			break
+		}
 
-	//Canada
-	case model.Canada:
-		{
-			return "EBAY-ENCA"
+		ids := make([]string, 0, maxItemIds)
+		for j := i; j < len(items) && j < i+maxItemIds; j++ {
+			ids = append(ids, items[j].ItemId)
 		}
-	default:
-		{
-			return "EBAY-US"
+
+		r, err := client.GetItems(ids)
+		if err != nil {
+			log.Printf("error: %s", err)
+			continue
+		}
+		for _, item := range r.Items {
+			if item.Gtin != "" {
+				gtins[item.ItemId] = item.Gtin
+			}
 		}
 	}
+	return gtins
 }
 
-// builds Offer list response mapping from vendor specific params
-func buildSearchResponse(r *SearchResponse) *model.OfferList {
-	if len(r.FindItemsByKeywordsResponse) == 0 || len(r.FindItemsByKeywordsResponse[0].PaginationOutput) == 0 {
-		return nil
-	}
-
-	head := r.FindItemsByKeywordsResponse[0]
-	pg := head.PaginationOutput[0]
+// builds a Browse API client for the marketplace of country sharing the repo token cache
+func (repo *Repo) newClient(country string) *Client {
+	endpoint := repo.config.GetProperty("eBayEndpoint")
+	campaignId := repo.config.GetProperty("eBayAffiliateCampaignId")
+	referenceId := repo.config.GetProperty("eBayAffiliateReferenceId")
+	return NewClient(endpoint, getMarketplaceId(country), campaignId, referenceId, repo.tokens, repo.client)
+}
 
-	page, err := strconv.Atoi(pg.PageNumber[0])
-	if err != nil {
-		log.Println(err)
-		return nil
-	}
+// eBay countries with a Browse API marketplace by ISO alpha-2 code
+var marketplaceCountries = map[string]bool{
+	"AT": true, "AU": true, "BE": true, "CA": true, "CH": true, "DE": true, "ES": true, "FR": true,
+	"GB": true, "HK": true, "IE": true, "IT": true, "NL": true, "PL": true, "SG": true, "US": true,
+}
 
-	totalPages, err := strconv.Atoi(pg.TotalPages[0])
-	if err != nil {
-		log.Println(err)
-		return nil
+// get Ebay marketplace Id of country, countries without a marketplace default to EBAY_US
+func getMarketplaceId(country string) string {
+	if c := model.CountryAlpha2(country); marketplaceCountries[c] {
+		return "EBAY_" + c
 	}
+	return "EBAY_US"
+}
 
-	total, err := strconv.Atoi(pg.TotalEntries[0])
-	if err != nil {
-		log.Println(err)
-		return nil
+// builds Offer list response mapping from vendor specific params, gtins holds the upc of items by item id
+func (repo *Repo) buildSearchResponse(r *SearchPagedCollection, gtins map[string]string) *model.OfferList {
+	page, totalPages := 1, 0
+	if r.Limit > 0 {
+		page = r.Offset/r.Limit + 1
+		totalPages = (r.Total + r.Limit - 1) / r.Limit
 	}
 
-	list := buildSearchItemList(head.SearchResult[0].Item)
-	o := model.NewOfferList(list, page, totalPages, total)
+	list := repo.buildSearchItemList(r.ItemSummaries, gtins)
+	o := model.NewOfferList(list, page, totalPages, r.Total)
 	return o
 }
 
-func buildSearchItemList(items []SearchItem) []model.Offer {
+func (repo *Repo) buildSearchItemList(items []ItemSummary, gtins map[string]string) []model.Offer {
 	list := make([]model.Offer, 0)
-	proxyRequired := config.IsProxyRequired(model.Ebay)
+	proxyRequired := repo.config.IsProxyRequired(model.Ebay)
 
 	for _, item := range items {
-		o := buildOffer(&item, proxyRequired)
+		o := repo.buildOffer(&item, gtins[item.ItemId], proxyRequired)
 		list = append(list, *o)
 	}
 
 	return list
 }
 
-func buildOffer(item *SearchItem, proxyRequired bool) *model.Offer {
-	price, err := strconv.ParseFloat(item.SellingStatus[0].ConvertedCurrentPrice[0].Value, 32)
-	if err != nil {
-		log.Printf("error on parsing price for item string: %v", item)
-		price = 0.0
+// builds an offer from an item summary, item summaries don't carry the gtin so upc is set when it is known
+func (repo *Repo) buildOffer(item *ItemSummary, upc string, proxyRequired bool) *model.Offer {
+	category := ""
+	if len(item.Categories) > 0 {
+		category = item.Categories[0].CategoryName
 	}
 
-	id := ""
-	if len(item.ProductID) > 0 {
-		id = item.ProductID[0].Value
-	} else if len(item.ItemID) > 0 {
-		id = item.ItemID[0]
-	}
+	o := model.NewOffer(
+		"",
+		item.LegacyItemId,
+		upc,
+		item.Title,
+		model.Ebay,
+		itemUrl(item.ItemAffiliateWebUrl, item.ItemWebUrl),
+		repo.config.BuildImgUrlExternal(imageUrl(item.Image), proxyRequired),
+		repo.config.BuildImgUrl("ebay-logo.png"),
+		category,
+		parseAmount(item.Price),
+		0.0,
+		0,
+		time.Now(),
+	)
+	o.Condition = item.Condition
+	o.Seller = sellerName(item.Seller)
+	o.ShippingCost = shippingCost(item.ShippingOptions)
+	return o
+}
 
-	url := ""
-	if len(item.ViewItemURL) > 0 {
-		url = item.ViewItemURL[0]
+// builds an offer from a full item listing
+func (repo *Repo) buildItemOffer(item *Item, proxyRequired bool) *model.Offer {
+	rating, numReviews := float32(0.0), 0
+	if item.ReviewRating != nil {
+		if r, err := strconv.ParseFloat(item.ReviewRating.AverageRating, 32); err == nil {
+			rating = float32(r)
+		}
+		numReviews = item.ReviewRating.ReviewCount
 	}
 
-	imgUrl := ""
-	if len(item.PictureURLLarge) > 0 {
-		imgUrl = item.PictureURLLarge[0]
+	// category path is the full path separated by '|', the last one is the item category
+	category := item.CategoryPath
+	if i := strings.LastIndex(category, "|"); i >= 0 {
+		category = category[i+1:]
 	}
 
 	o := model.NewOffer(
-		util.GenerateStringUUID(),
-		id,
 		"",
-		strings.Join(item.Title, ""),
+		item.LegacyItemId,
+		item.Gtin,
+		item.Title,
 		model.Ebay,
-		url,
-		config.BuildImgUrlExternal(imgUrl, proxyRequired),
-		config.BuildImgUrl("ebay-logo.png"),
-		strings.Join(item.PrimaryCategory[0].CategoryName, ""),
-		float32(price),
-		0.0,
-		0,
+		itemUrl(item.ItemAffiliateWebUrl, item.ItemWebUrl),
+		repo.config.BuildImgUrlExternal(imageUrl(item.Image), proxyRequired),
+		repo.config.BuildImgUrl("ebay-logo.png"),
+		category,
+		parseAmount(item.Price),
+		rating,
+		numReviews,
 		time.Now(),
 	)
+	o.Condition = item.Condition
+	o.Seller = sellerName(item.Seller)
+	o.ShippingCost = shippingCost(item.ShippingOptions)
 	return o
 }
 
+// prefers the affiliate url of an item if the affiliate campaign is configured
+func itemUrl(affiliateUrl, url string) string {
+	if affiliateUrl != "" {
+		return affiliateUrl
+	}
+	return url
+}
+
+func imageUrl(img *Image) string {
+	if img == nil {
+		return ""
+	}
+	return img.ImageUrl
+}
+
+func parseAmount(a *Amount) float32 {
+	if a == nil {
+		return 0.0
+	}
+	v, err := strconv.ParseFloat(a.Value, 32)
+	if err != nil {
+		log.Printf("error on parsing amount: %s", a.Value)
+		return 0.0
+	}
+	return float32(v)
+}
+
+func sellerName(s *Seller) string {
+	if s == nil {
+		return ""
+	}
+	return s.Username
+}
+
+// returns the cheapest fixed shipping cost, nil when shipping is calculated at checkout or unknown
+func shippingCost(options []ShippingOption) *float32 {
+	var cost *float32
+	for _, opt := range options {
+		if opt.ShippingCost == nil || opt.ShippingCostType == "CALCULATED" {
+			continue
+		}
+		c := parseAmount(opt.ShippingCost)
+		if cost == nil || c < *cost {
+			cost = &c
+		}
+	}
+	return cost
+}
+
 // filters vendor specific params from generic offer model params
-func filterParams(m map[string]string) map[string]string {
+func (repo *Repo) filterParams(m map[string]string) map[string]string {
 	p := make(map[string]string)
 
 	// get search keyword phrase
@@ -200,7 +294,7 @@ func filterParams(m map[string]string) map[string]string {
 		p[model.Keywords] = m[model.Name]
 	} else {
 		// ebay does not have a trending api so we need to fetch random query searches
-		p[model.Keywords] = getRandomSearchQuery()
+		p[model.Keywords] = repo.getRandomSearchQuery()
 	}
 
 	// get page - defaults to 1
@@ -220,15 +314,15 @@ func filterParams(m map[string]string) map[string]string {
 }
 
 // gets a random string inside an array of strings of queries
-func getRandomSearchQuery() string {
-	query := config.GetProperty("eBayDefaultSearchQuery")
+func (repo *Repo) getRandomSearchQuery() string {
+	query := repo.config.GetProperty("eBayDefaultSearchQuery")
 	keywords := strings.Split(query, ",")
 	i := rand.Intn(len(keywords))
 	return keywords[i]
 }
 
 // Creates Job for fetching Product Detail and returns a Channel with jobResult
-func GetDetailJob(id, idType, country string) *job.Job {
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
 	// convert to map for job to consume
 	m := make(map[string]string)
 	m["id"], m["idType"], m["country"] = id, idType, country
@@ -237,110 +331,75 @@ func GetDetailJob(id, idType, country string) *job.Job {
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewGetDetailTask()
+	task := NewGetDetailTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
-// filters Id Type for this vendor
-func getIdTypeVendor(idType string) string {
-	switch idType {
-	case model.Id:
-		return "ReferenceID"
-	case model.Upc:
-		return "UPC"
-	default:
-		return ""
-	}
-}
-
 // Search for a specific product detail either by Id or Upc
-func GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
 	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
 
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.Ebay) {
+	if !repo.monitor.IsServiceAvailable(model.Ebay) {
 		log.Printf("Unable to acquire lock from Request Monitor")
 		return nil
 	}
 
-	if idType == model.Id || idType == model.Upc {
-		idTypeVendor := getIdTypeVendor(idType)
-		endpoint := config.GetProperty("eBayEndpoint")
-		path := config.GetProperty("eBayProductSearchPath")
-		securityAppName := config.GetProperty("eBaySecurityAppName")
-		defaultDataFormat := config.GetProperty("eBayDefaultDataFormat")
-		affiliateNetworkId := config.GetProperty("eBayAffiliateNetworkId")
-		affiliateTrackingId := config.GetProperty("eBayAffiliateTrackingId")
-		affiliateCustomId := config.GetProperty("ebayAffiliateCustomId")
-		timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
-
-		url := fmt.Sprintf("%s/%s", endpoint, path)
-		req, err := http.NewRequest("GET", url, nil)
-		req.Header.Set("Accept", "application/json")
-		q := req.URL.Query()
-
-		q.Add("OPERATION-NAME", "findItemsByProduct")
-		q.Add("SERVICE-VERSION", "1.0.0")
-		q.Add("SECURITY-APPNAME", securityAppName)
-		q.Add("GLOBAL-ID", getGlobalId(country))
-		q.Add("RESPONSE-DATA-FORMAT", defaultDataFormat)
-		q.Add("affiliate.networkId", affiliateNetworkId)
-		q.Add("affiliate.trackingId", affiliateTrackingId)
-		q.Add("affiliate.customId", affiliateCustomId)
-		q.Add("outputSelector", "PictureURLLarge") // add large picture to standard result
-		q.Add("productId.@type", idTypeVendor)
-		q.Add("productId", id)
-		q.Add("paginationInput.entriesPerPage", "1")
-
-		req.URL.RawQuery = q.Encode()
-		url = fmt.Sprintf(req.URL.String())
-		log.Printf("Ebay get: %s", url)
-
-		client := &http.Client{
-			Timeout: timeout,
-		}
+	client := repo.newClient(country)
+	proxyRequired := repo.config.IsProxyRequired(model.Ebay)
 
-		resp, err := client.Do(req)
+	switch idType {
+	case model.Id:
+		var item *Item
+		var err error
+		if strings.HasPrefix(id, restItemIdPrefix) {
+			item, err = client.GetItem(id)
+		} else {
+			item, err = client.GetItemByLegacyId(id)
+		}
 		if err != nil {
-			log.Fatal("Do: ", err)
+			if !IsNotFound(err) {
+				log.Printf("error: %s", err)
+			}
 			return nil
 		}
-		defer resp.Body.Close()
-
-		var entity ProductDetailResponse
+		return repo.buildProductDetail(item, proxyRequired)
 
-		if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
-			log.Println(err)
+	case model.Upc:
+		r, err := client.SearchByGtin(id, 1)
+		if err != nil {
+			log.Printf("error: %s", err)
+			return nil
+		}
+		if len(r.ItemSummaries) == 0 {
 			return nil
 		}
-		return buildProductDetailResponse(&entity)
+		o := repo.buildOffer(&r.ItemSummaries[0], id, proxyRequired)
+		return model.NewOfferDetail(*o, "", make(map[string]string), make([]model.OfferDetailItem, 0))
 	}
 
 	return nil
 }
 
-func buildProductDetail(item *SearchItem) *model.OfferDetail {
-	proxyRequired := config.IsProxyRequired(model.Ebay)
-	o := buildOffer(item, proxyRequired)
+func (repo *Repo) buildProductDetail(item *Item, proxyRequired bool) *model.OfferDetail {
+	o := repo.buildItemOffer(item, proxyRequired)
 
 	attrs := make(map[string]string)
+	if item.Brand != "" {
+		attrs[model.Brand] = item.Brand
+	}
+	if item.Mpn != "" {
+		attrs[model.Model] = item.Mpn
+	}
 	detItems := make([]model.OfferDetailItem, 0)
 
 	det := model.NewOfferDetail(
 		*o,
-		"",
+		item.ShortDescription,
 		attrs,
 		detItems,
 	)
 
 	return det
 }
-
-func buildProductDetailResponse(item *ProductDetailResponse) *model.OfferDetail {
-	if item == nil || len(item.FindItemsByProductResponse) == 0 || len(item.FindItemsByProductResponse[0].SearchResult) == 0 {
-		return nil
-	}
-	p := item.FindItemsByProductResponse[0].SearchResult[0].Item[0]
-	return buildProductDetail(&p)
-}
@@ -1,5 +0,0 @@
-package ebay
-
-type SearchResponse struct {
-	FindItemsByKeywordsResponse []SearchResponseResult `json:"findItemsByKeywordsResponse"`
-}
@@ -0,0 +1,107 @@
+package ebay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// grant type used to mint application access tokens
+const clientCredentialsGrant = "client_credentials"
+
+// TokenSource mints OAuth application access tokens with the client credentials grant and caches them until
+// they are about to expire
+type TokenSource struct {
+	tokenUrl     string
+	clientId     string
+	clientSecret string
+	scope        string
+	margin       time.Duration
+	client       *http.Client
+	now          func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// response of the OAuth token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// builds a token source refreshing tokens margin before they expire
+func NewTokenSource(tokenUrl, clientId, clientSecret, scope string, margin time.Duration, client *http.Client) *TokenSource {
+	return &TokenSource{
+		tokenUrl:     tokenUrl,
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		scope:        scope,
+		margin:       margin,
+		client:       client,
+		now:          time.Now,
+	}
+}
+
+// returns the cached token or mints a new one if there is none or it is about to expire
+func (s *TokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Add(s.margin).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	t, err := s.fetch()
+	if err != nil {
+		return "", err
+	}
+	s.token = t.AccessToken
+	s.expiry = s.now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// drops the cached token so the next call to Token mints a new one, used when a token is rejected
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	s.token = ""
+	s.mu.Unlock()
+}
+
+func (s *TokenSource) fetch() (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", clientCredentialsGrant)
+	form.Set("scope", s.scope)
+
+	req, err := http.NewRequest(http.MethodPost, s.tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.clientId, s.clientSecret)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ebay token error: %d", resp.StatusCode)
+	}
+
+	var t tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+	if t.AccessToken == "" {
+		return nil, fmt.Errorf("ebay token error: empty access token")
+	}
+	return &t, nil
+}
@@ -0,0 +1,269 @@
+package offer
+
+import (
+	"fmt"
+	"github.com/guilhebl/go-offer/common/model"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Facets are computed over the merged offers of a search: provider, product category, brand, price buckets of
+// facetPriceBuckets and rating buckets. Other facets returned by providers are merged in.
+//
+// The values of a facet selected in the search columns filter the offers, e.g. {"name":"price","value":"25-50"}.
+// Several values of a facet are separated by | and any of them matches, offers match every facet selected.
+// Providers selected are the only ones searched, so the provider facet counts only them. Brands selected are also
+// sent to providers refining their results by brand. Native facets are applied by providers themselves, so once
+// selected only offers of providers refining their results by them are kept. Native facets named like computed ones,
+// e.g. the Walmart price ranges and brands of its whole result set, are listed as {provider}:{name} and can't be selected.
+//
+// Total and page counts are the ones of the providers, they're not narrowed by the facets applied to the merged page.
+var offerFacets = []string{model.PartyName, model.Category, model.Brand, model.Price, model.Rating}
+
+// computed facets sent to providers along with the search, the provider pages depend on them
+var providerFacets = map[string]bool{model.Brand: true}
+
+// rating buckets, offers are counted in every bucket up to their rating
+var ratingBuckets = []string{"4+", "3+", "2+", "1+"}
+
+const facetValueSeparator = "|"
+
+// separates the provider from the name of native facets named like computed ones
+const nativeFacetSeparator = ":"
+
+// checks if name is a facet computed over offers, its filter is applied locally
+func isOfferFacet(name string) bool {
+	for _, f := range offerFacets {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// records the native facets of a provider search page by name, facets of page named like computed ones are
+// renamed {provider}:{name} so they're merged apart from computed facets, it must be called before page is merged
+func addNativeFacets(natives map[string]map[string]bool, provider string, page *model.OfferList) {
+	if page == nil {
+		return
+	}
+	for i, f := range page.Facets {
+		if isOfferFacet(f.Name) {
+			page.Facets[i].Name = provider + nativeFacetSeparator + f.Name
+			continue
+		}
+		if natives[f.Name] == nil {
+			natives[f.Name] = make(map[string]bool)
+		}
+		natives[f.Name][provider] = true
+	}
+}
+
+// returns the facet values selected in search params, natives are the facets returned by providers
+func facetFilters(params map[string]string, natives map[string]map[string]bool) map[string][]string {
+	filters := make(map[string][]string)
+	for name, value := range params {
+		if value == "" || (!isOfferFacet(name) && natives[name] == nil) {
+			continue
+		}
+		filters[name] = strings.Split(value, facetValueSeparator)
+	}
+	return filters
+}
+
+// filters the merged offers of a search by the facet values selected in params and computes the facets of list,
+// the counts of a facet are computed over the offers matching the other facets selected so any of its values can
+// be added to the selection. natives holds the providers of each native facet returned.
+func (m *Module) applyFacets(list *model.OfferList, params map[string]string, natives map[string]map[string]bool) {
+	filters := facetFilters(params, natives)
+
+	facets := make([]model.Facet, 0, len(offerFacets)+len(list.Facets))
+	for _, name := range offerFacets {
+		offers := filterOffers(list.List, filters, natives, name)
+
+		var values []model.FacetValue
+		switch name {
+		case model.Price:
+			values = countBuckets(offers, m.priceBuckets(), func(o *model.Offer) float32 { return o.Price })
+		case model.Rating:
+			values = countBuckets(offers, ratingBuckets, func(o *model.Offer) float32 { return o.Rating })
+		default:
+			values = countValues(offers, func(o *model.Offer) string { return offerFacetValue(o, name) })
+		}
+
+		if len(values) > 0 {
+			facets = append(facets, model.NewFacet(name, values))
+		}
+	}
+
+	for _, f := range list.Facets {
+		if !isOfferFacet(f.Name) && len(f.Values) > 0 {
+			facets = append(facets, f)
+		}
+	}
+
+	if len(filters) > 0 {
+		list.List = filterOffers(list.List, filters, natives, "")
+	}
+	if len(facets) > 0 {
+		list.Facets = facets
+	} else {
+		list.Facets = nil
+	}
+}
+
+// returns the offers matching every facet filter but the one of skip
+func filterOffers(offers []model.Offer, filters map[string][]string, natives map[string]map[string]bool, skip string) []model.Offer {
+	list := make([]model.Offer, 0, len(offers))
+	for _, o := range offers {
+		match := true
+		for name, values := range filters {
+			if name != skip && !matchesFacet(&o, name, values, natives) {
+				match = false
+				break
+			}
+		}
+		if match {
+			list = append(list, o)
+		}
+	}
+	return list
+}
+
+// checks if offer matches any of the values of a facet, offers match native facets applied by their provider
+func matchesFacet(o *model.Offer, name string, values []string, natives map[string]map[string]bool) bool {
+	if !isOfferFacet(name) {
+		return natives[name][o.PartyName]
+	}
+
+	for _, v := range values {
+		switch name {
+		case model.Price:
+			if inBucket(o.Price, v) {
+				return true
+			}
+		case model.Rating:
+			if inBucket(o.Rating, v) {
+				return true
+			}
+		default:
+			if offerFacetValue(o, name) == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returns the value of offer of a facet counted by value
+func offerFacetValue(o *model.Offer, name string) string {
+	switch name {
+	case model.PartyName:
+		return o.PartyName
+	case model.Category:
+		return o.ProductCategory
+	case model.Brand:
+		return o.Brand
+	}
+	return ""
+}
+
+// returns the providers selected in the provider facet of params, all providers if none is selected
+func selectProviders(providers []string, params map[string]string) []string {
+	if params[model.PartyName] == "" {
+		return providers
+	}
+
+	selected := make([]string, 0, len(providers))
+	for _, p := range providers {
+		for _, v := range strings.Split(params[model.PartyName], facetValueSeparator) {
+			if p == v {
+				selected = append(selected, p)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// counts the offers of each value sorted by count then value, offers without value are not counted
+func countValues(offers []model.Offer, value func(o *model.Offer) string) []model.FacetValue {
+	counts := make(map[string]int)
+	for i := range offers {
+		if v := value(&offers[i]); v != "" {
+			counts[v]++
+		}
+	}
+
+	values := make([]model.FacetValue, 0, len(counts))
+	for v, c := range counts {
+		values = append(values, model.FacetValue{Value: v, Count: c})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
+
+// counts the offers of each bucket in bucket order, empty buckets are left out
+func countBuckets(offers []model.Offer, buckets []string, value func(o *model.Offer) float32) []model.FacetValue {
+	values := make([]model.FacetValue, 0, len(buckets))
+	for _, b := range buckets {
+		count := 0
+		for i := range offers {
+			if inBucket(value(&offers[i]), b) {
+				count++
+			}
+		}
+		if count > 0 {
+			values = append(values, model.FacetValue{Value: b, Count: count})
+		}
+	}
+	return values
+}
+
+// checks if v is within bucket: "low-high" holds values from low up to high, "low+" holds values from low
+func inBucket(v float32, bucket string) bool {
+	low, high, ok := parseBucket(bucket)
+	return ok && float64(v) >= low && float64(v) < high
+}
+
+func parseBucket(bucket string) (float64, float64, bool) {
+	if strings.HasSuffix(bucket, "+") {
+		low, err := strconv.ParseFloat(strings.TrimSuffix(bucket, "+"), 64)
+		return low, math.Inf(1), err == nil
+	}
+
+	bounds := strings.SplitN(bucket, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, false
+	}
+	low, err := strconv.ParseFloat(bounds[0], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	high, err := strconv.ParseFloat(bounds[1], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return low, high, true
+}
+
+// returns the price buckets of the bounds listed in facetPriceBuckets, e.g. 25,50 buckets 0-25, 25-50 and 50+
+func (m *Module) priceBuckets() []string {
+	buckets := make([]string, 0)
+	low := "0"
+	for _, bound := range strings.Split(m.Config.GetProperty("facetPriceBuckets"), ",") {
+		if bound = strings.TrimSpace(bound); bound == "" {
+			continue
+		}
+		buckets = append(buckets, fmt.Sprintf("%s-%s", low, bound))
+		low = bound
+	}
+	return append(buckets, low+"+")
+}
@@ -0,0 +1,136 @@
+package offer
+
+import (
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newFacetOffer(id, party, category, brand string, price, rating float32) model.Offer {
+	o := model.NewOffer("", id, "", "offer "+id, party, "", "", "", category, price, rating, 0, time.Now())
+	o.Brand = brand
+	return *o
+}
+
+func newFacetList() (*model.OfferList, map[string]map[string]bool) {
+	list := model.NewOfferList([]model.Offer{
+		newFacetOffer("1", model.Walmart, "Video Games", "Bethesda", 19.99, 4.5),
+		newFacetOffer("2", model.Walmart, "Toys", "Funko", 29.99, 3.2),
+		newFacetOffer("3", model.BestBuy, "Video Games", "Bethesda", 39.99, 4.8),
+		newFacetOffer("4", model.Ebay, "", "", 120, 0),
+	}, 1, 3, 60)
+	list.Facets = []model.Facet{
+		model.NewFacet("color", []model.FacetValue{{Value: "red", Count: 43}}),
+		model.NewFacet(model.Price, []model.FacetValue{{Value: "$10 - $25", Count: 12}}),
+	}
+
+	natives := make(map[string]map[string]bool)
+	addNativeFacets(natives, model.Walmart, list)
+	addNativeFacets(natives, model.BestBuy, &model.OfferList{Facets: list.Facets[:1]})
+	return list, natives
+}
+
+// tests facets are computed over offers and native facets merged in
+func TestApplyFacets(t *testing.T) {
+	m := newTestModule(t)
+	list, natives := newFacetList()
+
+	m.applyFacets(list, map[string]string{model.Name: "skyrim"}, natives)
+	assert.Equal(t, 4, len(list.List))
+	assert.Equal(t, 60, list.TotalCount)
+
+	expected := []model.Facet{
+		model.NewFacet(model.PartyName, []model.FacetValue{{Value: model.Walmart, Count: 2}, {Value: model.BestBuy, Count: 1}, {Value: model.Ebay, Count: 1}}),
+		model.NewFacet(model.Category, []model.FacetValue{{Value: "Video Games", Count: 2}, {Value: "Toys", Count: 1}}),
+		model.NewFacet(model.Brand, []model.FacetValue{{Value: "Bethesda", Count: 2}, {Value: "Funko", Count: 1}}),
+		model.NewFacet(model.Price, []model.FacetValue{{Value: "0-25", Count: 1}, {Value: "25-50", Count: 2}, {Value: "100-250", Count: 1}}),
+		model.NewFacet(model.Rating, []model.FacetValue{{Value: "4+", Count: 2}, {Value: "3+", Count: 3}, {Value: "2+", Count: 3}, {Value: "1+", Count: 3}}),
+		model.NewFacet("color", []model.FacetValue{{Value: "red", Count: 43}}),
+		model.NewFacet(model.Walmart+":"+model.Price, []model.FacetValue{{Value: "$10 - $25", Count: 12}}),
+	}
+	assert.Equal(t, expected, list.Facets)
+}
+
+// tests native facets named like computed ones are kept apart under the name of their provider
+func TestApplyFacetsNativePrice(t *testing.T) {
+	m := newTestModule(t)
+	natives := make(map[string]map[string]bool)
+	list := model.NewOfferList(make([]model.Offer, 0), 1, 1, 0)
+
+	walmart := model.NewOfferList([]model.Offer{newFacetOffer("1", model.Walmart, "", "", 19.99, 0)}, 1, 1, 38)
+	walmart.Facets = []model.Facet{model.NewFacet(model.Price, []model.FacetValue{{Value: "$10 - $25", Count: 12}, {Value: "$25 - $50", Count: 26}})}
+	bestbuy := model.NewOfferList([]model.Offer{newFacetOffer("2", model.BestBuy, "", "", 39.99, 0)}, 1, 1, 20)
+	bestbuy.Facets = []model.Facet{model.NewFacet(model.Price, []model.FacetValue{{Value: "$25 - $50", Count: 20}})}
+	for _, page := range []*model.OfferList{walmart, bestbuy} {
+		addNativeFacets(natives, page.List[0].PartyName, page)
+		mergeSearchResponse(list, page)
+	}
+
+	m.applyFacets(list, map[string]string{model.Price: "0-25", model.Walmart + ":" + model.Price: "$25 - $50"}, natives)
+	assert.Equal(t, 1, len(list.List))
+	assert.Equal(t, "1", list.List[0].ExternalId)
+
+	expected := []model.Facet{
+		model.NewFacet(model.PartyName, []model.FacetValue{{Value: model.Walmart, Count: 1}}),
+		model.NewFacet(model.Price, []model.FacetValue{{Value: "0-25", Count: 1}, {Value: "25-50", Count: 1}}),
+		model.NewFacet(model.Walmart+":"+model.Price, []model.FacetValue{{Value: "$10 - $25", Count: 12}, {Value: "$25 - $50", Count: 26}}),
+		model.NewFacet(model.BestBuy+":"+model.Price, []model.FacetValue{{Value: "$25 - $50", Count: 20}}),
+	}
+	assert.Equal(t, expected, list.Facets)
+}
+
+// tests selected facet values filter offers, each facet counted over the offers matching the other facets
+func TestApplyFacetsSelected(t *testing.T) {
+	m := newTestModule(t)
+	list, natives := newFacetList()
+
+	params := map[string]string{model.Price: "0-25|25-50", model.Category: "Video Games"}
+	m.applyFacets(list, params, natives)
+	assert.Equal(t, 2, len(list.List))
+	assert.Equal(t, "1", list.List[0].ExternalId)
+	assert.Equal(t, "3", list.List[1].ExternalId)
+
+	// totals of providers are kept
+	assert.Equal(t, 60, list.TotalCount)
+	assert.Equal(t, 3, list.PageCount)
+
+	// categories of offers within the price buckets, prices of video games
+	assert.Equal(t, []model.FacetValue{{Value: "Video Games", Count: 2}, {Value: "Toys", Count: 1}}, list.Facets[1].Values)
+	assert.Equal(t, []model.FacetValue{{Value: "0-25", Count: 1}, {Value: "25-50", Count: 1}}, list.Facets[3].Values)
+
+	// brands are matched over offers
+	list, natives = newFacetList()
+	m.applyFacets(list, map[string]string{model.Brand: "Funko"}, natives)
+	assert.Equal(t, 1, len(list.List))
+	assert.Equal(t, "2", list.List[0].ExternalId)
+
+	// native facets keep offers of providers refining their results by them
+	list, natives = newFacetList()
+	m.applyFacets(list, map[string]string{"color": "red", model.Rating: "4+"}, natives)
+	assert.Equal(t, 2, len(list.List))
+	assert.Equal(t, model.Walmart, list.List[0].PartyName)
+	assert.Equal(t, model.BestBuy, list.List[1].PartyName)
+
+	// native facets without providers are not filters
+	list, _ = newFacetList()
+	m.applyFacets(list, map[string]string{"color": "red"}, map[string]map[string]bool{})
+	assert.Equal(t, 4, len(list.List))
+}
+
+// tests only providers selected in the provider facet are searched
+func TestSelectProviders(t *testing.T) {
+	providers := []string{model.Walmart, model.BestBuy, model.Ebay}
+	assert.Equal(t, providers, selectProviders(providers, map[string]string{}))
+	assert.Equal(t, []string{model.Walmart, model.Ebay}, selectProviders(providers, map[string]string{model.PartyName: "ebay.com|walmart.com"}))
+	assert.Empty(t, selectProviders(providers, map[string]string{model.PartyName: "target.com"}))
+}
+
+func TestInBucket(t *testing.T) {
+	assert.True(t, inBucket(25, "25-50"))
+	assert.False(t, inBucket(50, "25-50"))
+	assert.True(t, inBucket(500, "500+"))
+	assert.True(t, inBucket(4, "4+"))
+	assert.False(t, inBucket(3.9, "4+"))
+	assert.False(t, inBucket(10, "cheap"))
+}
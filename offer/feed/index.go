@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"github.com/guilhebl/go-offer/common/model"
+	"strings"
+	"unicode"
+)
+
+// item is an indexed product of a feed
+type item struct {
+	offer       model.Offer
+	description string
+}
+
+// index holds the products of all feeds in load order, indexed by external id, upc and name tokens
+type index struct {
+	items  []*item
+	byId   map[string]*item
+	byUpc  map[string]*item
+	tokens map[string][]int
+}
+
+func newIndex() *index {
+	return &index{
+		items:  make([]*item, 0),
+		byId:   make(map[string]*item),
+		byUpc:  make(map[string]*item),
+		tokens: make(map[string][]int),
+	}
+}
+
+// adds an item, items with an external id already indexed are skipped
+func (idx *index) add(it *item) {
+	id := it.offer.ExternalId
+	if _, ok := idx.byId[id]; ok {
+		return
+	}
+
+	pos := len(idx.items)
+	idx.items = append(idx.items, it)
+	idx.byId[id] = it
+	if upc := it.offer.Upc; upc != "" {
+		if _, ok := idx.byUpc[upc]; !ok {
+			idx.byUpc[upc] = it
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range tokenize(it.offer.Name + " " + it.offer.Brand) {
+		if !seen[t] {
+			seen[t] = true
+			idx.tokens[t] = append(idx.tokens[t], pos)
+		}
+	}
+}
+
+// returns the items matching every token of keywords in load order, all items if keywords has no tokens
+func (idx *index) search(keywords string) []*item {
+	tokens := tokenize(keywords)
+	if len(tokens) == 0 {
+		return idx.items
+	}
+
+	// intersect the sorted positions of each token
+	matches := idx.tokens[tokens[0]]
+	for _, t := range tokens[1:] {
+		matches = intersect(matches, idx.tokens[t])
+	}
+
+	list := make([]*item, 0, len(matches))
+	for _, pos := range matches {
+		list = append(list, idx.items[pos])
+	}
+	return list
+}
+
+// intersects two sorted slices of positions
+func intersect(a, b []int) []int {
+	out := make([]int, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// splits s into lower-cased words of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
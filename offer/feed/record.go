@@ -0,0 +1,155 @@
+package feed
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// record is a product of a feed, attribute names are lower-cased
+type record map[string]string
+
+// feed file formats by extension
+const (
+	formatCSV  = ".csv"
+	formatJSON = ".jsonl"
+	formatXML  = ".xml"
+)
+
+// returns the first non empty attribute of names
+func (r record) get(names ...string) string {
+	for _, n := range names {
+		if v := strings.TrimSpace(r[n]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// returns if path is a feed file of a known format
+func isFeedFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case formatCSV, formatJSON, formatXML:
+		return true
+	}
+	return false
+}
+
+// reads the records of a feed file by its format
+func readFile(path string) ([]record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case formatCSV:
+		return readCSV(f)
+	case formatJSON:
+		return readJSONLines(f)
+	case formatXML:
+		return readMerchantXML(f)
+	}
+	return nil, fmt.Errorf("unknown feed format: %s", path)
+}
+
+// reads a csv feed, the first row holds the attribute names
+func readCSV(r io.Reader) ([]record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	records := make([]record, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := make(record)
+		for i, v := range row {
+			if i < len(header) {
+				rec[header[i]] = v
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// reads a JSON Lines feed, one product object per line, nested values are ignored
+func readJSONLines(r io.Reader) ([]record, error) {
+	records := make([]record, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var obj map[string]interface{}
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+
+		rec := make(record)
+		for k, v := range obj {
+			switch v.(type) {
+			case nil, map[string]interface{}, []interface{}:
+				continue
+			}
+			rec[strings.ToLower(k)] = fmt.Sprint(v)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// merchantFeed is a Google Merchant Center RSS 2.0 feed, item attributes are read by local name
+// so g:id and id are the same attribute
+type merchantFeed struct {
+	Items []struct {
+		Attributes []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"channel>item"`
+}
+
+// reads a Google Merchant XML feed
+func readMerchantXML(r io.Reader) ([]record, error) {
+	var f merchantFeed
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	records := make([]record, 0, len(f.Items))
+	for _, item := range f.Items {
+		rec := make(record)
+		for _, a := range item.Attributes {
+			name := strings.ToLower(a.XMLName.Local)
+			if _, ok := rec[name]; !ok {
+				rec[name] = a.Value
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,268 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/guilhebl/go-strutil"
+	"github.com/guilhebl/go-worker-pool"
+	"html"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repo is the provider of the product feeds merchants drop in feedDirectory: CSV, JSON Lines or Google Merchant XML
+// files named after the merchant, e.g. acme.csv. Feeds are indexed in memory and reloaded when the files change.
+// Merchants number their products on their own, so the external id of an offer is its merchant and id, e.g. acme:1001
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+
+	mu        sync.Mutex
+	idx       *index
+	signature string
+	checked   time.Time
+}
+
+// builds a new feed provider using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		idx:     newIndex(),
+	}
+}
+
+// returns the index of the feeds, checking at most every feedReloadIntervalSeconds if the feed files changed
+// and reloading them if so. If a reload fails the previous index is kept.
+func (repo *Repo) index() *index {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	interval := time.Duration(repo.config.GetIntProperty("feedReloadIntervalSeconds")) * time.Second
+	if !repo.checked.IsZero() && time.Since(repo.checked) < interval {
+		return repo.idx
+	}
+	repo.checked = time.Now()
+
+	dir := repo.config.GetProperty("feedDirectory")
+	if dir == "" {
+		return repo.idx
+	}
+
+	files, signature, err := listFeedFiles(dir)
+	if err != nil {
+		log.Printf("error reading feeds: %s", err)
+		return repo.idx
+	}
+	if signature == repo.signature {
+		return repo.idx
+	}
+
+	log.Printf("loading %d feeds from %s", len(files), dir)
+	repo.idx = repo.load(files)
+	repo.signature = signature
+	return repo.idx
+}
+
+// lists the feed files of dir sorted by name, along with a signature of their names, sizes and modification times
+func listFeedFiles(dir string) ([]string, string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	files := make([]string, 0)
+	var signature strings.Builder
+	for _, e := range entries {
+		if e.IsDir() || !isFeedFile(e.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+		fmt.Fprintf(&signature, "%s|%d|%d;", e.Name(), e.Size(), e.ModTime().UnixNano())
+	}
+	return files, signature.String(), nil
+}
+
+// builds a new index of the feed files, files that fail to load are skipped
+func (repo *Repo) load(files []string) *index {
+	idx := newIndex()
+	proxyRequired := repo.config.IsProxyRequired(model.Feed)
+
+	for _, path := range files {
+		records, err := readFile(path)
+		if err != nil {
+			log.Printf("error loading feed %s: %s", path, err)
+			continue
+		}
+
+		merchant := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		for _, r := range records {
+			if it := repo.buildItem(r, merchant, proxyRequired); it != nil {
+				idx.add(it)
+			}
+		}
+	}
+	return idx
+}
+
+// separates the merchant from the product id in the external id of offers
+const merchantIdSeparator = ":"
+
+// maps a feed record to an item, attribute names of Google Merchant feeds and their common aliases are accepted.
+// returns nil if the record has no id
+func (repo *Repo) buildItem(r record, merchant string, proxyRequired bool) *item {
+	id := r.get("id", "sku", "product_id")
+	if id == "" {
+		return nil
+	}
+
+	rating, _ := strconv.ParseFloat(r.get("rating", "product_rating"), 32)
+	numReviews, _ := strconv.Atoi(r.get("num_reviews", "review_count"))
+
+	o := model.NewOffer(
+		"",
+		merchant+merchantIdSeparator+id,
+		r.get("gtin", "upc", "ean"),
+		html.UnescapeString(r.get("title", "name")),
+		model.Feed,
+		r.get("link", "url"),
+		repo.config.BuildImgUrlExternal(r.get("image_link", "image", "image_url"), proxyRequired),
+		repo.config.BuildImgUrl("feed-logo.png"),
+		r.get("product_type", "category", "google_product_category"),
+		parsePrice(r.get("sale_price", "price")),
+		float32(rating),
+		numReviews,
+		time.Now(),
+	)
+	o.Condition = r.get("condition")
+	o.Brand = r.get("brand")
+	o.Seller = r.get("seller")
+	if o.Seller == "" {
+		o.Seller = merchant
+	}
+
+	return &item{
+		offer:       *o,
+		description: r.get("description"),
+	}
+}
+
+// parses a feed price, merchant feeds append the currency e.g. "15.00 USD"
+func parsePrice(s string) float32 {
+	fields := strings.Fields(strings.NewReplacer("$", "", ",", "").Replace(s))
+	if len(fields) == 0 {
+		return 0
+	}
+	p, _ := strconv.ParseFloat(fields[0], 32)
+	return float32(p)
+}
+
+// Creates Job for Searching offers from feeds and returns a Channel with jobResults
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewSearchTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Searches for offers in feeds matching every keyword, without keywords all products are listed
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(model.Feed) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil, monitor.ErrThrottled
+	}
+
+	page := 1
+	if m[model.Page] != "" {
+		p, err := strconv.Atoi(m[model.Page])
+		if err != nil || p < 1 {
+			log.Printf("format page error: %s", m[model.Page])
+			return nil, errors.New(model.InvalidRequest)
+		}
+		page = p
+	}
+	pageSize := repo.config.GetIntProperty("feedDefaultPageSize")
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	items := repo.index().search(m[model.Name])
+	total := len(items)
+	totalPages := (total + pageSize - 1) / pageSize
+
+	list := make([]model.Offer, 0, pageSize)
+	for i := (page - 1) * pageSize; i < total && i < page*pageSize; i++ {
+		list = append(list, items[i].offer)
+	}
+	return model.NewOfferList(list, page, totalPages, total), nil
+}
+
+// Creates Job for fetching Product Detail and returns a Channel with jobResult
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
+	// convert to map for job to consume
+	m := make(map[string]string)
+	m["id"], m["idType"], m["country"] = id, idType, country
+
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewGetDetailTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Search for a specific product detail either by Id or Upc
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
+
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(model.Feed) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil
+	}
+
+	var it *item
+	switch idType {
+	case model.Id:
+		it = repo.index().byId[id]
+	case model.Upc:
+		it = repo.index().byUpc[id]
+	}
+	if it == nil {
+		return nil
+	}
+	return repo.buildProductDetail(it)
+}
+
+func (repo *Repo) buildProductDetail(it *item) *model.OfferDetail {
+	attrs := make(map[string]string)
+	if it.offer.Brand != "" {
+		attrs[model.Brand] = it.offer.Brand
+	}
+
+	detItems := make([]model.OfferDetailItem, 0)
+	det := model.NewOfferDetail(
+		it.offer,
+		html.UnescapeString(strutil.FilterHtmlTags(it.description)),
+		attrs,
+		detItems,
+	)
+
+	return det
+}
@@ -0,0 +1,132 @@
+package feed
+
+import (
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestRepo(t *testing.T, dir string) *Repo {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	c.SetProperty("feedDirectory", dir)
+	c.SetProperty("feedReloadIntervalSeconds", "0")
+	return NewRepo(c, monitor.NewRequestMonitor(c), http.DefaultClient)
+}
+
+func TestLoadFeeds(t *testing.T) {
+	repo := newTestRepo(t, "testdata/feeds")
+
+	// csv
+	d := repo.GetOfferDetail("acme-games:AG-1001", model.Id, model.Canada)
+	assert.NotNil(t, d)
+	assert.Equal(t, "093155171251", d.Offer.Upc)
+	assert.Equal(t, float32(29.99), d.Offer.Price)
+	assert.Equal(t, float32(4.7), d.Offer.Rating)
+	assert.Equal(t, 128, d.Offer.NumReviews)
+	assert.Equal(t, "new", d.Offer.Condition)
+	assert.Equal(t, "acme-games", d.Offer.Seller)
+	assert.Equal(t, "https://acme-games.example.com/p/AG-1001", d.Offer.SemanticName)
+	assert.True(t, d.Offer.IsValid(func(name string) bool { return name == model.Feed }))
+
+	// json lines
+	d = repo.GetOfferDetail("northwind:NW-77", model.Id, model.Canada)
+	assert.NotNil(t, d)
+	assert.Equal(t, float32(54.5), d.Offer.Price)
+	assert.Equal(t, "Northwind Apparel", d.Offer.Seller)
+	assert.Equal(t, "Clothing", d.Offer.ProductCategory)
+
+	// google merchant xml
+	d = repo.GetOfferDetail("0887276311111", model.Upc, model.Canada)
+	assert.NotNil(t, d)
+	assert.Equal(t, "contoso:CT-5001", d.Offer.ExternalId)
+	assert.Equal(t, `4K Ultra HD Smart TV 55"`, d.Offer.Name)
+	assert.Equal(t, "Electronics > TVs", d.Offer.ProductCategory)
+	assert.Equal(t, float32(499.99), d.Offer.Price)
+	assert.Equal(t, "Crisp 4K picture with HDR.", d.Description)
+	assert.Equal(t, "contoso", d.Offer.Seller)
+
+	assert.Nil(t, repo.GetOfferDetail("AG-1001", model.Id, model.Canada))
+	assert.Nil(t, repo.GetOfferDetail("XX-1", model.Id, model.Canada))
+	assert.Nil(t, repo.GetOfferDetail("123456789", model.Upc, model.Canada))
+}
+
+func TestSearch(t *testing.T) {
+	repo := newTestRepo(t, "testdata/feeds")
+
+	list, err := repo.search(map[string]string{model.Name: "Skyrim"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, list.TotalCount)
+	assert.Equal(t, "acme-games:AG-1001", list.List[0].ExternalId)
+	assert.Equal(t, "contoso:CT-5002", list.List[1].ExternalId)
+	assert.Equal(t, "northwind:NW-77", list.List[2].ExternalId)
+
+	list, _ = repo.search(map[string]string{model.Name: "skyrim playstation"})
+	assert.Equal(t, 1, list.TotalCount)
+
+	// brands are searchable
+	list, _ = repo.search(map[string]string{model.Name: "bethesda"})
+	assert.Equal(t, 2, list.TotalCount)
+
+	list, _ = repo.search(map[string]string{model.Name: "zelda"})
+	assert.Equal(t, 0, len(list.List))
+
+	// without keywords every product is listed
+	repo.config.SetProperty("feedDefaultPageSize", "5")
+	list, _ = repo.search(map[string]string{model.Page: "2"})
+	assert.Equal(t, 7, list.TotalCount)
+	assert.Equal(t, 2, list.PageCount)
+	assert.Equal(t, 2, len(list.List))
+}
+
+func TestReloadOnChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feeds")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	repo := newTestRepo(t, dir)
+	search := func(m map[string]string) *model.OfferList {
+		list, _ := repo.search(m)
+		return list
+	}
+	assert.Equal(t, 0, search(map[string]string{}).TotalCount)
+
+	csv := "id,title,price\nZ-1,Skyrim Poster,9.99\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "zeta.csv"), []byte(csv), 0644))
+	assert.Equal(t, 1, search(map[string]string{model.Name: "skyrim"}).TotalCount)
+
+	// unknown formats are ignored
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("skyrim"), 0644))
+	assert.Equal(t, 1, search(map[string]string{model.Name: "skyrim"}).TotalCount)
+
+	assert.Nil(t, os.Remove(filepath.Join(dir, "zeta.csv")))
+	assert.Equal(t, 0, search(map[string]string{model.Name: "skyrim"}).TotalCount)
+}
+
+// tests products of merchants numbering them alike are told apart by merchant
+func TestSameIdMerchants(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feeds")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "acme.csv"), []byte("id,title,price\n1001,Skyrim Poster,9.99\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "zeta.csv"), []byte("id,title,price\n1001,Skyrim Mug,14.99\n"), 0644))
+	repo := newTestRepo(t, dir)
+
+	list, err := repo.search(map[string]string{model.Name: "skyrim"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, list.TotalCount)
+
+	d := repo.GetOfferDetail("zeta:1001", model.Id, model.Canada)
+	assert.NotNil(t, d)
+	assert.Equal(t, "Skyrim Mug", d.Offer.Name)
+
+	// offer ids are derived from the external id so they're unique across merchants
+	assert.NotEqual(t, model.NewOfferId(model.Feed, "acme:1001", model.Canada), model.NewOfferId(model.Feed, d.Offer.ExternalId, model.Canada))
+}
@@ -2,11 +2,14 @@ package offer
 
 import (
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
 )
 
@@ -18,25 +21,48 @@ func handleErr(errMsg string, w http.ResponseWriter) {
 	case model.InvalidRequest:
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(model.InvalidRequest)
+	case model.Unauthorized:
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(model.Unauthorized)
+	case model.NotFound:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(model.JsonErr{Code: http.StatusNotFound, Text: "Not Found"})
+	case model.VersionConflict:
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(model.VersionConflict)
+	case model.VersionRequired:
+		w.WriteHeader(http.StatusPreconditionRequired)
+		json.NewEncoder(w).Encode(model.VersionRequired)
+	case model.CacheDisabled:
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(model.CacheDisabled)
 	default:
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(model.InternalError)
 	}
 }
 
+// tells clients if the response was served from cache, only sent when cache is enabled
+func (m *Module) setCacheHeader(w http.ResponseWriter, status string) {
+	if m.isCacheEnabled() {
+		w.Header().Set(CacheHeader, status)
+	}
+}
+
 // Searches with no keywords for Trending and Promotional Deals in each marketplace provider
-func Index(w http.ResponseWriter, r *http.Request) {
+func (m *Module) Index(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("Index: %s", r.URL)
 
 	// search with empty keyword
-	req := model.NewEmptyListRequest(config.GetIntProperty("defaultRowsPerPage"))
-	result, err := SearchOffers(req)
+	req := model.NewEmptyListRequest(m.Config.GetIntProperty("defaultRowsPerPage"))
+	result, status, err := m.searchOffersCached(req)
 	if err != nil {
 		handleErr(err.Error(), w)
 		return
 	}
 
 	// set ok response
+	m.setCacheHeader(w, status)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -45,7 +71,7 @@ func Index(w http.ResponseWriter, r *http.Request) {
 }
 
 // Searches for offers from marketplace providers using keyword and other filters
-func Search(w http.ResponseWriter, r *http.Request) {
+func (m *Module) Search(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
@@ -58,13 +84,14 @@ func Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := SearchOffers(&req)
+	result, status, err := m.searchOffersCached(&req)
 	if err != nil {
 		handleErr(err.Error(), w)
 		return
 	}
 
 	// set response
+	m.setCacheHeader(w, status)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -72,30 +99,19 @@ func Search(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Reset Db
-func ResetDatastore(w http.ResponseWriter, r *http.Request) {
-	fmt.Printf("Reset Datastore: %s", r.URL)
+// Searches for a page of offers in Db filtered by partyName, upc and productCategory query params
+// sorted by sortBy and sortOrder, pages are set by page, rowsPerPage and pageState params. pages of a
+// pageState are counted only with count=true
+func (m *Module) SearchDatastore(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Search Datastore: %s", r.URL)
 
-	err := ResetDb()
+	req, err := m.newDatastoreRequest(r)
 	if err != nil {
 		handleErr(err.Error(), w)
 		return
 	}
 
-	// set ok response
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode("OK"); err != nil {
-		panic(err)
-	}
-}
-
-// Searches for all offers in Db
-func SearchDatastore(w http.ResponseWriter, r *http.Request) {
-	fmt.Printf("Search Datastore: %s", r.URL)
-
-	req := model.NewEmptyListRequest(config.GetIntProperty("defaultRowsPerPage"))
-	result, err := SearchOffersDb(req)
+	result, err := m.SearchOffersDb(req)
 	if err != nil {
 		handleErr(err.Error(), w)
 		return
@@ -109,8 +125,36 @@ func SearchDatastore(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// builds a list request from datastore search query params, page defaults to first page of defaultRowsPerPage
+func (m *Module) newDatastoreRequest(r *http.Request) (*model.ListRequest, error) {
+	req := model.NewEmptyListRequest(m.Config.GetIntProperty("defaultRowsPerPage"))
+
+	for _, name := range []string{model.PartyName, model.Upc, model.Category, model.Count} {
+		if v := r.FormValue(name); v != "" {
+			req.SearchColumns = append(req.SearchColumns, model.NewNameValue(name, v))
+		}
+	}
+
+	var err error
+	if v := r.FormValue(model.Page); v != "" {
+		if req.Page, err = strconv.Atoi(v); err != nil {
+			return nil, errors.New(model.InvalidRequest)
+		}
+	}
+	if v := r.FormValue(model.RowsPerPage); v != "" {
+		if req.RowsPerPage, err = strconv.Atoi(v); err != nil {
+			return nil, errors.New(model.InvalidRequest)
+		}
+	}
+
+	req.SortBy = r.FormValue(model.SortBy)
+	req.SortOrder = r.FormValue(model.SortOrder)
+	req.PageState = r.FormValue(model.PageState)
+	return req, nil
+}
+
 // Adds to Datastore a new offer
-func AddOffer(w http.ResponseWriter, r *http.Request) {
+func (m *Module) AddOffer(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("Add to Datastore: %s", r.URL)
 
 	defer r.Body.Close()
@@ -125,13 +169,14 @@ func AddOffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := AddOfferDb(&req)
+	result, err := m.AddOfferDb(&req)
 	if err != nil {
 		handleErr(err.Error(), w)
 		return
 	}
 
 	// set response
+	setETag(w, result)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -139,8 +184,111 @@ func AddOffer(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Gets an offer from Datastore by id
+func (m *Module) GetOffer(w http.ResponseWriter, r *http.Request) {
+	result, err := m.GetOfferDb(mux.Vars(r)["id"])
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+
+	setETag(w, result)
+	writeJson(w, http.StatusOK, result)
+}
+
+// Gets an offer detail of the catalog by source provider and external id
+func (m *Module) CatalogOffer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	result, err := m.GetCatalogOffer(vars["source"], vars["externalId"])
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+
+	writeJson(w, http.StatusOK, result)
+}
+
+// Replaces an offer in Datastore, the current version must be sent in If-Match header or version field
+func (m *Module) UpdateOffer(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Update in Datastore: %s", r.URL)
+
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	var req model.Offer
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		handleErr(model.InvalidRequest, w)
+		return
+	}
+
+	result, err := m.UpdateOfferDb(mux.Vars(r)["id"], &req, requestVersion(r, body))
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+
+	setETag(w, result)
+	writeJson(w, http.StatusOK, result)
+}
+
+// Patches an offer in Datastore with a JSON merge patch, the current version must be sent in If-Match header or version field
+func (m *Module) PatchOffer(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Patch in Datastore: %s", r.URL)
+
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		handleErr(model.InvalidRequest, w)
+		return
+	}
+
+	result, err := m.PatchOfferDb(mux.Vars(r)["id"], body, requestVersion(r, body))
+	if err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+
+	setETag(w, result)
+	writeJson(w, http.StatusOK, result)
+}
+
+// Deletes an offer from Datastore, the current version must be sent in If-Match header
+func (m *Module) DeleteOffer(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("Delete from Datastore: %s", r.URL)
+
+	if err := m.DeleteOfferDb(mux.Vars(r)["id"], requestVersion(r, nil)); err != nil {
+		handleErr(err.Error(), w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sets the ETag header of an offer from its version
+func setETag(w http.ResponseWriter, o *model.Offer) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(o.Version, 10)))
+}
+
+// reads the version a change is based on from If-Match header or else from version field of body, 0 if missing
+func requestVersion(r *http.Request, body []byte) int64 {
+	if tag := r.Header.Get("If-Match"); tag != "" {
+		v, _ := strconv.ParseInt(strings.Trim(strings.TrimPrefix(tag, "W/"), `"`), 10, 64)
+		return v
+	}
+
+	var req struct {
+		Version int64 `json:"version"`
+	}
+	json.Unmarshal(body, &req)
+	return req.Version
+}
+
 // Get Offer Detail from marketplace provider and associated competitors
-func Show(w http.ResponseWriter, r *http.Request) {
+func (m *Module) Show(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	var id string
 	var err error
@@ -153,14 +301,23 @@ func Show(w http.ResponseWriter, r *http.Request) {
 	source := r.FormValue("source")
 	country := r.FormValue("country")
 
+	// an offer id is resolved to its provider, external id and country
 	request := model.NewDetailRequest(id, idType, source, country)
-	result, err := GetOfferDetail(request)
+	if idType == "" && source == "" {
+		if request, err = m.ResolveOfferId(id); err != nil {
+			handleErr(err.Error(), w)
+			return
+		}
+	}
+
+	result, status, err := m.getOfferDetailCached(request)
 	if err != nil {
 		handleErr(err.Error(), w)
 		return
 	}
 
 	if result != nil && result.Offer.Id != "" {
+		m.setCacheHeader(w, status)
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		w.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(w).Encode(*result); err != nil {
@@ -5,58 +5,71 @@ import (
 	"github.com/guilhebl/go-offer/common/cache"
 	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/db"
+	"github.com/guilhebl/go-offer/offer/monitor"
 	"github.com/guilhebl/go-worker-pool"
 	"log"
 	"net/http"
 	"runtime"
+	"strings"
 	"sync"
+	"time"
 )
 
-// centralized module manager which holds references to JobQueue and other global app scoped objects
-// Singleton enforcing the module will be initialized at max. once per app.
+// centralized module manager which holds references to JobQueue and other app scoped objects
+// Every dependency is injected when the module is built so multiple isolated instances can run in the same process.
 type Module struct {
-	JobQueue        chan job.Job
-	Dispatcher      *job.WorkerPool
-	Router          *mux.Router
-	RedisCache      *cache.RedisCache
-	CassandraClient *db.CassandraClient
-}
+	Config     *config.Configuration
+	JobQueue   chan job.Job
+	Dispatcher *job.WorkerPool
+	Router     *mux.Router
+	Cache      cache.Cache
+	Store      db.OfferStore
+	Providers  ProviderRegistry
+	HttpClient *http.Client
+	flights    *cache.Group
 
-var instance *Module
-var once sync.Once
+	// slots of stale entries refreshed in background and keys being refreshed
+	refreshes  chan struct{}
+	refreshing sync.Map
 
-func BuildInstance(mode string) *Module {
-	once.Do(func() {
-		instance = newModule(mode)
-	})
-	return instance
-}
+	// queue of offers observed in marketplaces to be upserted into the catalog and of offer refs to be stored
+	catalog        chan catalogItem
+	catalogDone    chan struct{}
+	catalogEnabled bool
+
+	// refs of the offer ids minted lately, offers are resolved from it by id until their refs are stored
+	refs *cache.LRUCache
 
-func GetInstance() *Module {
-	return instance
+	// background work scheduling jobs (warm ups and refreshes), Stop waits for it before closing the JobQueue
+	background sync.WaitGroup
+	stopping   chan struct{}
+	stopMu     sync.Mutex
 }
 
-// Builds a new module which is a container for the running app instance
-// router - the router configuration with URL routes and mapped action handlers
+// Builds a new module wiring default dependencies from the config file of this mode
 // mode - test or production modes, which will make the app read from either test or prod config properties.
-func newModule(mode string) *Module {
+func BuildModule(mode string) *Module {
 	log.Printf("New Module, mode: %s", mode)
 
-	// init config
-	config.BuildInstance(mode)
-
-	// init mux
-	router := NewRouter()
-	// init static folder
-	router.PathPrefix("/static").Handler(http.StripPrefix("/static", http.FileServer(http.Dir("static/"))))
+	c := config.NewConfiguration(mode)
 
 	// init Db
-	clusterConfig := db.BuildInstance(config.GetProperty("cassandraHost"),
-		config.GetProperty("cassandraUser"),
-		config.GetProperty("cassandraPassword"),
-		config.GetProperty("cassandraKeyspace"),
-		config.GetIntProperty("cassandraPort"))
+	store := NewOfferStore(c)
+
+	// init marketplace providers sharing the same http client and request monitor
+	client := NewHttpClient(c)
+	providers := NewProviderRegistry(c, monitor.NewRequestMonitor(c), client)
+
+	return NewModule(c, store, NewCache(c), providers, client)
+}
 
+// Builds a new module which is a container for the running app instance
+// c - the config of this instance
+// store - the datastore client
+// cacheStore - the cache backend, nil if cache is disabled
+// providers - the marketplace providers registry
+// client - the http client used on outbound calls
+func NewModule(c *config.Configuration, store db.OfferStore, cacheStore cache.Cache, providers ProviderRegistry, client *http.Client) *Module {
 	// fetch ENV var param ?
 	// maxWorker := os.Getenv("MAX_WORKERS")
 	numCPUs := runtime.NumCPU()
@@ -64,31 +77,145 @@ func newModule(mode string) *Module {
 	workerPool := job.NewWorkerPool(maxWorkers)
 	jobQueue := make(chan job.Job)
 
+	maxRefreshes := c.GetIntProperty("cacheMaxRefreshes")
+	if maxRefreshes <= 0 {
+		maxRefreshes = maxWorkers
+	}
+
+	refCacheSize := c.GetIntProperty("offerRefCacheSize")
+	if refCacheSize <= 0 {
+		refCacheSize = defaultOfferRefCacheSize
+	}
+
 	module := Module{
-		Dispatcher:      &workerPool,
-		JobQueue:        jobQueue,
-		Router:          router,
-		CassandraClient: clusterConfig,
+		Config:     c,
+		Dispatcher: &workerPool,
+		JobQueue:   jobQueue,
+		Cache:      cacheStore,
+		Store:      store,
+		Providers:  providers,
+		HttpClient: client,
+		flights:    cache.NewGroup(),
+		refreshes:  make(chan struct{}, maxRefreshes),
+		refs:       cache.NewLRUCache(refCacheSize, 0),
+		stopping:   make(chan struct{}),
 	}
 
+	// init mux
+	module.Router = NewRouter(module.routes())
+	// init static folder
+	module.Router.PathPrefix("/static").Handler(http.StripPrefix("/static", http.FileServer(http.Dir("static/"))))
+
 	// A buffered channel that we can send work requests on.
 	module.Dispatcher.Run(jobQueue)
 
-	// init cache
-	if config.GetBoolProperty("cacheEnabled") {
-		host := config.GetProperty("cacheHost")
-		port := config.GetProperty("cachePort")
-		cacheDefaultExpiration := config.GetIntProperty("cacheExpirationSeconds")
-		module.RedisCache = cache.BuildInstance(host, port, cacheDefaultExpiration)
-	}
+	// ingest offers observed in marketplaces into the catalog and store refs of offer ids
+	module.startCatalog()
 
 	return &module
 }
 
+// builds the offer store selected by config: Cassandra or else in-memory. A failed migration on startup is
+// logged and the app keeps serving marketplace searches, datastore calls fail until the schema is migrated
+func NewOfferStore(c *config.Configuration) db.OfferStore {
+	if !c.GetBoolProperty("cassandraEnabled") {
+		return db.NewMemoryStore()
+	}
+
+	client := NewCassandraClient(c)
+	if c.GetBoolProperty("cassandraMigrateOnStartup") {
+		if _, err := client.Migrate(c.GetProperty("cassandraMigrationsDir"), false); err != nil {
+			log.Printf("datastore migration failed on startup: %s", err.Error())
+		}
+	}
+	return client
+}
+
+// builds the Cassandra client from config, fails if config is not valid
+func NewCassandraClient(c *config.Configuration) *db.CassandraClient {
+	client, err := db.NewCassandraClient(db.CassandraOptions{
+		Hosts:                strings.Split(c.GetProperty("cassandraHosts"), ","),
+		Port:                 c.GetIntProperty("cassandraPort"),
+		Username:             c.GetProperty("cassandraUser"),
+		Password:             c.GetProperty("cassandraPassword"),
+		Keyspace:             c.GetProperty("cassandraKeyspace"),
+		Consistency:          c.GetProperty("cassandraConsistency"),
+		TimeoutMillis:        c.GetIntProperty("cassandraTimeoutMillis"),
+		ConnectTimeoutMillis: c.GetIntProperty("cassandraConnectTimeoutMillis"),
+		NumConns:             c.GetIntProperty("cassandraNumConns"),
+		Retries:              c.GetIntProperty("cassandraRetries"),
+		RetryMinMillis:       c.GetIntProperty("cassandraRetryMinMillis"),
+		RetryMaxMillis:       c.GetIntProperty("cassandraRetryMaxMillis"),
+		LocalDC:              c.GetProperty("cassandraLocalDC"),
+		TLS:                  c.GetBoolProperty("cassandraTLS"),
+		CaPath:               c.GetProperty("cassandraCaPath"),
+		CertPath:             c.GetProperty("cassandraCertPath"),
+		KeyPath:              c.GetProperty("cassandraKeyPath"),
+		TLSVerifyHost:        c.GetBoolProperty("cassandraTLSVerifyHost"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+// builds the cache backend selected by config: in-memory LRU, Redis or a local LRU in front of Redis
+// returns nil if cache is disabled
+func NewCache(c *config.Configuration) cache.Cache {
+	if !c.GetBoolProperty("cacheEnabled") {
+		return nil
+	}
+
+	expiration := c.GetIntProperty("cacheExpirationSeconds")
+	maxEntries := c.GetIntProperty("cacheMemoryMaxEntries")
+
+	switch c.GetProperty("cacheType") {
+	case cache.Redis:
+		return newRedisCache(c)
+	case cache.Tiered:
+		l1Expiration := c.GetIntProperty("cacheMemoryExpirationSeconds")
+		l1 := cache.NewLRUCache(maxEntries, l1Expiration)
+		return cache.NewTieredCache(l1, newRedisCache(c), l1Expiration)
+	default:
+		return cache.NewLRUCache(maxEntries, expiration)
+	}
+}
+
+// builds the redis cache client from config
+func newRedisCache(c *config.Configuration) *cache.RedisCache {
+	return cache.NewRedisCache(cache.RedisOptions{
+		Host:                   c.GetProperty("cacheHost"),
+		Port:                   c.GetProperty("cachePort"),
+		Password:               c.GetProperty("cachePassword"),
+		DB:                     c.GetIntProperty("cacheDb"),
+		TLS:                    c.GetBoolProperty("cacheTLS"),
+		PoolSize:               c.GetIntProperty("cachePoolSize"),
+		KeyPrefix:              c.GetProperty("cacheKeyPrefix"),
+		KeyVersion:             c.GetIntProperty("cacheKeyVersion"),
+		CacheExpirationSeconds: c.GetIntProperty("cacheExpirationSeconds"),
+	})
+}
+
+// builds the http client used on outbound calls to marketplace providers
+func NewHttpClient(c *config.Configuration) *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(c.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond,
+	}
+}
+
 // stops pool and closes JobQueue returns the result of closing both
 func (m *Module) Stop() bool {
 	log.Printf("%s", "Stopping Module")
+
+	// no background work is started from now on, the running one stops scheduling jobs and is waited for
+	m.stopMu.Lock()
+	close(m.stopping)
+	m.stopMu.Unlock()
+	m.background.Wait()
+
 	m.Dispatcher.Stop()
+	m.stopCatalog()
+	m.Store.Close()
 
 	// close the Job queue chan
 	close(m.JobQueue)
@@ -103,3 +230,29 @@ func (m *Module) Stop() bool {
 
 	return ok
 }
+
+// runs f in background unless the module is stopping, returns false if f is not run
+func (m *Module) goBackground(f func()) bool {
+	m.stopMu.Lock()
+	defer m.stopMu.Unlock()
+	if m.isStopping() {
+		return false
+	}
+
+	m.background.Add(1)
+	go func() {
+		defer m.background.Done()
+		f()
+	}()
+	return true
+}
+
+// returns if the module is stopping, background work checks it before scheduling jobs
+func (m *Module) isStopping() bool {
+	select {
+	case <-m.stopping:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,13 +1,39 @@
 package offer
 
 import (
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"reflect"
 	"testing"
+	"time"
 )
 
-// tests if app module is built correctly setting up worker pool and other global scoped objects
-func TestGetInstance(t *testing.T) {
+// builds a new module instance in test mode with in-memory store, no cache and default providers
+func newTestModule(t *testing.T) *Module {
+	return newTestModuleFromConfig(newTestConfig(t))
+}
+
+// loads the config of test mode
+func newTestConfig(t *testing.T) *config.Configuration {
+	c, err := config.NewConfigurationFromFile("../" + config.TestConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// builds a new module instance of config c with in-memory store, no cache and default providers
+func newTestModuleFromConfig(c *config.Configuration) *Module {
+	client := NewHttpClient(c)
+	providers := NewProviderRegistry(c, monitor.NewRequestMonitor(c), client)
+	return NewModule(c, NewOfferStore(c), nil, providers, client)
+}
+
+// tests if app module is built correctly setting up worker pool and other app scoped objects
+func TestNewModule(t *testing.T) {
 
-	module := BuildInstance("test")
+	module := newTestModule(t)
 
 	if module == nil {
 		t.Error("Error while creating Module")
@@ -23,4 +49,61 @@ func TestGetInstance(t *testing.T) {
 	if &j == nil {
 		t.Error("Error while creating Module Dispatcher JobQueue")
 	}
+
+	for _, country := range []string{model.UnitedStates, model.Canada, "gbr"} {
+		for _, p := range module.Config.MarketplaceProviders(country) {
+			if _, ok := module.Providers[p]; !ok {
+				t.Errorf("Error while creating Module Providers: %s of %s not registered", p, country)
+			}
+		}
+	}
+}
+
+// tests the providers of canada are still read from the deprecated marketplaceProvidersCanada key
+func TestMarketplaceProvidersDeprecatedKey(t *testing.T) {
+	c := newTestConfig(t)
+	c.SetProperty("marketplaceProviders.can", "")
+	c.SetProperty("marketplaceProvidersCanada", model.WalmartCanada+","+model.BestBuyCanada)
+	if p := c.MarketplaceProviders(model.Canada); !reflect.DeepEqual(p, []string{model.WalmartCanada, model.BestBuyCanada}) {
+		t.Errorf("Error providers of deprecated key not read: %v", p)
+	}
+
+	c.SetProperty("marketplaceProviders.can", model.Ebay)
+	if p := c.MarketplaceProviders(model.Canada); !reflect.DeepEqual(p, []string{model.Ebay}) {
+		t.Errorf("Error deprecated key preferred over marketplaceProviders.can: %v", p)
+	}
+}
+
+// tests if two modules built in the same process are isolated from each other
+func TestNewModuleIsolated(t *testing.T) {
+	m1 := newTestModule(t)
+	m2 := newTestModule(t)
+
+	m2.Config.SetProperty("defaultRowsPerPage", "25")
+
+	if m1.Config.GetIntProperty("defaultRowsPerPage") != 10 || m2.Config.GetIntProperty("defaultRowsPerPage") != 25 {
+		t.Error("Error module configs are not isolated")
+	}
+
+	if m1.JobQueue == m2.JobQueue || m1.Router == m2.Router {
+		t.Error("Error modules share app scoped objects")
+	}
+}
+
+// tests offers are validated against the providers of each module, REST providers are only known to their module
+// and their definitions are found relative to the config file whatever the working directory
+func TestNewModuleKnownParties(t *testing.T) {
+	m1 := newTestModule(t)
+
+	c := newTestConfig(t)
+	c.SetProperty("restProviderDefinitions", "")
+	m2 := newTestModuleFromConfig(c)
+
+	o := model.NewOffer("", "DX-20931", "", "offer", "deals.example.com", "", "", "", "", 10, 0, 0, time.Now())
+	if _, err := m1.AddOfferDb(o); err != nil {
+		t.Errorf("Error offer of REST provider rejected: %s", err)
+	}
+	if _, err := m2.AddOfferDb(o); err == nil || err.Error() != model.InvalidRequest {
+		t.Error("Error offer of provider unknown to module accepted")
+	}
 }
@@ -1,70 +1,93 @@
 package monitor
 
 import (
+	"errors"
 	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
 	"sync"
 	"time"
 )
 
-// RequestMonitor is a singleton responsible for controlling the outbound calls to Marketplace providers
+// ErrThrottled is returned by providers called before their wait interval elapsed, unlike transport failures
+// the provider is reachable and may be called again shortly
+var ErrThrottled = errors.New("provider call throttled by Request Monitor")
+
+// RequestMonitor is responsible for controlling the outbound calls to Marketplace providers
 // controlling volume of calls being made to the external marketplace environment, making sure number
 // of calls per second are within the limits and boundaries of each provider API.
 type RequestMonitor struct {
 	lastCalls     sync.Map
+	mu            sync.RWMutex
 	waitIntervals map[string]int
 }
 
-// checks if this api is available after waiting a certain "treshold" this avoids flooding this external resource with
-// calls, certain external APIs have quotas as max X requests per second.
-func (r *RequestMonitor) isServiceAvailable(name string, timestamp int64) bool {
-	s := GetInstance()
-	lastCall, ok := instance.lastCalls.Load(name)
-	diffLastCall := timestamp - lastCall.(int64)
-	waitInterval := int64(s.waitIntervals[name])
+// builds a new request monitor reading wait intervals of each provider from config, providers with several
+// buckets like the Amazon marketplace regions register their own
+func NewRequestMonitor(c *config.Configuration) *RequestMonitor {
+	walmartWaitInterval := c.GetIntProperty("walmartRequestWaitIntervalMilis")
+	walmartCanadaWaitInterval := c.GetIntProperty("walmartCaRequestWaitIntervalMilis")
+	eBayWaitInterval := c.GetIntProperty("eBayRequestWaitIntervalMilis")
+	bestBuyWaitInterval := c.GetIntProperty("bestbuyRequestWaitIntervalMilis")
+	bestBuyCanadaWaitInterval := c.GetIntProperty("bestbuyCaRequestWaitIntervalMilis")
+	targetWaitInterval := c.GetIntProperty("targetRequestWaitIntervalMilis")
+	aliExpressWaitInterval := c.GetIntProperty("aliexpressRequestWaitIntervalMilis")
+	feedWaitInterval := c.GetIntProperty("feedRequestWaitIntervalMilis")
 
-	if ok && diffLastCall >= waitInterval {
-		instance.lastCalls.Store(name, timestamp)
-		return true
+	wi := map[string]int{
+		model.Walmart:       walmartWaitInterval,
+		model.WalmartCanada: walmartCanadaWaitInterval,
+		model.Ebay:          eBayWaitInterval,
+		model.BestBuy:       bestBuyWaitInterval,
+		model.BestBuyCanada: bestBuyCanadaWaitInterval,
+		model.Target:        targetWaitInterval,
+		model.AliExpress:    aliExpressWaitInterval,
+		model.Feed:          feedWaitInterval,
 	}
 
-	return false
-}
+	r := &RequestMonitor{waitIntervals: wi}
+
+	// store initial last calls timestamps
+	for name := range wi {
+		r.lastCalls.Store(name, int64(0))
+	}
 
-var instance *RequestMonitor
-var once sync.Once
+	return r
+}
 
-func GetInstance() *RequestMonitor {
-	once.Do(func() {
+// registers the wait interval of a provider defined at runtime, it's safe to call while the monitor is used
+func (r *RequestMonitor) Register(name string, waitInterval int) {
+	r.mu.Lock()
+	r.waitIntervals[name] = waitInterval
+	r.mu.Unlock()
+	r.lastCalls.Store(name, int64(0))
+}
 
-		walmartWaitInterval := config.GetIntProperty("walmartRequestWaitIntervalMilis")
-		eBayWaitInterval := config.GetIntProperty("eBayRequestWaitIntervalMilis")
-		amazonWaitInterval := config.GetIntProperty("amazonRequestWaitIntervalMilis")
-		bestBuyWaitInterval := config.GetIntProperty("bestbuyRequestWaitIntervalMilis")
+// checks if this api is available after waiting a certain "treshold" this avoids flooding this external resource with
+// calls, certain external APIs have quotas as max X requests per second.
+func (r *RequestMonitor) isServiceAvailable(name string, timestamp int64) bool {
+	lastCall, ok := r.lastCalls.Load(name)
+	if !ok {
+		return false
+	}
 
-		wi := map[string]int{
-			model.Walmart: walmartWaitInterval,
-			model.Ebay:    eBayWaitInterval,
-			model.Amazon:  amazonWaitInterval,
-			model.BestBuy: bestBuyWaitInterval,
-		}
+	diffLastCall := timestamp - lastCall.(int64)
+	r.mu.RLock()
+	waitInterval := int64(r.waitIntervals[name])
+	r.mu.RUnlock()
 
-		instance = &RequestMonitor{sync.Map{}, wi}
+	if diffLastCall >= waitInterval {
+		r.lastCalls.Store(name, timestamp)
+		return true
+	}
 
-		// store initial last calls timestamps
-		instance.lastCalls.Store(model.Walmart, int64(0))
-		instance.lastCalls.Store(model.Ebay, int64(0))
-		instance.lastCalls.Store(model.Amazon, int64(0))
-		instance.lastCalls.Store(model.BestBuy, int64(0))
-	})
-	return instance
+	return false
 }
 
 // Checks if waitIntervalMilis has passed since last Call.
 // Difference in miliseconds (1*1000) = 1 second
-func IsServiceAvailable(name string) bool {
+func (r *RequestMonitor) IsServiceAvailable(name string) bool {
 	now := timeMillis()
-	return instance.isServiceAvailable(name, now)
+	return r.isServiceAvailable(name, now)
 }
 
 // gets time in millis
@@ -0,0 +1,89 @@
+package offer
+
+import (
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/aliexpress"
+	"github.com/guilhebl/go-offer/offer/amazon"
+	"github.com/guilhebl/go-offer/offer/bestbuy"
+	"github.com/guilhebl/go-offer/offer/ebay"
+	"github.com/guilhebl/go-offer/offer/feed"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/guilhebl/go-offer/offer/rest"
+	"github.com/guilhebl/go-offer/offer/target"
+	"github.com/guilhebl/go-offer/offer/walmart"
+	"github.com/guilhebl/go-worker-pool"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Provider represents a marketplace that can be searched for offers and queried for product details
+type Provider interface {
+	// creates a job to search offers using request params
+	SearchOffers(m map[string]string) *job.Job
+
+	// creates a job to fetch a product detail by id and idType
+	GetDetailJob(id, idType, country string) *job.Job
+
+	// fetches a product detail by id and idType
+	GetOfferDetail(id, idType, country string) *model.OfferDetail
+}
+
+// CountryProvider is implemented by providers serving countries not listed in their marketplace providers,
+// such as a regional marketplace enabled by setting its credentials
+type CountryProvider interface {
+	// checks if the provider serves country
+	ServesCountry(country string) bool
+}
+
+// SortingProvider is implemented by providers whose search pages are sorted by the sort params of the search
+type SortingProvider interface {
+	// checks if the provider sorts its search pages
+	SortsResults() bool
+}
+
+// ProviderRegistry maps each marketplace party name to its provider
+type ProviderRegistry map[string]Provider
+
+// checks if name is the party name of a registered marketplace provider
+func (r ProviderRegistry) IsKnownParty(name string) bool {
+	_, ok := r[name]
+	return ok
+}
+
+// builds the registry of all marketplace providers sharing the same config, request monitor and http client
+func NewProviderRegistry(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) ProviderRegistry {
+	r := ProviderRegistry{
+		model.Amazon:        amazon.NewRepo(c, m, client),
+		model.Walmart:       walmart.NewRepo(c, m, client),
+		model.WalmartCanada: walmart.NewCanadaRepo(c, m, client),
+		model.BestBuy:       bestbuy.NewRepo(c, m, client),
+		model.BestBuyCanada: bestbuy.NewCanadaRepo(c, m, client),
+		model.Ebay:          ebay.NewRepo(c, m, client),
+		model.Target:        target.NewRepo(c, m, client),
+		model.AliExpress:    aliexpress.NewRepo(c, m, client),
+		model.Feed:          feed.NewRepo(c, m, client),
+	}
+	registerRestProviders(r, c, m, client)
+	return r
+}
+
+// registers the REST providers of the definition files listed in restProviderDefinitions, relative to the config file,
+// definitions that fail to load are skipped
+func registerRestProviders(r ProviderRegistry, c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) {
+	for _, path := range strings.Split(c.GetProperty("restProviderDefinitions"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+
+		def, err := rest.LoadDefinition(c.ResolvePath(path))
+		if err != nil {
+			log.Printf("error loading REST provider definition %s: %s", path, err)
+			continue
+		}
+
+		m.Register(def.Name, def.RequestWaitIntervalMillis)
+		r[def.Name] = rest.NewRepo(c, m, client, def)
+	}
+}
@@ -3,136 +3,322 @@ package offer
 import (
 	"encoding/json"
 	"errors"
-	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/db"
 	"github.com/guilhebl/go-offer/common/model"
-	"github.com/guilhebl/go-offer/offer/amazon"
-	"github.com/guilhebl/go-offer/offer/bestbuy"
-	"github.com/guilhebl/go-offer/offer/ebay"
-	"github.com/guilhebl/go-offer/offer/walmart"
+	"github.com/guilhebl/go-offer/offer/monitor"
 	"github.com/guilhebl/go-worker-pool"
-	"github.com/guilhebl/xcrypto"
 	"log"
 	"math/rand"
 	"sort"
 	"strings"
+	"time"
 )
 
 // searches offers - tries to fetch 1st in cache if not found calls marketplace
-func SearchOffers(r *model.ListRequest) (*model.OfferList, error) {
+func (m *Module) SearchOffers(r *model.ListRequest) (*model.OfferList, error) {
+	obj, _, err := m.searchOffersCached(r)
+	return obj, err
+}
+
+// searches offers returning also the cache lookup result: fresh entries are served from cache,
+// stale entries are served while refreshed in background and misses call marketplace
+func (m *Module) searchOffersCached(r *model.ListRequest) (*model.OfferList, string, error) {
 	// validates request before querying marketplace
 	if !r.IsValid() {
-		return nil, errors.New(model.InvalidRequest)
+		return nil, CacheMiss, errors.New(model.InvalidRequest)
 	}
 
-	// transform request
-	jsonReq, _ := json.Marshal(&r)
-	key := string(jsonReq)
-	if key == "" {
-		key = model.Trending
-	}
+	// transform request into its canonical form so equivalent searches share cache entries and calls
+	r = r.Canonical()
 
 	// search first in cache
-	hash := xcrypto.GenerateSHA1(key)
-	cacheEnabled := isCacheEnabled()
-
-	var obj *model.OfferList
-	if cacheEnabled {
-		if data, _ := GetInstance().RedisCache.Get(hash); data != "" {
-			if err := json.Unmarshal([]byte(data), &obj); err != nil {
-				return nil, err
-			}
-			return obj, nil
-		}
+	key := searchCacheKey(r)
+
+	load := func() (interface{}, bool) {
+		var obj *model.OfferList
+		ok := m.getCached(key, &obj) == CacheHit
+		return obj, ok
 	}
 
-	// if not found in cache search and store valid output in cache
-	obj = searchOffers(r.Map())
-	if cacheEnabled && obj != nil {
-		data, _ := json.Marshal(&obj)
-		err := GetInstance().RedisCache.Set(hash, string(data))
-		if err != nil {
-			return nil, err
+	// store complete output in cache, if a provider failed or was throttled the partial output is kept
+	// for cachePartialExpirationSeconds only so the missing provider is called again shortly
+	fetch := func() interface{} {
+		obj, err := m.searchOffers(r.Map())
+		obj.Request = r
+		if err == nil {
+			m.setCached(key, obj)
+		} else {
+			m.setCachedFor(key, obj, time.Duration(m.Config.GetIntProperty("cachePartialExpirationSeconds"))*time.Second)
 		}
+		return obj
+	}
+
+	var cached *model.OfferList
+	switch m.getCached(key, &cached) {
+	case CacheHit:
+		return cached, CacheHit, nil
+	case CacheStale:
+		m.refresh(key, fetch, load)
+		return cached, CacheStale, nil
 	}
 
-	return obj, nil
+	// if not found in cache search, identical concurrent searches share the same call
+	obj, _ := m.coalesce(key, fetch, load).(*model.OfferList)
+	return obj, CacheMiss, nil
 }
 
-// resets Db
-func ResetDb() error {
-	return db.Reset()
+// replaces all offers in Db with the offers of datastoreFixturesFile - returns number of offers loaded
+func (m *Module) LoadFixturesDb() (int, error) {
+	return db.LoadFixtures(m.Store, m.Config.GetProperty("datastoreFixturesFile"))
 }
 
-// searches offers in Db
-func SearchOffersDb(r *model.ListRequest) (*model.OfferList, error) {
-	// validates request before querying marketplace
+// searches a page of offers in Db filtered by party name, upc and category search columns
+// the page is sorted if a sort field is set and the next page is fetched using the returned page state,
+// pages fetched by page state have unknown total and page counts unless the count search column is true
+func (m *Module) SearchOffersDb(r *model.ListRequest) (*model.OfferList, error) {
+	// validates request before querying Db
 	if !r.IsValid() {
 		return nil, errors.New(model.InvalidRequest)
 	}
 
-	list := model.NewOfferList(make([]model.Offer, 0, 40), 1, 1, 0)
-	var err error
-	list.List, err = db.GetOffers()
-	return list, err
+	// the datastore has no order by the sort columns so sortBy sorts the offers of the fetched page only,
+	// sorted listings can't jump to a page number as it would scan all the previous pages to sort one
+	if r.SortBy != "" && r.Page > 1 && r.PageState == "" {
+		return nil, errors.New(model.InvalidRequest)
+	}
+
+	params := r.Map()
+	q := db.NewOfferQuery(params[model.PartyName], params[model.Upc], params[model.Category], r.Page, r.RowsPerPage, r.PageState)
+	q.Count = q.Count || params[model.Count] == "true"
+	page, err := m.Store.QueryOffers(q)
+	if err != nil {
+		return nil, err
+	}
+
+	pageCount := db.UnknownCount
+	if page.TotalCount != db.UnknownCount {
+		pageCount = (page.TotalCount + r.RowsPerPage - 1) / r.RowsPerPage
+	}
+	if pageCount == 0 {
+		pageCount = 1
+	}
+
+	list := model.NewOfferList(page.Offers, r.Page, pageCount, page.TotalCount)
+	list.NextPageState = page.NextPageState
+
+	if r.SortBy != "" {
+		m.sortList(list, model.UnitedStates, "", r.SortBy, r.SortOrder == "asc")
+	}
+	return list, nil
 }
 
 // add offer to Db - returns offer with new id
-func AddOfferDb(r *model.Offer) (*model.Offer, error) {
-	// validates request before querying marketplace
-	if r.Name == "" {
+func (m *Module) AddOfferDb(r *model.Offer) (*model.Offer, error) {
+	// validates offer before storing it
+	if !r.IsValid(m.Providers.IsKnownParty) {
+		return nil, errors.New(model.InvalidRequest)
+	}
+
+	return m.Store.InsertOffer(r)
+}
+
+// gets offer from Db by id
+func (m *Module) GetOfferDb(id string) (*model.Offer, error) {
+	return m.Store.GetOffer(id)
+}
+
+// replaces offer in Db by id if version is still the current one - returns offer with new version
+func (m *Module) UpdateOfferDb(id string, r *model.Offer, version int64) (*model.Offer, error) {
+	if !r.IsValid(m.Providers.IsKnownParty) {
 		return nil, errors.New(model.InvalidRequest)
 	}
+	if version <= 0 {
+		return nil, errors.New(model.VersionRequired)
+	}
+
+	o, err := m.Store.GetOffer(id)
+	if err != nil {
+		return nil, err
+	}
 
-	return db.InsertOffer(r)
+	// keep creation date if not sent
+	r.Id = id
+	if r.Created.IsZero() {
+		r.Created = o.Created
+	}
+
+	return m.Store.UpdateOffer(r, version)
 }
 
-// Searches marketplace providers by keyword
-func searchOffers(m map[string]string) *model.OfferList {
-	log.Printf("Search: %v", m)
+// applies a JSON merge patch to offer in Db by id if version is still the current one - returns offer with new version
+func (m *Module) PatchOfferDb(id string, patch []byte, version int64) (*model.Offer, error) {
+	if version <= 0 {
+		return nil, errors.New(model.VersionRequired)
+	}
+
+	o, err := m.Store.GetOffer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// only fields present in patch are overwritten
+	if err := json.Unmarshal(patch, o); err != nil {
+		return nil, errors.New(model.InvalidRequest)
+	}
+	o.Id = id
+
+	if !o.IsValid(m.Providers.IsKnownParty) {
+		return nil, errors.New(model.InvalidRequest)
+	}
 
-	country := m[model.Country]
+	return m.Store.UpdateOffer(o, version)
+}
+
+// deletes offer from Db by id if version is still the current one
+func (m *Module) DeleteOfferDb(id string, version int64) error {
+	if version <= 0 {
+		return errors.New(model.VersionRequired)
+	}
+
+	return m.Store.DeleteOffer(id, version)
+}
+
+// Searches marketplace providers by keyword, each provider page is cached on its own so only providers missing
+// in cache are called. Returns ProvidersUnavailable along with the partial list if any provider failed, or
+// ProvidersThrottled if none failed but some were throttled. Providers without results are not partial.
+func (m *Module) searchOffers(params map[string]string) (*model.OfferList, error) {
+	log.Printf("Search: %v", params)
+
+	country := params[model.Country]
 	if country == "" {
 		country = model.UnitedStates
 	}
 
+	// search providers selected
+	providers := selectProviders(m.getProvidersByCountry(country), params)
+
 	// build empty response
-	rowsPerPage := int(config.GetIntProperty("defaultRowsPerPage"))
-	numProviders := config.CountMarketplaceProviderListSize()
-	capacity := numProviders * rowsPerPage
+	rowsPerPage := int(m.Config.GetIntProperty("defaultRowsPerPage"))
+	capacity := len(providers) * rowsPerPage
 	list := model.NewOfferList(make([]model.Offer, 0, capacity), 1, 1, 0)
 
-	// search providers
-	providers := getProvidersByCountry(country)
-
-	// create a slice of jobResult outputs
-	jobOutputs := make([]<-chan job.JobResult, 0)
+	// create a slice of pending provider searches
+	searches := make([]providerSearch, 0)
+	natives := make(map[string]map[string]bool)
 
 	for i := 0; i < len(providers); i++ {
-		job := search(providers[i], m)
+		s := providerSearch{key: pageCacheKey(providers[i], params, m.sortsResults(providers[i]))}
+
+		// use fresh provider page from cache, keep stale one as fallback if provider fails
+		if m.getCached(s.key, &s.stale) == CacheHit {
+			addNativeFacets(natives, providers[i], s.stale)
+			mergeSearchResponse(list, s.stale)
+			continue
+		}
+
+		job := m.search(providers[i], params)
 		if job != nil {
-			jobOutputs = append(jobOutputs, job.ReturnChannel)
+			s.provider, s.out = providers[i], job.ReturnChannel
+			searches = append(searches, s)
 			// Push each job onto the queue.
-			GetInstance().JobQueue <- *job
+			m.JobQueue <- *job
 		}
 	}
 
-	// Consume the merged output from all jobs
-	out := job.Merge(jobOutputs...)
-	for r := range out {
+	// Consume the output from all jobs, providers throttled or failing are merged from their stale page
+	failures, throttled := 0, 0
+	for _, s := range searches {
+		r := <-s.out
 		if r.Error == nil {
-			mergeSearchResponse(list, r.Value.(*model.OfferList))
+			page := r.Value.(*model.OfferList)
+			m.assignOfferIds(page.List, country)
+			m.setCachedFor(s.key, page, m.providerCacheExpiration(s.provider))
+			m.ingestOffers(page)
+			addNativeFacets(natives, s.provider, page)
+			mergeSearchResponse(list, page)
+			continue
 		}
+
+		if r.Error == monitor.ErrThrottled {
+			throttled++
+		} else {
+			log.Printf("search failed on %s: %s", s.provider, r.Error.Error())
+			failures++
+		}
+		addNativeFacets(natives, s.provider, s.stale)
+		mergeSearchResponse(list, s.stale)
 	}
 
+	// filter by the facet values selected and compute facets of results
+	m.applyFacets(list, params, natives)
+
 	// sort list
-	sortList(list, country, m[model.Name], m[model.SortBy], m[model.SortOrder] == "asc")
+	m.sortList(list, country, params[model.Name], params[model.SortBy], params[model.SortOrder] == "asc")
+
+	if failures > 0 {
+		return list, errors.New(model.ProvidersUnavailable)
+	}
+	if throttled > 0 {
+		return list, errors.New(model.ProvidersThrottled)
+	}
+	return list, nil
+}
 
-	return list
+// assigns the offers of a provider the ids derived from provider, external id and country,
+// providers leave ids empty so the same offer always has the same id
+func (m *Module) assignOfferIds(list []model.Offer, country string) {
+	refs := make([]model.OfferRef, 0, len(list))
+	for i := range list {
+		refs = append(refs, assignOfferId(&list[i], country))
+	}
+	m.putOfferRefs(refs)
+}
+
+// assigns the offer the id derived from its provider, external id and country, returns the reference of the id
+func assignOfferId(o *model.Offer, country string) model.OfferRef {
+	ref := model.NewOfferRef(o.PartyName, o.ExternalId, country)
+	o.Id = ref.Id
+	return *ref
+}
+
+// caches the refs of minted offer ids so offers can be fetched by id alone right away whether or not the catalog
+// is enabled, refs not cached yet are queued to be stored. refs of offers without external id are skipped
+func (m *Module) putOfferRefs(refs []model.OfferRef) {
+	pending := make([]model.OfferRef, 0, len(refs))
+	for _, ref := range refs {
+		if ref.ExternalId == "" {
+			continue
+		}
+		if _, err := m.refs.Get(ref.Id); err == nil {
+			continue
+		}
+		value, _ := json.Marshal(ref)
+		m.refs.Set(ref.Id, string(value), 0)
+		pending = append(pending, ref)
+	}
+
+	if len(pending) > 0 && !m.ingest(catalogItem{refs: pending}) {
+		m.forgetOfferRefs(pending)
+	}
+}
+
+// removes refs not stored from cache so they're queued again
+func (m *Module) forgetOfferRefs(refs []model.OfferRef) {
+	for _, ref := range refs {
+		m.refs.Delete(ref.Id)
+	}
+}
+
+// represents a search job in a provider with the key of its page in cache
+type providerSearch struct {
+	provider string
+	key      string
+	stale    *model.OfferList
+	out      <-chan job.JobResult
 }
 
 // sorts offer list by field
-func sortList(list *model.OfferList, country, keyword, sortBy string, asc bool) {
+func (m *Module) sortList(list *model.OfferList, country, keyword, sortBy string, asc bool) {
 	switch sortBy {
 
 	case model.Id:
@@ -179,57 +365,62 @@ func sortList(list *model.OfferList, country, keyword, sortBy string, asc bool)
 		if keyword != "" {
 			sortByBestResults(list, keyword)
 		} else {
-			sortGroupedByProvider(list, country)
+			m.sortGroupedByProvider(list, country)
 		}
 	}
 }
 
 // Groups the results in buckets with each provider appearing in the first group of results
-func sortGroupedByProvider(list *model.OfferList, country string) {
-	m := make(map[string][]model.Offer)
+func (m *Module) sortGroupedByProvider(list *model.OfferList, country string) {
+	buckets := make(map[string][]model.Offer)
 
 	// split providers into buckets
-	providers := getProvidersByCountry(country)
+	providers := m.getProvidersByCountry(country)
 	for _, p := range providers {
-		m[p] = make([]model.Offer, 0)
+		buckets[p] = make([]model.Offer, 0)
 	}
 
 	// fill each providers queue
+	remaining := 0
 	for _, o := range list.List {
-		m[o.PartyName] = append(m[o.PartyName], o)
+		if _, ok := buckets[o.PartyName]; ok {
+			buckets[o.PartyName] = append(buckets[o.PartyName], o)
+			remaining++
+		}
 	}
 
 	// shuffle each queue
 	for _, p := range providers {
-		src := m[p]
+		src := buckets[p]
 		dest := make([]model.Offer, len(src))
 		perm := rand.Perm(len(src))
 		for i, v := range perm {
 			dest[v] = src[i]
 		}
-		m[p] = dest
+		buckets[p] = dest
 	}
 
 	// create output list
 	listSorted := make([]model.Offer, 0)
 
-	// keep filling groups of items from each provider queue until empty
-	for i := 0; i < len(list.List); i++ {
+	// keep filling groups of items from each provider queue until all are empty
+	for remaining > 0 {
 
 		// fetch a random index of provider slice
 		idx := rand.Intn(len(providers))
 		p := providers[idx]
 
 		// pick first element from queue if not empty
-		if len(m[p]) > 0 {
-			offerList := m[p]
+		if len(buckets[p]) > 0 {
+			offerList := buckets[p]
 			o := offerList[0]
 
 			// append to output list
 			listSorted = append(listSorted, o)
 
 			// remove element from queue
-			m[p] = append(offerList[:0], offerList[1:]...)
+			buckets[p] = append(offerList[:0], offerList[1:]...)
+			remaining--
 		}
 
 		// remove provider from next round
@@ -237,7 +428,7 @@ func sortGroupedByProvider(list *model.OfferList, country string) {
 
 		// reset list of providers if all removed
 		if len(providers) == 0 {
-			providers = getProvidersByCountry(country)
+			providers = m.getProvidersByCountry(country)
 		}
 	}
 
@@ -340,86 +531,144 @@ func calculateKeywordsRank(keywords []string, o *model.Offer) (int, int, int) {
 func mergeSearchResponse(list *model.OfferList, list2 *model.OfferList) {
 	if list2 != nil && list2.TotalCount > 0 {
 		list.List = append(list.List, list2.List...)
+		list.Facets = model.MergeFacets(list.Facets, list2.Facets)
 		list.TotalCount += list2.TotalCount
 		list.PageCount += list2.PageCount
 	}
 }
 
+// checks if provider sorts its search pages by the sort params
+func (m *Module) sortsResults(provider string) bool {
+	p, ok := m.Providers[provider].(SortingProvider)
+	return ok && p.SortsResults()
+}
+
 // searches create a new Job to search in a provider that returns a OfferList channel
-func search(provider string, m map[string]string) *job.Job {
-	switch provider {
-	case model.Amazon:
-		return amazon.SearchOffers(m)
-	case model.Walmart:
-		return walmart.SearchOffers(m)
-	case model.BestBuy:
-		return bestbuy.SearchOffers(m)
-	case model.Ebay:
-		return ebay.SearchOffers(m)
+func (m *Module) search(provider string, params map[string]string) *job.Job {
+	if p, ok := m.Providers[provider]; ok {
+		return p.SearchOffers(params)
 	}
 
 	return nil
 }
 
-func getProvidersByCountry(country string) []string {
-	switch country {
-	case model.Canada:
-		return strings.Split(config.GetProperty("marketplaceProvidersCanada"), ",")
-	default:
-		return strings.Split(config.GetProperty("marketplaceProviders"), ",")
+// returns the marketplace providers configured for a country that are registered in this module,
+// followed by the providers serving the country on their own sorted by name
+func (m *Module) getProvidersByCountry(country string) []string {
+	providers := make([]string, 0)
+	listed := make(map[string]bool)
+	for _, p := range m.Config.MarketplaceProviders(country) {
+		if _, ok := m.Providers[p]; ok && !listed[p] {
+			providers = append(providers, p)
+			listed[p] = true
+		}
 	}
+
+	serving := make([]string, 0)
+	for name, p := range m.Providers {
+		if cp, ok := p.(CountryProvider); ok && !listed[name] && cp.ServesCountry(country) {
+			serving = append(serving, name)
+		}
+	}
+	sort.Strings(serving)
+
+	return append(providers, serving...)
 }
 
 // Gets Product Detail from marketplace provider by Id and IdType, fetching competitors prices using UPC
-func GetOfferDetail(r *model.DetailRequest) (*model.OfferDetail, error) {
+func (m *Module) GetOfferDetail(r *model.DetailRequest) (*model.OfferDetail, error) {
+	obj, _, err := m.getOfferDetailCached(r)
+	return obj, err
+}
+
+// Builds the detail request of an offer id observed in marketplace results, refs minted lately are read from
+// memory and others from the datastore, fails with NotFound if id was never observed
+func (m *Module) ResolveOfferId(id string) (*model.DetailRequest, error) {
+	ref := &model.OfferRef{}
+	value, err := m.refs.Get(id)
+	if err != nil || json.Unmarshal([]byte(value), ref) != nil {
+		if ref, err = m.Store.GetOfferRef(id); err != nil {
+			return nil, err
+		}
+	}
+	return model.NewDetailRequest(ref.ExternalId, model.Id, ref.PartyName, ref.Country), nil
+}
+
+// Gets Product Detail returning also the cache lookup result, stale entries are served while refreshed in background
+func (m *Module) getOfferDetailCached(r *model.DetailRequest) (*model.OfferDetail, string, error) {
 	// validate and transform request before querying marketplace
 	if !r.IsValid() {
-		return nil, errors.New(model.InvalidRequest)
+		return nil, CacheMiss, errors.New(model.InvalidRequest)
 	}
-	jsonReq, _ := json.Marshal(&r)
-	key := string(jsonReq)
 
 	// search first in cache
-	hash := xcrypto.GenerateSHA1(key)
-	cacheEnabled := isCacheEnabled()
-
-	var obj *model.OfferDetail
-	if cacheEnabled {
-		if data, _ := GetInstance().RedisCache.Get(hash); data != "" {
-			if err := json.Unmarshal([]byte(data), &obj); err != nil {
-				return nil, err
-			}
-			return obj, nil
+	key := detailCacheKey(r)
+
+	load := func() (interface{}, bool) {
+		var obj *model.OfferDetail
+		ok := m.getCached(key, &obj) == CacheHit
+		return obj, ok
+	}
+
+	// store in cache if possible, if source provider failed keep serving the stale entry
+	fetch := func() interface{} {
+		obj := m.getOfferDetail(r)
+		if obj != nil {
+			m.setCached(key, obj)
 		}
+		return obj
 	}
 
-	// store valid output in cache
-	obj = getDetail(r.Id, r.IdType, r.Source, r.Country)
+	var cached *model.OfferDetail
+	switch m.getCached(key, &cached) {
+	case CacheHit:
+		return cached, CacheHit, nil
+	case CacheStale:
+		m.refresh(key, fetch, load)
+		return cached, CacheStale, nil
+	}
+
+	// identical concurrent lookups share the same call
+	obj, _ := m.coalesce(key, fetch, load).(*model.OfferDetail)
+	return obj, CacheMiss, nil
+}
+
+// Gets Product Detail from source provider, if product has Upc fetches competitors details in parallel using worker pool jobs
+func (m *Module) getOfferDetail(r *model.DetailRequest) *model.OfferDetail {
+	obj := m.getDetail(r.Id, r.IdType, r.Source, r.Country)
+	if obj != nil {
+		m.putOfferRefs([]model.OfferRef{assignOfferId(&obj.Offer, r.Country)})
+		m.ingestDetail(obj)
+	}
 
 	// if product has Upc fetch competitors details in parallel using worker pool jobs
 	if obj != nil && obj.Offer.Upc != "" {
-		providers := getProvidersByCountry(r.Country)
+		providers := m.getProvidersByCountry(r.Country)
 
 		// create a slice of jobResult outputs
 		jobOutputs := make([]<-chan job.JobResult, 0)
 
 		for i := 0; i < len(providers); i++ {
 			if p := providers[i]; p != r.Source {
-				job := getDetailJob(obj.Offer.Upc, model.Upc, providers[i], r.Country)
+				job := m.getDetailJob(obj.Offer.Upc, model.Upc, providers[i], r.Country)
 				if job != nil {
 					jobOutputs = append(jobOutputs, job.ReturnChannel)
 					// Push each job onto the queue.
-					GetInstance().JobQueue <- *job
+					m.JobQueue <- *job
 				}
 			}
 		}
 
 		// Consume the merged output from all jobs
 		out := job.Merge(jobOutputs...)
+		country := r.Country
+		refs := make([]model.OfferRef, 0, len(jobOutputs))
 		for r := range out {
 			if r.Error == nil {
 				// build detail item
 				d := r.Value.(*model.OfferDetail)
+				refs = append(refs, assignOfferId(&d.Offer, country))
+				m.ingestDetail(d)
 
 				detItem := model.NewOfferDetailItem(
 					d.Offer.PartyName,
@@ -432,59 +681,29 @@ func GetOfferDetail(r *model.DetailRequest) (*model.OfferDetail, error) {
 				obj.ProductDetailItems = append(obj.ProductDetailItems, *detItem)
 			}
 		}
+		m.putOfferRefs(refs)
 	}
 
-	// store in cache if possible
-	if cacheEnabled && obj != nil {
-		data, err := json.Marshal(&obj)
-		if err != nil {
-			return nil, err
-		}
-
-		err = GetInstance().RedisCache.Set(hash, string(data))
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return obj, nil
+	return obj
 }
 
 // creates a job to fetch a product detail from a given source using id and idType and country
-func getDetailJob(id, idType, source, country string) *job.Job {
+func (m *Module) getDetailJob(id, idType, source, country string) *job.Job {
 	log.Printf("getDetail Job: %s, %s, %s, %s", id, idType, source, country)
 
-	switch source {
-	case model.Amazon:
-		return amazon.GetDetailJob(id, idType, country)
-	case model.Walmart:
-		return walmart.GetDetailJob(id, idType, country)
-	case model.BestBuy:
-		return bestbuy.GetDetailJob(id, idType, country)
-	case model.Ebay:
-		return ebay.GetDetailJob(id, idType, country)
+	if p, ok := m.Providers[source]; ok {
+		return p.GetDetailJob(id, idType, country)
 	}
 
 	return nil
 }
 
 // gets a product detail from a given source using id and idType and country
-func getDetail(id, idType, source, country string) *model.OfferDetail {
+func (m *Module) getDetail(id, idType, source, country string) *model.OfferDetail {
 	log.Printf("get: %s, %s, %s, %s", id, idType, source, country)
 
-	switch source {
-	case model.Amazon:
-		return amazon.GetOfferDetail(id, idType, country)
-	case model.Walmart:
-		return walmart.GetOfferDetail(id, idType, country)
-	case model.BestBuy:
-		return bestbuy.GetOfferDetail(id, idType, country)
-	case model.Ebay:
-		return ebay.GetOfferDetail(id, idType, country)
+	if p, ok := m.Providers[source]; ok {
+		return p.GetOfferDetail(id, idType, country)
 	}
 	return nil
 }
-
-func isCacheEnabled() bool {
-	return config.GetBoolProperty("cacheEnabled")
-}
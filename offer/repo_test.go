@@ -0,0 +1,387 @@
+package offer
+
+import (
+	"errors"
+	"github.com/guilhebl/go-offer/common/cache"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/guilhebl/go-worker-pool"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider returns a fixed offer for every call and counts the number of searches
+type fakeProvider struct {
+	name      string
+	delay     time.Duration
+	failing   int32
+	throttled int32
+	empty     bool
+	searches  int32
+}
+
+type fakeSearchTask struct {
+	p *fakeProvider
+}
+
+func (t *fakeSearchTask) Run(payload job.Payload) job.JobResult {
+	atomic.AddInt32(&t.p.searches, 1)
+	time.Sleep(t.p.delay)
+	if atomic.LoadInt32(&t.p.failing) == 1 {
+		return job.NewJobResult(nil, errors.New("error on search"))
+	}
+	if atomic.LoadInt32(&t.p.throttled) == 1 {
+		return job.NewJobResult(nil, monitor.ErrThrottled)
+	}
+	if t.p.empty {
+		return job.NewJobResult(model.NewOfferList(make([]model.Offer, 0), 1, 0, 0), nil)
+	}
+	o := model.NewOffer("1", "1", "", "offer "+payload.Params[model.Name], t.p.name, "", "", "", "", 10, 0, 0, time.Now())
+	return job.NewJobResult(model.NewOfferList([]model.Offer{*o}, 1, 1, 1), nil)
+}
+
+func (p *fakeProvider) SearchOffers(m map[string]string) *job.Job {
+	task := fakeSearchTask{p}
+	j := job.NewJob(&task, m, job.NewJobResultChannel())
+	return &j
+}
+
+func (p *fakeProvider) GetDetailJob(id, idType, country string) *job.Job {
+	return nil
+}
+
+func (p *fakeProvider) GetOfferDetail(id, idType, country string) *model.OfferDetail {
+	if idType != model.Id {
+		return nil
+	}
+	o := model.NewOffer("", id, "", "offer "+id, p.name, "", "", "", "", 10, 0, 0, time.Now())
+	return model.NewOfferDetail(*o, "", nil, nil)
+}
+
+// sortingProvider is a fake provider sorting its search pages
+type sortingProvider struct {
+	*fakeProvider
+}
+
+func (p sortingProvider) SortsResults() bool {
+	return true
+}
+
+// failingCache fails every operation as if the cache server was down
+type failingCache struct{}
+
+func (c failingCache) Get(key string) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func (c failingCache) Set(key, value string, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (c failingCache) Delete(key string) error {
+	return errors.New("connection refused")
+}
+
+func (c failingCache) DeletePattern(pattern string) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func (c failingCache) Stats() cache.Stats {
+	return cache.Stats{}
+}
+
+// lockedCache is a cache whose locks are always held by another instance
+type lockedCache struct {
+	*cache.LRUCache
+}
+
+func (c lockedCache) Lock(key string, ttl time.Duration) (string, bool, error) {
+	return "", false, nil
+}
+
+func (c lockedCache) Unlock(key, token string) error {
+	return nil
+}
+
+// builds a test module with a single fake provider and the given cache
+func newFakeProviderModule(t *testing.T, c cache.Cache) (*Module, *fakeProvider) {
+	m := newTestModule(t)
+	p := &fakeProvider{name: model.Walmart}
+	m.Config.SetProperty("marketplaceProviders", model.Walmart)
+	m.Providers = ProviderRegistry{model.Walmart: p}
+	m.Cache = c
+	return m, p
+}
+
+func newSearchRequest(keyword string) *model.ListRequest {
+	return model.NewListRequest([]model.NameValue{model.NewNameValue(model.Name, keyword)}, "", "", 1, 10)
+}
+
+// tests search results are cached and served from cache on next call
+func TestSearchOffersCached(t *testing.T) {
+	m, p := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+
+	for i := 0; i < 2; i++ {
+		list, err := m.SearchOffers(newSearchRequest("skyrim"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(list.List))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.searches))
+}
+
+// tests cache failures fall through to providers
+func TestSearchOffersCacheFailure(t *testing.T) {
+	m, p := newFakeProviderModule(t, failingCache{})
+
+	list, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	assert.Equal(t, "offer skyrim", list.List[0].Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.searches))
+}
+
+// tests identical concurrent searches call providers only once
+func TestSearchOffersCoalesced(t *testing.T) {
+	m, p := newFakeProviderModule(t, nil)
+	p.delay = 100 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list, err := m.SearchOffers(newSearchRequest("skyrim"))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(list.List))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.searches))
+}
+
+// tests a search locked by another instance waits for its cached result instead of calling providers
+func TestSearchOffersLockedByOtherInstance(t *testing.T) {
+	lru := cache.NewLRUCache(10, 60)
+	m, p := newFakeProviderModule(t, lockedCache{lru})
+	m.Config.SetProperty("cacheLockPollMillis", "10")
+
+	// simulate the lock holder storing its result
+	other, _ := newFakeProviderModule(t, lru)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		other.SearchOffers(newSearchRequest("skyrim"))
+	}()
+
+	list, err := m.SearchOffers(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	assert.Equal(t, "offer skyrim", list.List[0].Name)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&p.searches))
+}
+
+// tests stale entries are served right away and refreshed in background
+func TestSearchOffersStaleWhileRevalidate(t *testing.T) {
+	m, p := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.Config.SetProperty("cacheExpirationSeconds", "0")
+	m.Config.SetProperty("walmartCacheExpirationSeconds", "0")
+
+	_, status, _ := m.searchOffersCached(newSearchRequest("skyrim"))
+	assert.Equal(t, CacheMiss, status)
+
+	list, status, err := m.searchOffersCached(newSearchRequest("skyrim"))
+	assert.Nil(t, err)
+	assert.Equal(t, CacheStale, status)
+	assert.Equal(t, "offer skyrim", list.List[0].Name)
+
+	// wait for background refresh
+	for i := 0; i < 100 && atomic.LoadInt32(&p.searches) < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&p.searches))
+}
+
+// tests background refreshes are bounded and a key is refreshed once at a time
+func TestRefreshBounded(t *testing.T) {
+	m, _ := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.refreshes = make(chan struct{}, 1)
+
+	release := make(chan struct{})
+	var fetches int32
+	fetch := func() interface{} {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return nil
+	}
+	load := func() (interface{}, bool) { return nil, false }
+
+	m.refresh("a", fetch, load)
+	for i := 0; i < 100 && atomic.LoadInt32(&fetches) < 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// key a is being refreshed and no slot is left for key b
+	m.refresh("a", fetch, load)
+	m.refresh("b", fetch, load)
+	close(release)
+
+	for i := 0; i < 100 && len(m.refreshes) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	assert.Equal(t, 0, len(m.refreshes))
+}
+
+// tests stale entries keep being served while providers fail
+func TestSearchOffersStaleOnProviderFailure(t *testing.T) {
+	m, p := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.Config.SetProperty("cacheExpirationSeconds", "0")
+	m.SearchOffers(newSearchRequest("skyrim"))
+
+	atomic.StoreInt32(&p.failing, 1)
+	for i := 0; i < 3; i++ {
+		list, status, err := m.searchOffersCached(newSearchRequest("skyrim"))
+		assert.Nil(t, err)
+		assert.Equal(t, CacheStale, status)
+		assert.Equal(t, "offer skyrim", list.List[0].Name)
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// tests equivalent searches share the same cache entry and the canonical request is echoed back
+func TestSearchOffersCanonicalRequest(t *testing.T) {
+	m, p := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+
+	r1 := model.NewListRequest([]model.NameValue{
+		model.NewNameValue(model.Name, "Skyrim  skyrim "),
+		model.NewNameValue(model.Country, "US"),
+	}, "", "asc", 1, 10)
+	r2 := model.NewListRequest([]model.NameValue{
+		model.NewNameValue(model.Country, "usa"),
+		model.NewNameValue(model.Name, "skyrim"),
+	}, "", "", 1, 10)
+
+	m.SearchOffers(r1)
+	list, err := m.SearchOffers(r2)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.searches))
+
+	expected := []model.NameValue{model.NewNameValue(model.Country, model.UnitedStates), model.NewNameValue(model.Name, "skyrim")}
+	assert.Equal(t, expected, list.Request.SearchColumns)
+	assert.Equal(t, "", list.Request.SortOrder)
+}
+
+// tests provider pages are cached on their own so a new sort order reuses them and only failed providers are called again
+func TestSearchOffersProviderPagesCached(t *testing.T) {
+	m, walmart := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	bestbuy := &fakeProvider{name: model.BestBuy, failing: 1}
+	m.Providers[model.BestBuy] = bestbuy
+	m.Config.SetProperty("marketplaceProviders", model.Walmart+","+model.BestBuy)
+
+	r := newSearchRequest("skyrim")
+	list, _ := m.SearchOffers(r)
+	assert.Equal(t, 1, len(list.List))
+
+	// the partial search is served from cache until cachePartialExpirationSeconds elapse
+	atomic.StoreInt32(&bestbuy.failing, 0)
+	list, status, _ := m.searchOffersCached(r)
+	assert.Equal(t, CacheHit, status)
+	assert.Equal(t, 1, len(list.List))
+
+	r.SortBy, r.SortOrder = model.Price, "asc"
+	list, _ = m.SearchOffers(r)
+	assert.Equal(t, 2, len(list.List))
+
+	r.SortOrder = "desc"
+	list, _ = m.SearchOffers(r)
+	assert.Equal(t, 2, len(list.List))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&walmart.searches))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&bestbuy.searches))
+}
+
+// tests throttled providers are not failures, the partial search is cached for cachePartialExpirationSeconds
+func TestSearchOffersThrottledProvider(t *testing.T) {
+	m, _ := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	bestbuy := &fakeProvider{name: model.BestBuy, throttled: 1}
+	m.Providers[model.BestBuy] = bestbuy
+	m.Config.SetProperty("marketplaceProviders", model.Walmart+","+model.BestBuy)
+
+	list, err := m.searchOffers(newSearchRequest("skyrim").Canonical().Map())
+	assert.Equal(t, model.ProvidersThrottled, err.Error())
+	assert.Equal(t, 1, len(list.List))
+
+	m.Config.SetProperty("cachePartialExpirationSeconds", "0")
+	r := newSearchRequest("zelda")
+	list, status, err := m.searchOffersCached(r)
+	assert.Nil(t, err)
+	assert.Equal(t, CacheMiss, status)
+	assert.Equal(t, 1, len(list.List))
+
+	// the partial entry is stale at once and refreshed with the throttled provider
+	atomic.StoreInt32(&bestbuy.throttled, 0)
+	_, status, _ = m.searchOffersCached(r)
+	assert.Equal(t, CacheStale, status)
+	time.Sleep(50 * time.Millisecond)
+	list, _, _ = m.searchOffersCached(r)
+	assert.Equal(t, 2, len(list.List))
+}
+
+// tests providers without results are not failures and the search is cached as complete
+func TestSearchOffersEmptyProvider(t *testing.T) {
+	m, _ := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.Providers[model.BestBuy] = &fakeProvider{name: model.BestBuy, empty: true}
+	m.Config.SetProperty("marketplaceProviders", model.Walmart+","+model.BestBuy)
+	m.Config.SetProperty("cachePartialExpirationSeconds", "0")
+
+	list, err := m.searchOffers(newSearchRequest("skyrim").Canonical().Map())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list.List))
+
+	r := newSearchRequest("skyrim")
+	m.SearchOffers(r)
+	_, status, _ := m.searchOffersCached(r)
+	assert.Equal(t, CacheHit, status)
+}
+
+// tests pages of providers sorting their results are cached for each sort
+func TestSearchOffersSortedProviderPagesCached(t *testing.T) {
+	m, walmart := newFakeProviderModule(t, cache.NewLRUCache(10, 60))
+	m.Providers[model.Walmart] = sortingProvider{walmart}
+	bestbuy := &fakeProvider{name: model.BestBuy}
+	m.Providers[model.BestBuy] = bestbuy
+	m.Config.SetProperty("marketplaceProviders", model.Walmart+","+model.BestBuy)
+
+	r := newSearchRequest("skyrim")
+	m.SearchOffers(r)
+
+	r.SortBy, r.SortOrder = model.Price, "asc"
+	list, _ := m.SearchOffers(r)
+	assert.Equal(t, 2, len(list.List))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&walmart.searches))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&bestbuy.searches))
+}
+
+// tests facets of merged provider pages add up their counts
+func TestMergeSearchResponseFacets(t *testing.T) {
+	list := model.NewOfferList(make([]model.Offer, 0), 1, 1, 0)
+
+	page := model.NewOfferList(make([]model.Offer, 1), 1, 1, 1)
+	page.Facets = []model.Facet{model.NewFacet(model.Brand, []model.FacetValue{{Value: "Bethesda", Count: 3}})}
+	mergeSearchResponse(list, page)
+
+	page = model.NewOfferList(make([]model.Offer, 1), 1, 1, 1)
+	page.Facets = []model.Facet{
+		model.NewFacet(model.Brand, []model.FacetValue{{Value: "Funko", Count: 1}, {Value: "Bethesda", Count: 2}}),
+		model.NewFacet(model.Price, []model.FacetValue{{Value: "$10 - $25", Count: 4}}),
+	}
+	mergeSearchResponse(list, page)
+
+	expected := []model.Facet{
+		model.NewFacet(model.Brand, []model.FacetValue{{Value: "Bethesda", Count: 5}, {Value: "Funko", Count: 1}}),
+		model.NewFacet(model.Price, []model.FacetValue{{Value: "$10 - $25", Count: 4}}),
+	}
+	assert.Equal(t, expected, list.Facets)
+}
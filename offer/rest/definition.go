@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/http"
+)
+
+// Definition describes a marketplace REST/JSON API: its endpoints, query templates, auth header and the
+// paths of each offer field in the JSON responses. Definitions are YAML or JSON files, JSON being valid YAML.
+type Definition struct {
+	// party name of the marketplace, e.g. deals.example.com
+	Name     string `yaml:"name"`
+	Endpoint string `yaml:"endpoint"`
+
+	// logo file served from the local assets folder
+	Logo                      string   `yaml:"logo"`
+	ImageProxyRequired        bool     `yaml:"imageProxyRequired"`
+	RequestWaitIntervalMillis int      `yaml:"requestWaitIntervalMillis"`
+	PageSize                  int      `yaml:"pageSize"`
+	DefaultSearchQuery        []string `yaml:"defaultSearchQuery"`
+
+	Auth        *Auth    `yaml:"auth"`
+	Search      Request  `yaml:"search"`
+	DetailById  *Request `yaml:"detailById"`
+	DetailByUpc *Request `yaml:"detailByUpc"`
+	Fields      Fields   `yaml:"fields"`
+}
+
+// Auth is a header set in every request, its value is a template usually reading a secret from config
+type Auth struct {
+	Header string `yaml:"header"`
+	Value  string `yaml:"value"`
+}
+
+// Request is an API call: path, query and body are templates, Results is the path of the list of products
+// in the response, Total the path of the total count of results and Result the path of a single product
+type Request struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Query   map[string]string `yaml:"query"`
+	Body    string            `yaml:"body"`
+	Results string            `yaml:"results"`
+	Total   string            `yaml:"total"`
+	Result  string            `yaml:"result"`
+}
+
+// Fields are the paths of each offer field in a product of the response, e.g. images.0.url
+type Fields struct {
+	ExternalId  string `yaml:"externalId"`
+	Upc         string `yaml:"upc"`
+	Name        string `yaml:"name"`
+	Url         string `yaml:"url"`
+	Image       string `yaml:"image"`
+	Category    string `yaml:"category"`
+	Price       string `yaml:"price"`
+	Rating      string `yaml:"rating"`
+	NumReviews  string `yaml:"numReviews"`
+	Description string `yaml:"description"`
+
+	// max rating of the marketplace, ratings are rescaled to model.MaxRating when set
+	RatingScale float32 `yaml:"ratingScale"`
+}
+
+// reads a definition from a YAML or JSON file
+func LoadDefinition(path string) (*Definition, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var def Definition
+	if err := yaml.Unmarshal(b, &def); err != nil {
+		return nil, err
+	}
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// checks required fields and sets defaults
+func (d *Definition) validate() error {
+	if d.Name == "" || d.Endpoint == "" {
+		return errors.New("name and endpoint are required")
+	}
+	if d.Search.Path == "" || d.Fields.ExternalId == "" || d.Fields.Name == "" {
+		return errors.New("search path and externalId and name fields are required")
+	}
+	if d.PageSize <= 0 {
+		d.PageSize = 10
+	}
+	for _, r := range []*Request{&d.Search, d.DetailById, d.DetailByUpc} {
+		if r != nil && r.Method == "" {
+			r.Method = http.MethodGet
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// looks up a dot separated path in a decoded json value, array elements are selected by index
+// e.g. images.0.url, an empty path returns the value itself and a missing path returns nil
+func lookup(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// returns the list at path, nil if path is not a list
+func lookupList(v interface{}, path string) []interface{} {
+	list, _ := lookup(v, path).([]interface{})
+	return list
+}
+
+// returns the value at path as a string, numbers are formatted as decoded
+func lookupString(v interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	switch s := lookup(v, path).(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	case map[string]interface{}, []interface{}:
+		return ""
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+// returns the value at path as a number, numeric strings like "12.50" are parsed
+func lookupFloat(v interface{}, path string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(lookupString(v, path)), 64)
+	return f
+}
@@ -0,0 +1,330 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/guilhebl/go-strutil"
+	"github.com/guilhebl/go-worker-pool"
+	"html"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// template variables: {{keywords}}, {{page}}, {{pageSize}}, {{offset}}, {{country}}, {{countryCode}}, {{id}}
+// and {{config.<property>}} reading a property of the app config
+var templateVar = regexp.MustCompile(`\{\{\s*([\w.]+)\s*\}\}`)
+
+// Repo is a marketplace provider querying a REST/JSON API described by a Definition
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+	def     *Definition
+}
+
+// builds a new provider of the marketplace described by def using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client, def *Definition) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		def:     def,
+	}
+}
+
+// Creates Job for Searching offers and returns a Channel with jobResults
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewSearchTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Searches for offers using the search request of the definition
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(repo.def.Name) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil, monitor.ErrThrottled
+	}
+
+	// format vendor specific params
+	p := repo.filterParams(m)
+	page, err := strconv.Atoi(p[model.Page])
+	if err != nil || page < 1 {
+		log.Printf("format page error: %s", p[model.Page])
+		return nil, errors.New(model.InvalidRequest)
+	}
+	pageSize := repo.def.PageSize
+
+	vars := templateVars(p[model.Country])
+	vars[model.Keywords] = p[model.Keywords]
+	vars[model.Page] = strconv.Itoa(page)
+	vars["pageSize"] = strconv.Itoa(pageSize)
+	vars["offset"] = strconv.Itoa((page - 1) * pageSize)
+
+	body, err := repo.do(&repo.def.Search, vars)
+	if err != nil {
+		log.Printf("%s", err.Error())
+		return nil, err
+	}
+
+	items := lookupList(body, repo.def.Search.Results)
+	total := len(items)
+	if repo.def.Search.Total != "" {
+		total = int(lookupFloat(body, repo.def.Search.Total))
+	}
+	totalPages := (total + pageSize - 1) / pageSize
+
+	list := repo.buildSearchItemList(items)
+	return model.NewOfferList(list, page, totalPages, total), nil
+}
+
+// returns the template variables of country
+func templateVars(country string) map[string]string {
+	return map[string]string{
+		model.Country: model.NormalizeCountry(country),
+		"countryCode": model.CountryAlpha2(country),
+	}
+}
+
+// replaces the template variables of s
+func (repo *Repo) expand(s string, vars map[string]string) string {
+	return repo.expandEscaped(s, vars, nil)
+}
+
+// replaces the template variables of s escaping their values with escape
+func (repo *Repo) expandEscaped(s string, vars map[string]string, escape func(string) string) string {
+	return templateVar.ReplaceAllStringFunc(s, func(v string) string {
+		name := templateVar.FindStringSubmatch(v)[1]
+		value := vars[name]
+		if strings.HasPrefix(name, "config.") {
+			value = repo.config.GetProperty(strings.TrimPrefix(name, "config."))
+		}
+		if escape != nil {
+			value = escape(value)
+		}
+		return value
+	})
+}
+
+// escapes v as the content of a JSON string, body templates quote their string variables e.g. "q": "{{keywords}}"
+func jsonEscape(v string) string {
+	b, _ := json.Marshal(v)
+	return string(b[1 : len(b)-1])
+}
+
+// sends request r expanding its templates with vars, decoding the json response
+func (repo *Repo) do(r *Request, vars map[string]string) (interface{}, error) {
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(repo.expandEscaped(r.Body, vars, jsonEscape))
+	}
+
+	req, err := http.NewRequest(r.Method, strings.TrimSuffix(repo.def.Endpoint, "/")+repo.expandPath(r.Path, vars), body)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	for name, value := range r.Query {
+		if v := repo.expand(value, vars); v != "" {
+			q.Set(name, v)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a := repo.def.Auth; a != nil && a.Header != "" {
+		req.Header.Set(a.Header, repo.expand(a.Value, vars))
+	}
+	log.Printf("%s %s: %s", repo.def.Name, r.Method, req.URL.Path)
+
+	resp, err := repo.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s error: %d - %s", repo.def.Name, resp.StatusCode, req.URL.Path)
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// expands a path template escaping each variable as a path segment
+func (repo *Repo) expandPath(path string, vars map[string]string) string {
+	return repo.expandEscaped(path, vars, url.PathEscape)
+}
+
+func (repo *Repo) buildSearchItemList(items []interface{}) []model.Offer {
+	list := make([]model.Offer, 0)
+	proxyRequired := repo.def.ImageProxyRequired
+
+	for _, item := range items {
+		o := repo.buildOffer(item, proxyRequired)
+		list = append(list, *o)
+	}
+
+	return list
+}
+
+// maps a product of the response to an offer using the field paths of the definition
+func (repo *Repo) buildOffer(item interface{}, proxyRequired bool) *model.Offer {
+	f := repo.def.Fields
+
+	rating := float32(lookupFloat(item, f.Rating))
+	if f.RatingScale > 0 {
+		rating = rating * model.MaxRating / f.RatingScale
+	}
+
+	o := model.NewOffer(
+		"",
+		lookupString(item, f.ExternalId),
+		lookupString(item, f.Upc),
+		html.UnescapeString(lookupString(item, f.Name)),
+		repo.def.Name,
+		lookupString(item, f.Url),
+		repo.config.BuildImgUrlExternal(lookupString(item, f.Image), proxyRequired),
+		repo.config.BuildImgUrl(repo.def.Logo),
+		lookupString(item, f.Category),
+		float32(lookupFloat(item, f.Price)),
+		rating,
+		int(lookupFloat(item, f.NumReviews)),
+		time.Now(),
+	)
+	return o
+}
+
+// filters vendor specific params from generic offer model params
+func (repo *Repo) filterParams(m map[string]string) map[string]string {
+	p := make(map[string]string)
+
+	// get search keyword phrase, without keywords a random default query is searched
+	if m[model.Name] != "" {
+		p[model.Keywords] = m[model.Name]
+	} else {
+		p[model.Keywords] = repo.getRandomSearchQuery()
+	}
+
+	// get page - defaults to 1
+	if m[model.Page] != "" {
+		p[model.Page] = m[model.Page]
+	} else {
+		p[model.Page] = "1"
+	}
+
+	p[model.Country] = m[model.Country]
+	return p
+}
+
+// gets a random string inside the default search queries of the definition
+func (repo *Repo) getRandomSearchQuery() string {
+	keywords := repo.def.DefaultSearchQuery
+	if len(keywords) == 0 {
+		return ""
+	}
+	return keywords[rand.Intn(len(keywords))]
+}
+
+// Creates Job for fetching Product Detail and returns a Channel with jobResult
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
+	// convert to map for job to consume
+	m := make(map[string]string)
+	m["id"], m["idType"], m["country"] = id, idType, country
+
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewGetDetailTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Search for a specific product detail either by Id or Upc, lookups without a request in the definition return nil
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
+
+	var r *Request
+	switch idType {
+	case model.Id:
+		r = repo.def.DetailById
+	case model.Upc:
+		r = repo.def.DetailByUpc
+	}
+	if r == nil {
+		return nil
+	}
+
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(repo.def.Name) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil
+	}
+
+	vars := templateVars(country)
+	vars["id"] = id
+	body, err := repo.do(r, vars)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	if r.Results == "" {
+		item := lookup(body, r.Result)
+		if _, ok := item.(map[string]interface{}); !ok {
+			return nil
+		}
+		return repo.buildProductDetail(item)
+	}
+
+	// lookups returning a list pick the product with the upc, or the first one if upcs are not mapped
+	for _, item := range lookupList(body, r.Results) {
+		if idType != model.Upc || repo.def.Fields.Upc == "" || lookupString(item, repo.def.Fields.Upc) == id {
+			return repo.buildProductDetail(item)
+		}
+	}
+	return nil
+}
+
+func (repo *Repo) buildProductDetail(item interface{}) *model.OfferDetail {
+	o := repo.buildOffer(item, repo.def.ImageProxyRequired)
+
+	desc := lookupString(item, repo.def.Fields.Description)
+
+	attrs := make(map[string]string)
+	detItems := make([]model.OfferDetailItem, 0)
+	det := model.NewOfferDetail(
+		*o,
+		html.UnescapeString(strutil.FilterHtmlTags(desc)),
+		attrs,
+		detItems,
+	)
+
+	return det
+}
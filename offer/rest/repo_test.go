@@ -0,0 +1,161 @@
+package rest
+
+import (
+	"encoding/json"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serves the testdata fixtures of the sample definition, rejecting requests without the auth header
+func newDealsServer(t *testing.T) *httptest.Server {
+	serve := func(w http.ResponseWriter, file string) {
+		b, err := ioutil.ReadFile("testdata/" + file)
+		assert.Nil(t, err)
+		w.Write(b)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer TEST12345678" || r.URL.Query().Get("ship_to") != "AU" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/products/DX-20931":
+			serve(w, "deals_example_get_detail_by_id_response.json")
+		case r.URL.Path == "/products" && r.URL.Query().Get("gtin") == "093155171251":
+			serve(w, "deals_example_get_detail_by_upc_response.json")
+		case r.URL.Path == "/products" && r.URL.Query().Get("q") == "skyrim":
+			assert.Equal(t, "2", r.URL.Query().Get("page"))
+			assert.Equal(t, "10", r.URL.Query().Get("per_page"))
+			serve(w, "deals_example_search_response.json")
+		case r.URL.Path == "/products":
+			serve(w, "deals_example_search_no_results.json")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestRepo(t *testing.T, endpoint string) *Repo {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+
+	def, err := LoadDefinition("testdata/deals_example.yaml")
+	assert.Nil(t, err)
+	def.Endpoint = endpoint
+
+	m := monitor.NewRequestMonitor(c)
+	m.Register(def.Name, def.RequestWaitIntervalMillis)
+	return NewRepo(c, m, http.DefaultClient, def)
+}
+
+func TestLoadDefinition(t *testing.T) {
+	def, err := LoadDefinition("testdata/deals_example.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, "deals.example.com", def.Name)
+	assert.Equal(t, http.MethodGet, def.Search.Method)
+	assert.Equal(t, "{{keywords}}", def.Search.Query["q"])
+	assert.Equal(t, "images.0.url", def.Fields.Image)
+	assert.Equal(t, float32(10), def.Fields.RatingScale)
+
+	def = &Definition{Name: "deals.example.com", Endpoint: "https://api.deals.example.com"}
+	assert.NotNil(t, def.validate())
+}
+
+func TestLookup(t *testing.T) {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(`{"a":{"b":[{"c":"x"},{"c":12.5}]},"n":1005004531843040}`))
+	dec.UseNumber()
+	assert.Nil(t, dec.Decode(&v))
+
+	assert.Equal(t, "x", lookupString(v, "a.b.0.c"))
+	assert.Equal(t, 12.5, lookupFloat(v, "a.b.1.c"))
+	assert.Equal(t, "1005004531843040", lookupString(v, "n"))
+	assert.Equal(t, 2, len(lookupList(v, "a.b")))
+	assert.Nil(t, lookup(v, "a.b.2.c"))
+	assert.Nil(t, lookup(v, "a.x.c"))
+	assert.Equal(t, "", lookupString(v, "a"))
+}
+
+func TestSearch(t *testing.T) {
+	server := newDealsServer(t)
+	defer server.Close()
+	repo := newTestRepo(t, server.URL)
+
+	list, err := repo.search(map[string]string{model.Name: "skyrim", model.Page: "2", model.Country: "aus"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(list.List))
+	assert.Equal(t, 23, list.Summary.TotalCount)
+	assert.Equal(t, 3, list.Summary.PageCount)
+
+	o := list.List[0]
+	assert.Equal(t, "DX-20931", o.ExternalId)
+	assert.Equal(t, "093155171251", o.Upc)
+	assert.Equal(t, "deals.example.com", o.PartyName)
+	assert.Equal(t, "https://deals.example.com/p/DX-20931?ref=TEST12345678", o.SemanticName)
+	assert.Equal(t, "https://cdn.deals.example.com/img/DX-20931-1.jpg", o.MainImageFileUrl)
+	assert.Equal(t, "Video Games", o.ProductCategory)
+	assert.Equal(t, float32(39.95), o.Price)
+	assert.Equal(t, float32(4.3), o.Rating)
+	assert.Equal(t, 212, o.NumReviews)
+	assert.True(t, o.IsValid(func(name string) bool { return name == repo.def.Name }))
+
+	list, _ = repo.search(map[string]string{model.Name: "nothing", model.Country: "aus"})
+	assert.Equal(t, 0, len(list.List))
+}
+
+func TestGetOfferDetail(t *testing.T) {
+	server := newDealsServer(t)
+	defer server.Close()
+	repo := newTestRepo(t, server.URL)
+
+	d := repo.GetOfferDetail("DX-20931", model.Id, "aus")
+	assert.NotNil(t, d)
+	assert.Equal(t, "093155171251", d.Offer.Upc)
+	assert.True(t, strings.HasPrefix(d.Description, "Winner of more than 200 Game of the Year Awards"))
+
+	d = repo.GetOfferDetail("093155171251", model.Upc, "aus")
+	assert.NotNil(t, d)
+	assert.Equal(t, "DX-20931", d.Offer.ExternalId)
+
+	assert.Nil(t, repo.GetOfferDetail("DX-99999", model.Id, "aus"))
+	assert.Nil(t, repo.GetOfferDetail("065857174434", model.Upc, "aus"))
+}
+
+// tests values expanded in a json body are escaped so keywords can't break out of their string
+func TestSearchBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, `skyrim "special", edition`, body["q"])
+		assert.Equal(t, float64(2), body["page"])
+		assert.Equal(t, "TEST12345678", body["key"])
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		b, err := ioutil.ReadFile("testdata/deals_example_search_response.json")
+		assert.Nil(t, err)
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	repo := newTestRepo(t, server.URL)
+	repo.def.Auth = nil
+	repo.def.Search = Request{
+		Method:  http.MethodPost,
+		Path:    "/products/search",
+		Body:    `{"q": "{{keywords}}", "page": {{page}}, "key": "{{config.dealsExampleApiKey}}"}`,
+		Results: "data.items",
+	}
+
+	list, err := repo.search(map[string]string{model.Name: `skyrim "special", edition`, model.Page: "2"})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(list.List))
+}
@@ -0,0 +1,21 @@
+package rest
+
+import "github.com/guilhebl/go-worker-pool"
+
+// Executable Task implementation for search
+type SearchTask struct {
+	repo *Repo
+}
+
+func (t *SearchTask) Run(payload job.Payload) job.JobResult {
+	r, err := t.repo.search(payload.Params)
+	if err != nil {
+		return job.NewJobResult(nil, err)
+	}
+
+	return job.NewJobResult(r, nil)
+}
+
+func NewSearchTask(repo *Repo) SearchTask {
+	return SearchTask{repo: repo}
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/guilhebl/go-offer/common/util"
 )
 
-func NewRouter() *mux.Router {
+func NewRouter(routes Routes) *mux.Router {
 
 	router := mux.NewRouter().StrictSlash(true)
 	for _, route := range routes {
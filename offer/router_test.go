@@ -9,13 +9,17 @@ import (
 
 // tests if router is built correctly and routes to the right paths
 func TestNewRouter(t *testing.T) {
-	router := NewRouter()
+	router := newTestModule(t).Router
 	if router == nil {
 		t.Error("Error while creating Router")
 	}
 	testRoute(t, router, "Index", "/", "GET")
 	testRoute(t, router, "Search", "/offers", "POST")
 	testRoute(t, router, "Show", "/offers/", "GET")
+	testRoute(t, router, "CatalogOffer", "/catalog/", "GET")
+	testRoute(t, router, "CacheStats", "/admin/cache/stats", "GET")
+	testRoute(t, router, "CacheInvalidate", "/admin/cache", "DELETE")
+	testRoute(t, router, "CacheWarm", "/admin/cache/warm", "POST")
 }
 
 func testRoute(t *testing.T, router *mux.Router, name, regex, methodName string) {
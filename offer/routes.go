@@ -11,41 +11,104 @@ type Route struct {
 
 type Routes []Route
 
-var routes = Routes{
-	Route{
-		"Index",
-		"GET",
-		"/",
-		Index,
-	},
-	Route{
-		"Search",
-		"POST",
-		"/offers",
-		Search,
-	},
-	Route{
-		"Offers",
-		"GET",
-		"/offerlist",
-		SearchDatastore,
-	},
-	Route{
-		"AddOffer",
-		"POST",
-		"/offerlist",
-		AddOffer,
-	},
-	Route{
-		"Reset",
-		"GET",
-		"/reset",
-		ResetDatastore,
-	},
-	Route{
-		"Show",
-		"GET",
-		"/offers/{id}",
-		Show,
-	},
+// returns the routes of this module mapped to its action handlers
+func (m *Module) routes() Routes {
+	return Routes{
+		Route{
+			"Index",
+			"GET",
+			"/",
+			m.Index,
+		},
+		Route{
+			"Search",
+			"POST",
+			"/offers",
+			m.Search,
+		},
+		Route{
+			"Offers",
+			"GET",
+			"/offerlist",
+			m.SearchDatastore,
+		},
+		Route{
+			"AddOffer",
+			"POST",
+			"/offerlist",
+			m.AddOffer,
+		},
+		Route{
+			"GetOffer",
+			"GET",
+			"/offerlist/{id}",
+			m.GetOffer,
+		},
+		Route{
+			"UpdateOffer",
+			"PUT",
+			"/offerlist/{id}",
+			m.UpdateOffer,
+		},
+		Route{
+			"PatchOffer",
+			"PATCH",
+			"/offerlist/{id}",
+			m.PatchOffer,
+		},
+		Route{
+			"DeleteOffer",
+			"DELETE",
+			"/offerlist/{id}",
+			m.DeleteOffer,
+		},
+		Route{
+			"Show",
+			"GET",
+			"/offers/{id}",
+			m.Show,
+		},
+		Route{
+			"CatalogOffer",
+			"GET",
+			"/catalog/{source}/{externalId}",
+			m.CatalogOffer,
+		},
+		Route{
+			"CacheStats",
+			"GET",
+			"/admin/cache/stats",
+			m.requireAdmin(m.CacheStats),
+		},
+		Route{
+			"CacheSearchEntry",
+			"POST",
+			"/admin/cache/offers",
+			m.requireAdmin(m.CacheSearchEntry),
+		},
+		Route{
+			"CacheDetailEntry",
+			"GET",
+			"/admin/cache/offers/{id}",
+			m.requireAdmin(m.CacheDetailEntry),
+		},
+		Route{
+			"CacheInvalidate",
+			"DELETE",
+			"/admin/cache",
+			m.requireAdmin(m.CacheInvalidate),
+		},
+		Route{
+			"CacheWarm",
+			"POST",
+			"/admin/cache/warm",
+			m.requireAdmin(m.CacheWarm),
+		},
+		Route{
+			"LoadFixtures",
+			"POST",
+			"/admin/datastore/fixtures",
+			m.requireAdmin(m.LoadDatastoreFixtures),
+		},
+	}
 }
@@ -0,0 +1,72 @@
+package target
+
+// Product is a Target item identified by its tcin
+type Product struct {
+	Tcin              string             `json:"tcin"`
+	Item              Item               `json:"item"`
+	Price             *Price             `json:"price"`
+	RatingsAndReviews *RatingsAndReviews `json:"ratings_and_reviews"`
+}
+
+// Item holds the description, identifiers and classification of a product
+type Item struct {
+	Dpci                  string                `json:"dpci"`
+	PrimaryBarcode        string                `json:"primary_barcode"`
+	PrimaryBrand          *Brand                `json:"primary_brand"`
+	ProductDescription    ProductDescription    `json:"product_description"`
+	Enrichment            Enrichment            `json:"enrichment"`
+	ProductClassification ProductClassification `json:"product_classification"`
+	ProductVendors        []Vendor              `json:"product_vendors"`
+}
+
+// Brand of a product
+type Brand struct {
+	Name string `json:"name"`
+}
+
+// ProductDescription holds the title and descriptions of a product, texts may contain html entities and tags
+type ProductDescription struct {
+	Title                 string   `json:"title"`
+	DownstreamDescription string   `json:"downstream_description"`
+	BulletDescriptions    []string `json:"bullet_descriptions"`
+}
+
+// Enrichment holds the product page url and images of a product
+type Enrichment struct {
+	BuyUrl string `json:"buy_url"`
+	Images struct {
+		PrimaryImageUrl    string   `json:"primary_image_url"`
+		AlternateImageUrls []string `json:"alternate_image_urls"`
+	} `json:"images"`
+}
+
+// ProductClassification holds the product type and item type of a product
+type ProductClassification struct {
+	ProductTypeName string `json:"product_type_name"`
+	ItemType        struct {
+		Name string `json:"name"`
+	} `json:"item_type"`
+}
+
+// Vendor is a manufacturer or distributor of a product
+type Vendor struct {
+	VendorName string `json:"vendor_name"`
+}
+
+// Price holds the current and regular retail price of a product, products with variations have a price range
+type Price struct {
+	CurrentRetail         float32 `json:"current_retail"`
+	CurrentRetailMin      float32 `json:"current_retail_min"`
+	RegRetail             float32 `json:"reg_retail"`
+	FormattedCurrentPrice string  `json:"formatted_current_price"`
+}
+
+// RatingsAndReviews holds the reviews statistics of a product
+type RatingsAndReviews struct {
+	Statistics struct {
+		Rating struct {
+			Average float32 `json:"average"`
+			Count   int     `json:"count"`
+		} `json:"rating"`
+	} `json:"statistics"`
+}
@@ -0,0 +1,314 @@
+package target
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/guilhebl/go-strutil"
+	"github.com/guilhebl/go-worker-pool"
+	"html"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is the Target marketplace provider, it holds the dependencies required to query the Target API
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+}
+
+// builds a new Target provider using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+	}
+}
+
+// Creates Job for Searching offers from Target and returns a Channel with jobResults
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewSearchTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Searches for offers from target
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(model.Target) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil, monitor.ErrThrottled
+	}
+
+	// format vendor specific params
+	p := repo.filterParams(m)
+	page, err := strconv.Atoi(p[model.Page])
+	if err != nil || page < 1 {
+		log.Printf("format page error: %s", p[model.Page])
+		return nil, errors.New(model.InvalidRequest)
+	}
+	pageSize := repo.config.GetIntProperty("targetDefaultPageSize")
+
+	var entity SearchResponse
+	if err := repo.searchProducts(p[model.Keywords], pageSize, (page-1)*pageSize, &entity); err != nil {
+		log.Printf("%s", err.Error())
+		return nil, err
+	}
+	return repo.buildSearchResponse(&entity, page, pageSize), nil
+}
+
+// gets a page of products matching keywords
+func (repo *Repo) searchProducts(keywords string, count, offset int, entity *SearchResponse) error {
+	endpoint := fmt.Sprintf("%s/%s", repo.config.GetProperty("targetEndpoint"), repo.config.GetProperty("targetProductSearchPath"))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	q := repo.baseQuery(req)
+	q.Add("keyword", keywords)
+	q.Add("count", strconv.Itoa(count))
+	q.Add("offset", strconv.Itoa(offset))
+	req.URL.RawQuery = q.Encode()
+	log.Printf("Target search: %s", req.URL.String())
+
+	return repo.do(req, entity)
+}
+
+// sets the params common to every request
+func (repo *Repo) baseQuery(req *http.Request) url.Values {
+	req.Header.Set("Accept", "application/json")
+	q := req.URL.Query()
+	q.Add("key", repo.config.GetProperty("targetApiKey"))
+	q.Add("channel", repo.config.GetProperty("targetChannel"))
+	q.Add("pricing_store_id", repo.config.GetProperty("targetPricingStoreId"))
+	return q
+}
+
+// sends req decoding the json response into entity
+func (repo *Repo) do(req *http.Request, entity interface{}) error {
+	resp, err := repo.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("target error: %d - %s", resp.StatusCode, req.URL.Path)
+	}
+	return json.NewDecoder(resp.Body).Decode(entity)
+}
+
+// builds Offer list response mapping from vendor specific params
+func (repo *Repo) buildSearchResponse(r *SearchResponse, page, pageSize int) *model.OfferList {
+	meta := r.Data.Search.SearchResponse.Metadata
+	totalPages := meta.TotalPages
+	if totalPages == 0 && pageSize > 0 {
+		totalPages = (meta.TotalResults + pageSize - 1) / pageSize
+	}
+
+	list := repo.buildSearchItemList(r.Data.Search.Products)
+	o := model.NewOfferList(list, page, totalPages, meta.TotalResults)
+	return o
+}
+
+func (repo *Repo) buildSearchItemList(items []Product) []model.Offer {
+	list := make([]model.Offer, 0)
+	proxyRequired := repo.config.IsProxyRequired(model.Target)
+
+	for _, item := range items {
+		o := repo.buildOffer(&item, proxyRequired)
+		list = append(list, *o)
+	}
+
+	return list
+}
+
+func (repo *Repo) buildOffer(p *Product, proxyRequired bool) *model.Offer {
+	price := float32(0.0)
+	if p.Price != nil {
+		price = p.Price.CurrentRetail
+		if price == 0 {
+			price = p.Price.CurrentRetailMin
+		}
+	}
+
+	rating, numReviews := float32(0.0), 0
+	if p.RatingsAndReviews != nil {
+		rating = p.RatingsAndReviews.Statistics.Rating.Average
+		numReviews = p.RatingsAndReviews.Statistics.Rating.Count
+	}
+
+	category := p.Item.ProductClassification.ItemType.Name
+	if category == "" {
+		category = p.Item.ProductClassification.ProductTypeName
+	}
+
+	o := model.NewOffer(
+		"",
+		p.Tcin,
+		p.Item.PrimaryBarcode,
+		html.UnescapeString(p.Item.ProductDescription.Title),
+		model.Target,
+		repo.buildAffiliateUrl(p.Item.Enrichment.BuyUrl),
+		repo.config.BuildImgUrlExternal(p.Item.Enrichment.Images.PrimaryImageUrl, proxyRequired),
+		repo.config.BuildImgUrl("target-logo.png"),
+		category,
+		price,
+		rating,
+		numReviews,
+		time.Now(),
+	)
+	if p.Item.PrimaryBrand != nil {
+		o.Brand = html.UnescapeString(p.Item.PrimaryBrand.Name)
+	}
+	return o
+}
+
+// appends the affiliate id to a product page url
+func (repo *Repo) buildAffiliateUrl(buyUrl string) string {
+	affiliateId := repo.config.GetProperty("targetAffiliateId")
+	if buyUrl == "" || affiliateId == "" {
+		return buyUrl
+	}
+
+	sep := "?"
+	if strings.Contains(buyUrl, "?") {
+		sep = "&"
+	}
+	return buyUrl + sep + "afid=" + url.QueryEscape(affiliateId)
+}
+
+// filters vendor specific params from generic offer model params
+func (repo *Repo) filterParams(m map[string]string) map[string]string {
+	p := make(map[string]string)
+
+	// get search keyword phrase
+	if m[model.Name] != "" {
+		p[model.Keywords] = m[model.Name]
+	} else {
+		// target does not have a trending api so we need to fetch random query searches
+		p[model.Keywords] = repo.getRandomSearchQuery()
+	}
+
+	// get page - defaults to 1
+	if m[model.Page] != "" {
+		p[model.Page] = m[model.Page]
+	} else {
+		p[model.Page] = "1"
+	}
+	return p
+}
+
+// gets a random string inside an array of strings of queries
+func (repo *Repo) getRandomSearchQuery() string {
+	query := repo.config.GetProperty("targetDefaultSearchQuery")
+	keywords := strings.Split(query, ",")
+	i := rand.Intn(len(keywords))
+	return keywords[i]
+}
+
+// Creates Job for fetching Product Detail and returns a Channel with jobResult
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
+	// convert to map for job to consume
+	m := make(map[string]string)
+	m["id"], m["idType"], m["country"] = id, idType, country
+
+	// create output channel
+	out := job.NewJobResultChannel()
+
+	// let's create a job with the payload
+	task := NewGetDetailTask(repo)
+	job := job.NewJob(&task, m, out)
+	return &job
+}
+
+// Search for a specific product detail either by Id (tcin) or Upc
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
+
+	// try to acquire lock from request Monitor
+	if !repo.monitor.IsServiceAvailable(model.Target) {
+		log.Printf("Unable to acquire lock from Request Monitor")
+		return nil
+	}
+
+	switch idType {
+	case model.Id:
+		endpoint := fmt.Sprintf("%s/%s", repo.config.GetProperty("targetEndpoint"), repo.config.GetProperty("targetProductDetailPath"))
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return nil
+		}
+		q := repo.baseQuery(req)
+		q.Add("tcin", id)
+		req.URL.RawQuery = q.Encode()
+		log.Printf("Target get: %s", req.URL.String())
+
+		var entity ProductDetailResponse
+		if err := repo.do(req, &entity); err != nil {
+			log.Println(err)
+			return nil
+		}
+		if entity.Data.Product == nil {
+			return nil
+		}
+		return repo.buildProductDetail(entity.Data.Product)
+
+	case model.Upc:
+		// products are looked up by tcin only, so upc lookups search for the upc and pick the product with that barcode
+		var entity SearchResponse
+		if err := repo.searchProducts(id, repo.config.GetIntProperty("targetDefaultPageSize"), 0, &entity); err != nil {
+			log.Println(err)
+			return nil
+		}
+		for _, p := range entity.Data.Search.Products {
+			if p.Item.PrimaryBarcode == id {
+				return repo.buildProductDetail(&p)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (repo *Repo) buildProductDetail(p *Product) *model.OfferDetail {
+	o := repo.buildOffer(p, repo.config.IsProxyRequired(model.Target))
+
+	attrs := make(map[string]string)
+	if p.Item.PrimaryBrand != nil && p.Item.PrimaryBrand.Name != "" {
+		attrs[model.Brand] = html.UnescapeString(p.Item.PrimaryBrand.Name)
+	}
+	if len(p.Item.ProductVendors) > 0 && p.Item.ProductVendors[0].VendorName != "" {
+		attrs[model.Manufacturer] = p.Item.ProductVendors[0].VendorName
+	}
+
+	desc := p.Item.ProductDescription.DownstreamDescription
+	if desc == "" {
+		desc = strings.Join(p.Item.ProductDescription.BulletDescriptions, " ")
+	}
+
+	detItems := make([]model.OfferDetailItem, 0)
+	det := model.NewOfferDetail(
+		*o,
+		html.UnescapeString(strutil.FilterHtmlTags(desc)),
+		attrs,
+		detItems,
+	)
+
+	return det
+}
@@ -0,0 +1,153 @@
+package target
+
+import (
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// serves the testdata responses of the search and detail paths, searches are answered by keyword
+func newTargetServer(t *testing.T, query *url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.Query()
+
+		var file string
+		switch r.URL.Path {
+		case "/plp_search_v2":
+			switch query.Get("keyword") {
+			case "skyrim":
+				file = "target_sample_search_response.json"
+			case "065857174434":
+				file = "target_sample_get_detail_by_upc_response.json"
+			case "123456789":
+				file = "target_get_by_upc_not_found.json"
+			default:
+				file = "target_search_no_results.json"
+			}
+		case "/pdp_client_v1":
+			if query.Get("tcin") != "50833567" {
+				http.NotFound(w, r)
+				return
+			}
+			file = "target_sample_get_detail_by_id_response.json"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := ioutil.ReadFile("testdata/" + file)
+		assert.Nil(t, err)
+		w.Write(data)
+	}))
+}
+
+// builds a Target provider querying server
+func newTestRepo(t *testing.T, server *httptest.Server) *Repo {
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	c.SetProperty("targetEndpoint", server.URL)
+	return NewRepo(c, monitor.NewRequestMonitor(c), http.DefaultClient)
+}
+
+// tests search params are sent to the api and products mapped into offers
+func TestSearch(t *testing.T) {
+	var query url.Values
+	server := newTargetServer(t, &query)
+	defer server.Close()
+	repo := newTestRepo(t, server)
+
+	list, err := repo.search(map[string]string{model.Name: "skyrim", model.Page: "2"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "skyrim", query.Get("keyword"))
+	assert.Equal(t, "10", query.Get("count"))
+	assert.Equal(t, "10", query.Get("offset"))
+	assert.Equal(t, "TEST12345678", query.Get("key"))
+	assert.Equal(t, "WEB", query.Get("channel"))
+	assert.Equal(t, "TEST12345678", query.Get("pricing_store_id"))
+
+	assert.Equal(t, 2, list.Page)
+	assert.Equal(t, 1, list.PageCount)
+	assert.Equal(t, 5, list.TotalCount)
+	assert.Equal(t, 5, len(list.List))
+
+	o := list.List[0]
+	assert.Equal(t, "", o.Id)
+	assert.Equal(t, "50833567", o.ExternalId)
+	assert.Equal(t, "093155171251", o.Upc)
+	assert.Equal(t, "The Elder Scrolls V: Skyrim Special Edition - PlayStation 4", o.Name)
+	assert.Equal(t, model.Target, o.PartyName)
+	assert.Equal(t, "https://www.target.com/p/the-elder-scrolls-v-skyrim-special-edition-playstation-4/-/A-50833567?afid=TEST12345678", o.SemanticName)
+	assert.Equal(t, "Video Games", o.ProductCategory)
+	assert.Equal(t, float32(19.99), o.Price)
+	assert.Equal(t, float32(4.7), o.Rating)
+	assert.Equal(t, 312, o.NumReviews)
+
+	assert.Equal(t, "Books", list.List[4].ProductCategory)
+}
+
+// tests searches without results return an empty list and invalid pages fail
+func TestSearchNoResults(t *testing.T) {
+	var query url.Values
+	server := newTargetServer(t, &query)
+	defer server.Close()
+	repo := newTestRepo(t, server)
+
+	list, err := repo.search(map[string]string{model.Name: "xxxccasd"})
+	assert.Nil(t, err)
+	assert.Equal(t, "0", query.Get("offset"))
+	assert.Equal(t, 0, list.TotalCount)
+	assert.Equal(t, 0, list.PageCount)
+	assert.Empty(t, list.List)
+
+	list, err = repo.search(map[string]string{model.Name: "skyrim", model.Page: "0"})
+	assert.Nil(t, list)
+	assert.Equal(t, model.InvalidRequest, err.Error())
+}
+
+// tests product details are fetched by tcin with their description and attributes
+func TestGetOfferDetailById(t *testing.T) {
+	var query url.Values
+	server := newTargetServer(t, &query)
+	defer server.Close()
+	repo := newTestRepo(t, server)
+
+	d := repo.GetOfferDetail("50833567", model.Id, model.UnitedStates)
+	assert.NotNil(t, d)
+	assert.Equal(t, "50833567", query.Get("tcin"))
+	assert.Equal(t, "50833567", d.Offer.ExternalId)
+	assert.Equal(t, "065857174434", d.Offer.Upc)
+	assert.Equal(t, "The Elder Scrolls V: Skyrim Special Edition - PlayStation 4", d.Offer.Name)
+	assert.Equal(t, float32(19.99), d.Offer.Price)
+	assert.Contains(t, d.Description, "Winner of more than 200 Game of the Year Awards")
+	assert.ElementsMatch(t, []model.NameValue{
+		model.NewNameValue(model.Brand, "Bethesda"),
+		model.NewNameValue(model.Manufacturer, "Bethesda Softworks"),
+	}, d.Attributes)
+
+	assert.Nil(t, repo.GetOfferDetail("1", model.Id, model.UnitedStates))
+}
+
+// tests product details are looked up by upc searching for the product with that barcode
+func TestGetOfferDetailByUpc(t *testing.T) {
+	var query url.Values
+	server := newTargetServer(t, &query)
+	defer server.Close()
+	repo := newTestRepo(t, server)
+
+	d := repo.GetOfferDetail("065857174434", model.Upc, model.UnitedStates)
+	assert.NotNil(t, d)
+	assert.Equal(t, "065857174434", query.Get("keyword"))
+	assert.Equal(t, "52160342", d.Offer.ExternalId)
+	assert.Equal(t, "065857174434", d.Offer.Upc)
+	assert.Equal(t, "The Elder Scrolls V: Skyrim Special Edition - PlayStation 4 (Greatest Hits)", d.Offer.Name)
+	assert.Equal(t, 141, d.Offer.NumReviews)
+
+	assert.Nil(t, repo.GetOfferDetail("123456789", model.Upc, model.UnitedStates))
+}
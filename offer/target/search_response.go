@@ -0,0 +1,37 @@
+package target
+
+// SearchResponse is the response of a plp search, products matching keywords
+type SearchResponse struct {
+	Data struct {
+		Search struct {
+			SearchResponse struct {
+				Metadata SearchMetadata `json:"typed_metadata"`
+			} `json:"search_response"`
+			Products []Product `json:"products"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []ErrorData `json:"errors"`
+}
+
+// SearchMetadata holds the pagination of a search
+type SearchMetadata struct {
+	Count        int    `json:"count"`
+	CurrentPage  int    `json:"current_page"`
+	Offset       int    `json:"offset"`
+	TotalPages   int    `json:"total_pages"`
+	TotalResults int    `json:"total_results"`
+	Keyword      string `json:"keyword"`
+}
+
+// ProductDetailResponse is the response of a pdp lookup by tcin
+type ProductDetailResponse struct {
+	Data struct {
+		Product *Product `json:"product"`
+	} `json:"data"`
+	Errors []ErrorData `json:"errors"`
+}
+
+// ErrorData is an error returned by the API
+type ErrorData struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,12 @@
+package walmart
+
+type Facet struct {
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	FacetValues []FacetValue `json:"facetValues"`
+}
+
+type FacetValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
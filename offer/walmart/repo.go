@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/guilhebl/go-offer/common/config"
 	"github.com/guilhebl/go-offer/common/model"
-	"github.com/guilhebl/go-offer/common/util"
 	"github.com/guilhebl/go-offer/offer/monitor"
 	"github.com/guilhebl/go-strutil"
 	"github.com/guilhebl/go-worker-pool"
@@ -15,44 +14,90 @@ import (
 	"time"
 )
 
+// Repo is the Walmart marketplace provider, it holds the dependencies required to query the Walmart API
+// of a country: walmart.com or walmart.ca
+type Repo struct {
+	config  *config.Configuration
+	monitor *monitor.RequestMonitor
+	client  *http.Client
+
+	party  string // party name of the marketplace
+	prefix string // prefix of the config keys of the marketplace
+	logo   string
+}
+
+// builds a new Walmart provider of walmart.com using config, request monitor and http client
+func NewRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		party:   model.Walmart,
+		prefix:  "walmart",
+		logo:    "walmart-logo.png",
+	}
+}
+
+// builds a new Walmart provider of walmart.ca using config, request monitor and http client
+func NewCanadaRepo(c *config.Configuration, m *monitor.RequestMonitor, client *http.Client) *Repo {
+	return &Repo{
+		config:  c,
+		monitor: m,
+		client:  client,
+		party:   model.WalmartCanada,
+		prefix:  "walmartCa",
+		logo:    "walmart-ca-logo.png",
+	}
+}
+
+// returns the config property key of the marketplace, e.g. Endpoint reads walmartCaEndpoint for walmart.ca
+func (repo *Repo) property(key string) string {
+	return repo.config.GetProperty(repo.prefix + key)
+}
+
 // Creates Job for Searching offers from Walmart and returns a Channel with jobResults
-func SearchOffers(m map[string]string) *job.Job {
+func (repo *Repo) SearchOffers(m map[string]string) *job.Job {
 	// create output channel
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewSearchTask()
+	task := NewSearchTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
 // Searches for offers from Walmart
-func search(m map[string]string) *model.OfferList {
+func (repo *Repo) search(m map[string]string) (*model.OfferList, error) {
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.Walmart) {
+	if !repo.monitor.IsServiceAvailable(repo.party) {
 		log.Printf("Unable to acquire lock from Request Monitor")
-		return nil
+		return nil, monitor.ErrThrottled
 	}
 
 	// format walmart specific params
 	p := filterParams(m)
 
-	endpoint := config.GetProperty("walmartEndpoint")
+	// marketplaces without a trending api search their default query instead
+	trendingPath := repo.property("ProductTrendingPath")
+	if p[model.Query] == "" && trendingPath == "" {
+		p[model.Query] = repo.property("DefaultSearchQuery")
+	}
+
+	endpoint := repo.property("Endpoint")
 	isKeywordSearch := p[model.Query] != ""
 	page, _ := strconv.ParseInt(p[model.Page], 10, 0)
-	pageSize := int(config.GetIntProperty("walmartDefaultPageSize"))
-	responseGroup := config.GetProperty("walmartSearchResponseGroup")
-	apiKey := config.GetProperty("walmartApiKey")
-	affiliateId := config.GetProperty("walmartAffiliateId")
-	timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
+	pageSize := repo.pageSize(p[numItems])
+	responseGroup := repo.property("SearchResponseGroup")
+	apiKey := repo.property("ApiKey")
+	affiliateId := repo.property("AffiliateId")
 
-	var start = 1
+	start := 1
 	if page > 1 {
-		start = int(page) - 1*int(pageSize) + 1
+		start = (int(page)-1)*pageSize + 1
 	}
 
 	if isKeywordSearch {
-		path := config.GetProperty("walmartProductSearchPath")
+		path := repo.property("ProductSearchPath")
 		url := fmt.Sprintf("%s/%s", endpoint, path)
 
 		req, err := http.NewRequest("GET", url, nil)
@@ -62,20 +107,23 @@ func search(m map[string]string) *model.OfferList {
 		q.Add("responseGroup", responseGroup)
 		q.Add("apiKey", apiKey)
 		q.Add("lsPublisherId", affiliateId)
-		q.Add("query", p["query"])
+		q.Add("query", p[model.Query])
 		q.Add("start", strconv.Itoa(start))
+		q.Add(numItems, strconv.Itoa(pageSize))
+		q.Add("facet", "on")
+		for _, name := range []string{categoryId, sort, order, facetFilter, facetRange} {
+			if p[name] != "" {
+				q.Add(name, p[name])
+			}
+		}
 		req.URL.RawQuery = q.Encode()
 		url = fmt.Sprintf(req.URL.String())
-		log.Printf("Walmart search: %s", url)
-
-		client := &http.Client{
-			Timeout: timeout,
-		}
+		log.Printf("Walmart search %s: %s", repo.party, url)
 
-		resp, err := client.Do(req)
+		resp, err := repo.client.Do(req)
 		if err != nil {
-			log.Fatal("Do: ", err)
-			return nil
+			log.Printf("Do: %s", err)
+			return nil, err
 		}
 		defer resp.Body.Close()
 
@@ -83,13 +131,12 @@ func search(m map[string]string) *model.OfferList {
 
 		if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
 			log.Println(err)
-			return nil
+			return nil, err
 		}
-		return buildSearchResponse(&entity, pageSize)
+		return repo.buildSearchResponse(&entity, pageSize), nil
 	} else {
 		// search trending items if no keyword provided
-		path := config.GetProperty("walmartProductTrendingPath")
-		url := fmt.Sprintf("%s/%s", endpoint, path)
+		url := fmt.Sprintf("%s/%s", endpoint, trendingPath)
 		req, err := http.NewRequest("GET", url, nil)
 
 		req.Header.Set("Accept", "application/json")
@@ -100,16 +147,12 @@ func search(m map[string]string) *model.OfferList {
 		q.Add("lsPublisherId", affiliateId)
 		req.URL.RawQuery = q.Encode()
 		url = fmt.Sprintf(req.URL.String())
-		log.Printf("Walmart trending: %s", url)
-
-		client := &http.Client{
-			Timeout: timeout,
-		}
+		log.Printf("Walmart trending %s: %s", repo.party, url)
 
-		resp, err := client.Do(req)
+		resp, err := repo.client.Do(req)
 		if err != nil {
-			log.Fatal("Do: ", err)
-			return nil
+			log.Printf("Do: %s", err)
+			return nil, err
 		}
 		defer resp.Body.Close()
 
@@ -117,30 +160,63 @@ func search(m map[string]string) *model.OfferList {
 
 		if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
 			log.Println(err)
-			return nil
+			return nil, err
 		}
-		return buildTrendingResponse(&entity, int(page), pageSize)
+		return repo.buildTrendingResponse(&entity, int(page), pageSize), nil
 	}
 
-	return nil
+	return nil, nil
 }
 
-func buildTrendingResponse(r *TrendingResponse, page, pageSize int) *model.OfferList {
-	list := buildSearchItemList(r.Items)
+func (repo *Repo) buildTrendingResponse(r *TrendingResponse, page, pageSize int) *model.OfferList {
+	list := repo.buildSearchItemList(r.Items)
 	l := len(list)
 	o := model.NewOfferList(list, page, l/pageSize, l)
 	return o
 }
 
-func buildSearchResponse(r *SearchResponse, pageSize int) *model.OfferList {
-	list := buildSearchItemList(r.Items)
-	o := model.NewOfferList(list, r.Start/pageSize+1, r.TotalResults/pageSize, r.TotalResults)
+func (repo *Repo) buildSearchResponse(r *SearchResponse, pageSize int) *model.OfferList {
+	list := repo.buildSearchItemList(r.Items)
+	o := model.NewOfferList(list, (r.Start-1)/pageSize+1, (r.TotalResults+pageSize-1)/pageSize, r.TotalResults)
+	o.Facets = buildFacets(r.Facets)
 	return o
 }
 
-func buildSearchItemList(items []SearchItem) []model.Offer {
+// maps the facets of a search response keeping the values with offers
+func buildFacets(facets []Facet) []model.Facet {
+	if len(facets) == 0 {
+		return nil
+	}
+
+	list := make([]model.Facet, 0, len(facets))
+	for _, f := range facets {
+		values := make([]model.FacetValue, 0, len(f.FacetValues))
+		for _, v := range f.FacetValues {
+			if v.Count > 0 {
+				values = append(values, model.FacetValue{Value: v.Name, Count: v.Count})
+			}
+		}
+		list = append(list, model.NewFacet(f.Name, values))
+	}
+	return list
+}
+
+// returns the number of items of a search page: the rows per page requested up to walmartMaxPageSize,
+// defaults to walmartDefaultPageSize
+func (repo *Repo) pageSize(rows string) int {
+	pageSize := repo.config.GetIntProperty(repo.prefix + "DefaultPageSize")
+	if n, err := strconv.Atoi(rows); err == nil && n > 0 {
+		pageSize = n
+	}
+	if max := repo.config.GetIntProperty(repo.prefix + "MaxPageSize"); max > 0 && pageSize > max {
+		pageSize = max
+	}
+	return pageSize
+}
+
+func (repo *Repo) buildSearchItemList(items []SearchItem) []model.Offer {
 	list := make([]model.Offer, 0)
-	proxyRequired := config.IsProxyRequired(model.Walmart)
+	proxyRequired := repo.config.IsProxyRequired(repo.party)
 
 	for _, item := range items {
 		rate := 0.0
@@ -155,20 +231,21 @@ func buildSearchItemList(items []SearchItem) []model.Offer {
 		}
 
 		o := model.NewOffer(
-			util.GenerateStringUUID(),
+			"",
 			strconv.Itoa(item.ItemId),
 			item.Upc,
 			item.Name,
-			model.Walmart,
-			item.ProductTrackingUrl,
-			config.BuildImgUrlExternal(item.LargeImage, proxyRequired),
-			config.BuildImgUrl("walmart-logo.png"),
+			repo.party,
+			item.url(),
+			repo.config.BuildImgUrlExternal(item.LargeImage, proxyRequired),
+			repo.config.BuildImgUrl(repo.logo),
 			item.CategoryPath,
 			item.SalePrice,
 			float32(rate),
 			item.NumReviews,
 			time.Now(),
 		)
+		o.Brand = item.BrandName
 
 		list = append(list, *o)
 	}
@@ -176,6 +253,27 @@ func buildSearchItemList(items []SearchItem) []model.Offer {
 	return list
 }
 
+// Walmart search api params
+const (
+	categoryId  = "categoryId"
+	sort        = "sort"
+	order       = "order"
+	numItems    = "numItems"
+	facetFilter = "facet.filter"
+	facetRange  = "facet.range"
+)
+
+// maps the sort fields of a search to the sort options of the Walmart search api
+var sortOptions = map[string]string{
+	model.Price:      "price",
+	model.Name:       "title",
+	model.Rating:     "customerRating",
+	model.BestSeller: "bestseller",
+	model.Newest:     "new",
+}
+
+// maps search params to the Walmart search api params: keywords, page, category, brand facet filter,
+// price facet range, sort and number of items
 func filterParams(m map[string]string) map[string]string {
 	p := make(map[string]string)
 
@@ -191,11 +289,43 @@ func filterParams(m map[string]string) map[string]string {
 		p[model.Page] = "1"
 	}
 
+	p[numItems] = m[model.RowsPerPage]
+	p[categoryId] = m[model.CategoryId]
+
+	if m[model.Brand] != "" {
+		p[facetFilter] = "brand:" + m[model.Brand]
+	}
+
+	// price range bounds default to 0 and * when only one is set
+	if m[model.MinPrice] != "" || m[model.MaxPrice] != "" {
+		low, high := m[model.MinPrice], m[model.MaxPrice]
+		if low == "" {
+			low = "0"
+		}
+		if high == "" {
+			high = "*"
+		}
+		p[facetRange] = fmt.Sprintf("price:[%s TO %s]", low, high)
+	}
+
+	// only price, title and customer rating sorts have an order, bestseller and new are descending
+	if s, ok := sortOptions[m[model.SortBy]]; ok {
+		p[sort] = s
+		if s == "price" || s == "title" || s == "customerRating" {
+			p[order] = m[model.SortOrder]
+		}
+	}
+
 	return p
 }
 
+// Walmart search pages are sorted by the api so they are cached for each sort
+func (repo *Repo) SortsResults() bool {
+	return true
+}
+
 // Creates Job for fetching Product Detail and returns a Channel with jobResult
-func GetDetailJob(id, idType, country string) *job.Job {
+func (repo *Repo) GetDetailJob(id, idType, country string) *job.Job {
 	// convert to map for job to consume
 	m := make(map[string]string)
 	m["id"], m["idType"], m["country"] = id, idType, country
@@ -204,26 +334,25 @@ func GetDetailJob(id, idType, country string) *job.Job {
 	out := job.NewJobResultChannel()
 
 	// let's create a job with the payload
-	task := NewGetDetailTask()
+	task := NewGetDetailTask(repo)
 	job := job.NewJob(&task, m, out)
 	return &job
 }
 
 // Search for a specific product detail either by Id or Upc
-func GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
+func (repo *Repo) GetOfferDetail(id string, idType string, country string) *model.OfferDetail {
 	log.Printf("Get Detail: %s, %s, %s", id, idType, country)
 
 	// try to acquire lock from request Monitor
-	if !monitor.IsServiceAvailable(model.Walmart) {
+	if !repo.monitor.IsServiceAvailable(repo.party) {
 		log.Printf("Unable to acquire lock from Request Monitor")
 		return nil
 	}
 
-	endpoint := config.GetProperty("walmartEndpoint")
-	path := config.GetProperty("walmartProductDetailPath")
-	apiKey := config.GetProperty("walmartApiKey")
-	affiliateId := config.GetProperty("walmartAffiliateId")
-	timeout := time.Duration(config.GetIntProperty("marketplaceDefaultTimeout")) * time.Millisecond
+	endpoint := repo.property("Endpoint")
+	path := repo.property("ProductDetailPath")
+	apiKey := repo.property("ApiKey")
+	affiliateId := repo.property("AffiliateId")
 
 	if idType == model.Id {
 		url := fmt.Sprintf("%s/%s/%s", endpoint, path, id)
@@ -236,15 +365,11 @@ func GetOfferDetail(id string, idType string, country string) *model.OfferDetail
 		q.Add("lsPublisherId", affiliateId)
 		req.URL.RawQuery = q.Encode()
 		url = fmt.Sprintf(req.URL.String())
-		log.Printf("Walmart get: %s", url)
+		log.Printf("Walmart get %s: %s", repo.party, url)
 
-		client := &http.Client{
-			Timeout: timeout,
-		}
-
-		resp, err := client.Do(req)
+		resp, err := repo.client.Do(req)
 		if err != nil {
-			log.Fatal("Do: ", err)
+			log.Printf("Do: %s", err)
 			return nil
 		}
 		defer resp.Body.Close()
@@ -255,7 +380,7 @@ func GetOfferDetail(id string, idType string, country string) *model.OfferDetail
 			log.Println(err)
 			return nil
 		}
-		return buildProductDetail(&entity)
+		return repo.buildProductDetail(&entity)
 	} else if idType == model.Upc {
 		url := endpoint + "/" + path
 		req, err := http.NewRequest("GET", url, nil)
@@ -268,15 +393,11 @@ func GetOfferDetail(id string, idType string, country string) *model.OfferDetail
 		q.Add("lsPublisherId", affiliateId)
 		req.URL.RawQuery = q.Encode()
 		url = fmt.Sprintf(req.URL.String())
-		log.Printf("Walmart get by UPC: %s", url)
-
-		client := &http.Client{
-			Timeout: timeout,
-		}
+		log.Printf("Walmart get by UPC %s: %s", repo.party, url)
 
-		resp, err := client.Do(req)
+		resp, err := repo.client.Do(req)
 		if err != nil {
-			log.Fatal("Do: ", err)
+			log.Printf("Do: %s", err)
 			return nil
 		}
 		defer resp.Body.Close()
@@ -287,14 +408,14 @@ func GetOfferDetail(id string, idType string, country string) *model.OfferDetail
 			log.Println(err)
 			return nil
 		}
-		return buildProductDetailSearchResponse(&entity)
+		return repo.buildProductDetailSearchResponse(&entity)
 	}
 
 	return nil
 }
 
-func buildProductDetail(item *SearchItem) *model.OfferDetail {
-	proxyRequired := config.IsProxyRequired(model.Walmart)
+func (repo *Repo) buildProductDetail(item *SearchItem) *model.OfferDetail {
+	proxyRequired := repo.config.IsProxyRequired(repo.party)
 
 	rate, err := strconv.ParseFloat(item.CustomerRating, 32)
 	if err != nil {
@@ -303,14 +424,14 @@ func buildProductDetail(item *SearchItem) *model.OfferDetail {
 	}
 
 	o := model.NewOffer(
-		util.GenerateStringUUID(),
+		"",
 		strconv.Itoa(item.ItemId),
 		item.Upc,
 		item.Name,
-		model.Walmart,
-		item.ProductTrackingUrl,
-		config.BuildImgUrlExternal(item.LargeImage, proxyRequired),
-		config.BuildImgUrl("walmart-logo.png"),
+		repo.party,
+		item.url(),
+		repo.config.BuildImgUrlExternal(item.LargeImage, proxyRequired),
+		repo.config.BuildImgUrl(repo.logo),
 		item.CategoryPath,
 		item.SalePrice,
 		float32(rate),
@@ -331,11 +452,11 @@ func buildProductDetail(item *SearchItem) *model.OfferDetail {
 	return det
 }
 
-func buildProductDetailSearchResponse(item *BaseSearchResponse) *model.OfferDetail {
+func (repo *Repo) buildProductDetailSearchResponse(item *BaseSearchResponse) *model.OfferDetail {
 	if item == nil || len(item.Items) == 0 {
 		return nil
 	}
 	p := item.Items[0]
 
-	return buildProductDetail(&p)
+	return repo.buildProductDetail(&p)
 }
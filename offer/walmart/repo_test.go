@@ -0,0 +1,105 @@
+package walmart
+
+import (
+	"github.com/guilhebl/go-offer/common/config"
+	"github.com/guilhebl/go-offer/common/model"
+	"github.com/guilhebl/go-offer/offer/monitor"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFilterParams(t *testing.T) {
+	p := filterParams(map[string]string{
+		model.Name:        "skyrim",
+		model.Page:        "3",
+		model.RowsPerPage: "20",
+		model.CategoryId:  "2636_1224908",
+		model.Brand:       "Bethesda",
+		model.MinPrice:    "10",
+		model.SortBy:      model.Rating,
+		model.SortOrder:   "desc",
+	})
+	assert.Equal(t, "skyrim", p[model.Query])
+	assert.Equal(t, "3", p[model.Page])
+	assert.Equal(t, "20", p[numItems])
+	assert.Equal(t, "2636_1224908", p[categoryId])
+	assert.Equal(t, "brand:Bethesda", p[facetFilter])
+	assert.Equal(t, "price:[10 TO *]", p[facetRange])
+	assert.Equal(t, "customerRating", p[sort])
+	assert.Equal(t, "desc", p[order])
+
+	// bestseller and new sorts have no order, unknown sorts are left to the api
+	p = filterParams(map[string]string{model.SortBy: model.BestSeller, model.SortOrder: "asc"})
+	assert.Equal(t, "1", p[model.Page])
+	assert.Equal(t, "bestseller", p[sort])
+	assert.Equal(t, "", p[order])
+
+	p = filterParams(map[string]string{model.SortBy: model.NumReviews, model.MaxPrice: "50"})
+	assert.Equal(t, "", p[sort])
+	assert.Equal(t, "price:[0 TO 50]", p[facetRange])
+}
+
+// tests search params are sent to the api and its facets returned
+func TestSearch(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		data, _ := ioutil.ReadFile("testdata/walmart_sample_search_response.json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	c.SetProperty("walmartEndpoint", server.URL)
+	repo := NewRepo(c, monitor.NewRequestMonitor(c), http.DefaultClient)
+
+	list, err := repo.search(map[string]string{
+		model.Name:        "skyrim",
+		model.Page:        "2",
+		model.RowsPerPage: "50",
+		model.Brand:       "Bethesda",
+		model.SortBy:      model.Price,
+		model.SortOrder:   "asc",
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, list)
+
+	// pages are capped at walmartMaxPageSize items
+	assert.Equal(t, "26", query.Get("start"))
+	assert.Equal(t, "25", query.Get("numItems"))
+	assert.Equal(t, "brand:Bethesda", query.Get("facet.filter"))
+	assert.Equal(t, "price", query.Get("sort"))
+	assert.Equal(t, "asc", query.Get("order"))
+	assert.Equal(t, "on", query.Get("facet"))
+
+	assert.Equal(t, 38, list.TotalCount)
+	assert.Equal(t, 2, list.PageCount)
+
+	expected := []model.Facet{
+		model.NewFacet("brand", []model.FacetValue{{Value: "Bethesda", Count: 31}, {Value: "Funko", Count: 7}}),
+		model.NewFacet("price", []model.FacetValue{{Value: "$10 - $25", Count: 12}, {Value: "$25 - $50", Count: 26}}),
+	}
+	assert.Equal(t, expected, list.Facets)
+}
+
+// tests unreachable endpoints fail the call without stopping the server
+func TestSearchUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c, err := config.NewConfigurationFromFile("../../" + config.TestConfigFile)
+	assert.Nil(t, err)
+	c.SetProperty("walmartCaEndpoint", server.URL)
+	repo := NewCanadaRepo(c, monitor.NewRequestMonitor(c), http.DefaultClient)
+
+	list, err := repo.search(map[string]string{model.Name: "skyrim"})
+	assert.Nil(t, list)
+	assert.NotNil(t, err)
+	assert.Nil(t, repo.GetOfferDetail("6000197438161", model.Id, model.Canada))
+	assert.Nil(t, repo.GetOfferDetail("093155171251", model.Upc, model.Canada))
+}
@@ -5,6 +5,7 @@ type SearchItem struct {
 	ParentItemId       int     `json:"parentItemId"`
 	Upc                string  `json:"upc"`
 	Name               string  `json:"name"`
+	BrandName          string  `json:"brandName", omitempty`
 	SalePrice          float32 `json:"salePrice", omitempty`
 	CategoryPath       string  `json:"categoryPath"`
 	ShortDescription   string  `json:"shortDescription", omitempty`
@@ -18,3 +19,11 @@ type SearchItem struct {
 	CustomerRating     string  `json:"CustomerRating", omitempty`
 	NumReviews         int     `json:"numReviews", omitempty`
 }
+
+// returns the affiliate tracking url of the item, walmart.ca items without tracking link to the product page
+func (item *SearchItem) url() string {
+	if item.ProductTrackingUrl != "" {
+		return item.ProductTrackingUrl
+	}
+	return item.ProductUrl
+}
@@ -12,4 +12,5 @@ type SearchResponse struct {
 	Start         int          `json:"start"`
 	NumItems      int          `json:"numItems"`
 	Items         []SearchItem `json:"items"`
+	Facets        []Facet      `json:"facets"`
 }
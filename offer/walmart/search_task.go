@@ -1,22 +1,21 @@
 package walmart
 
-import (
-	"errors"
-	"github.com/guilhebl/go-worker-pool"
-)
+import "github.com/guilhebl/go-worker-pool"
 
 // Executable Task implementation for search
-type SearchTask struct{}
+type SearchTask struct {
+	repo *Repo
+}
 
 func (t *SearchTask) Run(payload job.Payload) job.JobResult {
-	r := search(payload.Params)
-	if r == nil {
-		return job.NewJobResult(nil, errors.New("error on search"))
+	r, err := t.repo.search(payload.Params)
+	if err != nil {
+		return job.NewJobResult(nil, err)
 	}
 
 	return job.NewJobResult(r, nil)
 }
 
-func NewSearchTask() SearchTask {
-	return SearchTask{}
+func NewSearchTask(repo *Repo) SearchTask {
+	return SearchTask{repo: repo}
 }